@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Difaal21/nebeng-dong/fare"
 	"github.com/sirupsen/logrus"
 )
 
@@ -32,6 +33,34 @@ type Config struct {
 	JWTAdmin struct {
 		PrivateKey, PublicKey []byte
 	}
+	Fare struct {
+		AverageSpeed float64
+		Tariffs      fare.Tariffs
+	}
+	PaymentGateway struct {
+		Provider  string
+		ServerKey string
+		SnapURL   string
+		APIURL    string
+		Timeout   time.Duration
+		// AllowFake opts into the in-memory gateway, whose notifications anyone knowing the key can forge.
+		AllowFake bool
+	}
+	// Administrator is the superadmin created on an empty administrators table.
+	Administrator struct {
+		Name     string
+		Email    string
+		Password string
+	}
+	Mailer struct {
+		Driver    string
+		Host      string
+		Port      string
+		Username  string
+		Password  string
+		From      string
+		Directory string
+	}
 	MariaDb struct {
 		Driver             string
 		Host               string
@@ -100,6 +129,89 @@ func (cfg *Config) jwtAdmin() {
 	cfg.JWTAdmin.PublicKey = publicKey
 }
 
+func (cfg *Config) fare() {
+	averageSpeed, _ := strconv.ParseFloat(os.Getenv("FARE_AVERAGE_SPEED_KMH"), 64)
+
+	cfg.Fare.AverageSpeed = averageSpeed
+	cfg.Fare.Tariffs = fare.Tariffs{
+		Default:      fareTariff("FARE", fare.Tariff{}),
+		VehicleTypes: make(map[string]fare.Tariff),
+	}
+
+	for _, vehicleType := range []string{"motorcycle", "car"} {
+		prefix := fmt.Sprintf("FARE_%s", strings.ToUpper(vehicleType))
+		cfg.Fare.Tariffs.VehicleTypes[vehicleType] = fareTariff(prefix, cfg.Fare.Tariffs.Default)
+	}
+}
+
+// fareTariff reads <prefix>_BASE_FARE, <prefix>_PER_KM, <prefix>_MINIMUM_FARE, <prefix>_COMMISSION_PERCENTAGE and <prefix>_COMMISSION_FLAT,
+// falling back to the given tariff for empty values.
+func fareTariff(prefix string, fallback fare.Tariff) fare.Tariff {
+	tariff := fallback
+
+	if baseFare, err := strconv.ParseInt(os.Getenv(prefix+"_BASE_FARE"), 10, 64); err == nil {
+		tariff.BaseFare = baseFare
+	}
+
+	if perKM, err := strconv.ParseInt(os.Getenv(prefix+"_PER_KM"), 10, 64); err == nil {
+		tariff.PerKM = perKM
+	}
+
+	if minimumFare, err := strconv.ParseInt(os.Getenv(prefix+"_MINIMUM_FARE"), 10, 64); err == nil {
+		tariff.MinimumFare = minimumFare
+	}
+
+	if percentage, err := strconv.ParseFloat(os.Getenv(prefix+"_COMMISSION_PERCENTAGE"), 64); err == nil {
+		tariff.CommissionPercentage = percentage
+	}
+
+	if flat, err := strconv.ParseInt(os.Getenv(prefix+"_COMMISSION_FLAT"), 10, 64); err == nil {
+		tariff.CommissionFlat = flat
+	}
+
+	return tariff
+}
+
+func (cfg *Config) paymentGateway() {
+	timeout, err := strconv.ParseInt(os.Getenv("PAYMENT_GATEWAY_TIMEOUT_SECONDS"), 10, 64)
+	if err != nil || timeout < 1 {
+		timeout = 15
+	}
+
+	cfg.PaymentGateway.Provider = os.Getenv("PAYMENT_GATEWAY_PROVIDER")
+	cfg.PaymentGateway.ServerKey = os.Getenv("PAYMENT_GATEWAY_SERVER_KEY")
+	cfg.PaymentGateway.SnapURL = os.Getenv("PAYMENT_GATEWAY_SNAP_URL")
+	cfg.PaymentGateway.APIURL = os.Getenv("PAYMENT_GATEWAY_API_URL")
+	cfg.PaymentGateway.Timeout = time.Duration(timeout) * time.Second
+	cfg.PaymentGateway.AllowFake, _ = strconv.ParseBool(os.Getenv("PAYMENT_GATEWAY_ALLOW_FAKE"))
+}
+
+func (cfg *Config) mailer() {
+	directory := os.Getenv("MAILER_DIRECTORY")
+	if directory == "" {
+		directory = "./tmp/mail"
+	}
+
+	cfg.Mailer.Driver = os.Getenv("MAILER_DRIVER")
+	cfg.Mailer.Host = os.Getenv("MAILER_HOST")
+	cfg.Mailer.Port = os.Getenv("MAILER_PORT")
+	cfg.Mailer.Username = os.Getenv("MAILER_USERNAME")
+	cfg.Mailer.Password = os.Getenv("MAILER_PASSWORD")
+	cfg.Mailer.From = os.Getenv("MAILER_FROM")
+	cfg.Mailer.Directory = directory
+}
+
+func (cfg *Config) administrator() {
+	name := os.Getenv("ADMIN_BOOTSTRAP_NAME")
+	if name == "" {
+		name = "superadmin"
+	}
+
+	cfg.Administrator.Name = name
+	cfg.Administrator.Email = os.Getenv("ADMIN_BOOTSTRAP_EMAIL")
+	cfg.Administrator.Password = os.Getenv("ADMIN_BOOTSTRAP_PASSWORD")
+}
+
 func (cfg *Config) app() {
 	appName := os.Getenv("APP_NAME")
 	port := os.Getenv("PORT")
@@ -141,6 +253,10 @@ func Load() *Config {
 	cfg.jwtAdmin()
 	cfg.mariaDb()
 	cfg.basicAuth()
+	cfg.fare()
+	cfg.paymentGateway()
+	cfg.mailer()
+	cfg.administrator()
 	cfg.logFormatter()
 	return cfg
 }
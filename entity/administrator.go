@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+type Administrator struct {
+	ID                    int64      `json:"id"`
+	Name                  string     `json:"name"`
+	Email                 string     `json:"email"`
+	Password              string     `json:"-"`
+	Role                  string     `json:"role"`
+	IsActive              bool       `json:"isActive"`
+	SessionsInvalidatedAt *time.Time `json:"-"`
+	CreatedAt             time.Time  `json:"createdAt"`
+	UpdatedAt             time.Time  `json:"updatedAt"`
+}
@@ -0,0 +1,23 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditLog records one administrator mutation, Before and After are JSON snapshots of the target.
+type AuditLog struct {
+	ID         int64           `json:"id"`
+	ActorId    int64           `json:"actorId"`
+	ActorEmail string          `json:"actorEmail"`
+	ActorRole  string          `json:"actorRole"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"targetType"`
+	TargetId   *int64          `json:"targetId"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	StatusCode int             `json:"statusCode"`
+	IPAddress  string          `json:"ipAddress"`
+	UserAgent  string          `json:"userAgent"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
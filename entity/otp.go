@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+// OTP is a one-time code texted to a phone number, only its SHA-256 is stored.
+type OTP struct {
+	ID          int64      `json:"id"`
+	PhoneNumber string     `json:"phoneNumber"`
+	Purpose     string     `json:"purpose"`
+	CodeHash    string     `json:"-"`
+	Attempts    int64      `json:"attempts"`
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	UsedAt      *time.Time `json:"usedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
@@ -7,10 +7,14 @@ type Passengers struct {
 	UserId                int64          `json:"userId,omitempty"`
 	ShareRideId           int64          `json:"shareRideId,omitempty"`
 	Status                int16          `json:"status"`
+	PickupCoordinate      Coordinate     `json:"pickupCoordinate"`
+	PickupNote            *string        `json:"pickupNote"`
 	DestinationCoordinate Coordinate     `json:"destinationCoordinate"`
 	Distance              float64        `json:"distance"`
 	CreatedAt             time.Time      `json:"createdAt"`
 	DroppedAt             *time.Time     `json:"droppedAt"`
+	PickedUpAt            *time.Time     `json:"pickedUpAt,omitempty"`
+	TravelledDistance     *float64       `json:"travelledDistance,omitempty"`
 	Payment               []*Payment     `json:"payment"`
 	User                  *UserInVehicle `json:"user"`
 }
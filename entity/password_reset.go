@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// PasswordReset stores only the SHA-256 of the token mailed to the user.
+type PasswordReset struct {
+	ID        int64      `json:"id"`
+	UserId    int64      `json:"userId"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
@@ -3,14 +3,18 @@ package entity
 import "time"
 
 type Payment struct {
-	ID             int64            `json:"id"`
-	RecipientId    int64            `json:"recipientId,omitempty"`
-	PassengerId    int64            `json:"passengerId,omitempty"`
-	UserId         int64            `json:"userId,omitempty"`
-	Status         string           `json:"status"`
-	TotalAmount    int64            `json:"totalAmount"`
-	CreatedAt      time.Time        `json:"createdAt"`
-	PaymentDetails []PaymentDetails `json:"paymentDetails"`
+	ID              int64            `json:"id"`
+	RecipientId     int64            `json:"recipientId,omitempty"`
+	PassengerId     int64            `json:"passengerId,omitempty"`
+	UserId          int64            `json:"userId,omitempty"`
+	Status          string           `json:"status"`
+	BaseFare        int64            `json:"baseFare,omitempty"`
+	DistanceFare    int64            `json:"distanceFare,omitempty"`
+	TotalAmount     int64            `json:"totalAmount"`
+	Commission      int64            `json:"commission,omitempty"`
+	CancellationFee int64            `json:"cancellationFee,omitempty"`
+	CreatedAt       time.Time        `json:"createdAt"`
+	PaymentDetails  []PaymentDetails `json:"paymentDetails"`
 }
 
 type PaymentDetails struct {
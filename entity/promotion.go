@@ -0,0 +1,31 @@
+package entity
+
+import "time"
+
+type Promotion struct {
+	ID                int64     `json:"id"`
+	Code              string    `json:"code"`
+	Name              string    `json:"name"`
+	DiscountType      string    `json:"discountType"`
+	DiscountValue     int64     `json:"discountValue"`
+	MaxDiscount       int64     `json:"maxDiscount"`
+	MinimumFare       int64     `json:"minimumFare"`
+	FirstRideOnly     bool      `json:"firstRideOnly"`
+	UsageLimit        int64     `json:"usageLimit"`
+	UsageLimitPerUser int64     `json:"usageLimitPerUser"`
+	StartsAt          time.Time `json:"startsAt"`
+	EndsAt            time.Time `json:"endsAt"`
+	IsActive          bool      `json:"isActive"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+type PromotionRedemption struct {
+	ID          int64     `json:"id"`
+	PromotionId int64     `json:"promotionId"`
+	UserId      int64     `json:"userId"`
+	PaymentId   int64     `json:"paymentId"`
+	Discount    int64     `json:"discount"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+type Rating struct {
+	ID          int64     `json:"id"`
+	ShareRideId int64     `json:"shareRideId"`
+	PassengerId int64     `json:"passengerId"`
+	RaterId     int64     `json:"raterId"`
+	RateeId     int64     `json:"rateeId"`
+	RaterRole   string    `json:"raterRole"`
+	Stars       int16     `json:"stars"`
+	Tags        []string  `json:"tags"`
+	Comment     *string   `json:"comment"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// Receipt is what a passenger keeps of a finished trip.
+type Receipt struct {
+	Number            string           `json:"number"`
+	ShareRideId       int64            `json:"shareRideId"`
+	PassengerId       int64            `json:"passengerId"`
+	PassengerUserId   int64            `json:"passengerUserId"`
+	PassengerName     string           `json:"passengerName"`
+	PassengerEmail    string           `json:"passengerEmail"`
+	DriverId          int64            `json:"driverId"`
+	DriverName        string           `json:"driverName"`
+	VehicleType       string           `json:"vehicleType"`
+	VehicleModel      string           `json:"vehicleModel"`
+	LicensePlate      string           `json:"licensePlate"`
+	BookedAt          time.Time        `json:"bookedAt"`
+	PickedUpAt        *time.Time       `json:"pickedUpAt"`
+	DroppedAt         *time.Time       `json:"droppedAt"`
+	Distance          float64          `json:"distance"`
+	TravelledDistance *float64         `json:"travelledDistance"`
+	BaseFare          int64            `json:"baseFare"`
+	DistanceFare      int64            `json:"distanceFare"`
+	TotalAmount       int64            `json:"totalAmount"`
+	PaymentStatus     string           `json:"paymentStatus"`
+	PaymentDetails    []PaymentDetails `json:"paymentDetails"`
+}
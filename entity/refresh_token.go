@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// RefreshToken is one link of a rotation chain, all links of a login share the FamilyId.
+// Only the SHA-256 of the token is stored.
+type RefreshToken struct {
+	ID         int64      `json:"id"`
+	UserId     int64      `json:"userId"`
+	FamilyId   string     `json:"familyId"`
+	TokenHash  string     `json:"-"`
+	DeviceName *string    `json:"deviceName"`
+	UserAgent  string     `json:"userAgent"`
+	IPAddress  string     `json:"ipAddress"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	UsedAt     *time.Time `json:"usedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
@@ -3,28 +3,35 @@ package entity
 import "time"
 
 type ShareRide struct {
-	ID           int64              `json:"id"`
-	DriverId     int64              `json:"driverId,omitempty"`
-	IsFull       bool               `json:"isFull"`
-	DriverStatus int16              `json:"driverStatus"`
-	CreatedAt    time.Time          `json:"createdAt"`
-	FinishedAt   *time.Time         `json:"finishedAt"`
-	Passengers   []*Passengers      `json:"passengers"`
-	Driver       *DriverInShareRide `json:"driver"`
+	ID             int64              `json:"id"`
+	DriverId       int64              `json:"driverId,omitempty"`
+	IsFull         bool               `json:"isFull"`
+	DriverStatus   int16              `json:"driverStatus"`
+	CreatedAt      time.Time          `json:"createdAt"`
+	FinishedAt     *time.Time         `json:"finishedAt"`
+	Passengers     []*Passengers      `json:"passengers"`
+	Driver         *DriverInShareRide `json:"driver"`
+	DriverDistance float64            `json:"driverDistance,omitempty"`
+	AvailableSeats int                `json:"availableSeats,omitempty"`
 }
 
 type DriverInShareRide struct {
-	ID          int64                     `json:"id"`
-	Name        string                    `json:"name"`
-	Email       string                    `json:"email"`
-	PhoneNumber string                    `json:"phoneNumber"`
-	Vehicle     *DriverVehicleInShareRide `json:"vehicle,omitempty"`
+	ID            int64                     `json:"id"`
+	Name          string                    `json:"name"`
+	Email         string                    `json:"email"`
+	PhoneNumber   string                    `json:"phoneNumber"`
+	RatingAverage float64                   `json:"ratingAverage"`
+	RatingCount   int64                     `json:"ratingCount"`
+	Vehicle       *DriverVehicleInShareRide `json:"vehicle,omitempty"`
+	Coordinate    *Coordinate               `json:"-"`
 }
 
 type DriverVehicleInShareRide struct {
 	ID           int64  `json:"id"`
+	Type         string `json:"type"`
 	Model        string `json:"model"`
 	LicensePlate string `json:"licensePlate"`
 	Manufacture  string `json:"manufacture"`
 	InUse        bool   `json:"inUse"`
+	Capacity     int    `json:"capacity,omitempty"`
 }
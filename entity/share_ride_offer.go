@@ -0,0 +1,18 @@
+package entity
+
+import "time"
+
+type ShareRideOffer struct {
+	ID          int64       `json:"id"`
+	BookingId   int64       `json:"bookingId"`
+	PassengerId int64       `json:"passengerId"`
+	ShareRideId int64       `json:"shareRideId"`
+	DriverId    int64       `json:"driverId"`
+	UserId      int64       `json:"userId"`
+	VehicleType string      `json:"vehicleType"`
+	Status      string      `json:"status"`
+	ExpiresAt   time.Time   `json:"expiresAt"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	RespondedAt *time.Time  `json:"respondedAt"`
+	Passenger   *Passengers `json:"passenger,omitempty"`
+}
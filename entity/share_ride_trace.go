@@ -0,0 +1,11 @@
+package entity
+
+import "time"
+
+type ShareRideTrace struct {
+	ID          int64      `json:"id"`
+	ShareRideId int64      `json:"shareRideId"`
+	DriverId    int64      `json:"driverId"`
+	Coordinate  Coordinate `json:"coordinate"`
+	RecordedAt  time.Time  `json:"recordedAt"`
+}
@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+type TopUp struct {
+	ID            int64      `json:"id"`
+	UserId        int64      `json:"userId"`
+	OrderId       string     `json:"orderId"`
+	Provider      string     `json:"provider"`
+	Amount        int64      `json:"amount"`
+	Status        string     `json:"status"`
+	TransactionId *string    `json:"transactionId"`
+	Token         string     `json:"token"`
+	RedirectURL   string     `json:"redirectUrl"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	SettledAt     *time.Time `json:"settledAt"`
+}
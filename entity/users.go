@@ -14,7 +14,10 @@ type Users struct {
 	Coordinate      *Coordinate       `json:"coordinate"`
 	IsEmailVerified bool              `json:"isEmailVerified"`
 	EmailVerifiedAt *time.Time        `json:"emailVerifiedAt"`
+	IsPhoneVerified bool              `json:"isPhoneVerified"`
 	IsDriver        bool              `json:"isDriver"`
+	RatingAverage   float64           `json:"ratingAverage"`
+	RatingCount     int64             `json:"ratingCount"`
 	Vehicles        []*VehiclesInUser `json:"vehicles,omitempty"`
 	CreatedAt       time.Time         `json:"createdAt"`
 	UpdatedAt       *time.Time        `json:"updatedAt"`
@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+type WalletTransaction struct {
+	ID            int64     `json:"id"`
+	UserId        int64     `json:"userId"`
+	EntryType     string    `json:"entryType"`
+	Amount        int64     `json:"amount"`
+	BalanceAfter  int64     `json:"balanceAfter"`
+	ReferenceType string    `json:"referenceType"`
+	ReferenceId   *int64    `json:"referenceId"`
+	Description   string    `json:"description"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
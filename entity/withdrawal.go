@@ -0,0 +1,30 @@
+package entity
+
+import "time"
+
+type BankAccount struct {
+	ID            int64     `json:"id"`
+	UserId        int64     `json:"userId"`
+	BankCode      string    `json:"bankCode"`
+	AccountNumber string    `json:"accountNumber"`
+	AccountHolder string    `json:"accountHolder"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type Withdrawal struct {
+	ID              int64        `json:"id"`
+	UserId          int64        `json:"userId"`
+	BankAccountId   int64        `json:"bankAccountId"`
+	Amount          int64        `json:"amount"`
+	Status          string       `json:"status"`
+	RejectionReason *string      `json:"rejectionReason"`
+	PayoutReference *string      `json:"payoutReference"`
+	RequestedAt     time.Time    `json:"requestedAt"`
+	ApprovedAt      *time.Time   `json:"approvedAt"`
+	ApprovedBy      *int64       `json:"approvedBy"`
+	RejectedAt      *time.Time   `json:"rejectedAt"`
+	RejectedBy      *int64       `json:"rejectedBy"`
+	PaidAt          *time.Time   `json:"paidAt"`
+	PaidBy          *int64       `json:"paidBy"`
+	BankAccount     *BankAccount `json:"bankAccount,omitempty"`
+}
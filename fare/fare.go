@@ -0,0 +1,64 @@
+package fare
+
+import (
+	"context"
+	"math"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+)
+
+type Fare struct {
+	VehicleType  string  `json:"vehicleType"`
+	Distance     float64 `json:"distance"`
+	Duration     int64   `json:"duration"`
+	BaseFare     int64   `json:"baseFare"`
+	DistanceFare int64   `json:"distanceFare"`
+	TotalAmount  int64   `json:"totalAmount"`
+}
+
+type Engine interface {
+	Calculate(ctx context.Context, vehicleType string, pickup, destination entity.Coordinate) (fare *Fare, err error)
+	Commission(vehicleType string, totalAmount int64) int64
+}
+
+type EngineImpl struct {
+	RouteEstimator RouteEstimator
+	Tariffs        Tariffs
+}
+
+func NewEngine(routeEstimator RouteEstimator, tariffs Tariffs) Engine {
+	return &EngineImpl{
+		RouteEstimator: routeEstimator,
+		Tariffs:        tariffs,
+	}
+}
+
+func (e *EngineImpl) Calculate(ctx context.Context, vehicleType string, pickup, destination entity.Coordinate) (fare *Fare, err error) {
+	route, err := e.RouteEstimator.Estimate(ctx, pickup, destination)
+	if err != nil {
+		return
+	}
+
+	tariff := e.Tariffs.For(vehicleType)
+
+	distanceFare := int64(math.Round(route.Distance * float64(tariff.PerKM)))
+	totalAmount := tariff.BaseFare + distanceFare
+	if totalAmount < tariff.MinimumFare {
+		totalAmount = tariff.MinimumFare
+	}
+
+	fare = &Fare{
+		VehicleType:  vehicleType,
+		Distance:     math.Round(route.Distance*100) / 100,
+		Duration:     int64(route.Duration.Seconds()),
+		BaseFare:     tariff.BaseFare,
+		DistanceFare: distanceFare,
+		TotalAmount:  totalAmount,
+	}
+
+	return
+}
+
+func (e *EngineImpl) Commission(vehicleType string, totalAmount int64) int64 {
+	return e.Tariffs.For(vehicleType).Commission(totalAmount)
+}
@@ -0,0 +1,39 @@
+package fare
+
+import (
+	"context"
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/helpers/geo"
+)
+
+type Route struct {
+	Distance float64 // km
+	Duration time.Duration
+}
+
+// RouteEstimator estimates the travelled route between two coordinates.
+// Haversine is the default, a routing backend can be plugged in by implementing this interface.
+type RouteEstimator interface {
+	Estimate(ctx context.Context, origin, destination entity.Coordinate) (route *Route, err error)
+}
+
+type HaversineEstimator struct {
+	AverageSpeed float64 // km/h
+}
+
+func NewHaversineEstimator(averageSpeed float64) RouteEstimator {
+	return &HaversineEstimator{AverageSpeed: averageSpeed}
+}
+
+func (h *HaversineEstimator) Estimate(ctx context.Context, origin, destination entity.Coordinate) (route *Route, err error) {
+	distance := geo.Distance(origin, destination)
+
+	route = &Route{Distance: distance}
+	if h.AverageSpeed > 0 {
+		route.Duration = time.Duration(distance / h.AverageSpeed * float64(time.Hour))
+	}
+
+	return
+}
@@ -0,0 +1,37 @@
+package fare
+
+import "math"
+
+type Tariff struct {
+	BaseFare    int64 `json:"baseFare"`
+	PerKM       int64 `json:"perKm"`
+	MinimumFare int64 `json:"minimumFare"`
+	// platform cut of every trip, a percentage of the fare plus a flat fee
+	CommissionPercentage float64 `json:"commissionPercentage"`
+	CommissionFlat       int64   `json:"commissionFlat"`
+}
+
+// Commission is the platform's share of the fare, never more than the fare itself.
+func (t Tariff) Commission(totalAmount int64) int64 {
+	commission := int64(math.Round(float64(totalAmount)*t.CommissionPercentage/100)) + t.CommissionFlat
+	if commission > totalAmount {
+		return totalAmount
+	}
+	if commission < 0 {
+		return 0
+	}
+	return commission
+}
+
+type Tariffs struct {
+	Default      Tariff
+	VehicleTypes map[string]Tariff
+}
+
+// For returns the tariff of the vehicle type, or the default tariff when the vehicle type has none.
+func (t Tariffs) For(vehicleType string) Tariff {
+	if tariff, ok := t.VehicleTypes[vehicleType]; ok {
+		return tariff
+	}
+	return t.Default
+}
@@ -0,0 +1,25 @@
+package geo
+
+import (
+	"math"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+)
+
+// EarthRadius is the mean radius of the earth in kilometers.
+const EarthRadius = 6371.0
+
+// Distance returns the great-circle distance in kilometers between two coordinates using the haversine formula.
+func Distance(origin, destination entity.Coordinate) float64 {
+	originLatitude := degreesToRadians(origin.Latitude)
+	destinationLatitude := degreesToRadians(destination.Latitude)
+	deltaLatitude := degreesToRadians(destination.Latitude - origin.Latitude)
+	deltaLongitude := degreesToRadians(destination.Longitude - origin.Longitude)
+
+	a := math.Pow(math.Sin(deltaLatitude/2), 2) + math.Cos(originLatitude)*math.Cos(destinationLatitude)*math.Pow(math.Sin(deltaLongitude/2), 2)
+	return EarthRadius * 2 * math.Asin(math.Sqrt(a))
+}
+
+func degreesToRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
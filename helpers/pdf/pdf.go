@@ -0,0 +1,135 @@
+// Package pdf writes simple text documents as PDF without external dependencies, enough for receipts.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	pageWidth  = 595.0 // A4 in points
+	pageHeight = 842.0
+	margin     = 50.0
+)
+
+type line struct {
+	text string
+	size float64
+	bold bool
+	x, y float64
+}
+
+// Document lays out lines from the top of an A4 page, starting a new page when one is full.
+type Document struct {
+	pages [][]line
+	y     float64
+}
+
+func New() *Document {
+	return &Document{pages: [][]line{{}}, y: pageHeight - margin}
+}
+
+// Text writes a line in the regular font.
+func (d *Document) Text(text string, size float64) {
+	d.add(size, line{text: text, size: size, x: margin})
+}
+
+// Bold writes a line in the bold font.
+func (d *Document) Bold(text string, size float64) {
+	d.add(size, line{text: text, size: size, bold: true, x: margin})
+}
+
+// Row writes a label on the left and its value in a second column, e.g. a fare breakdown.
+func (d *Document) Row(label, value string, size float64) {
+	d.add(size, line{text: label, size: size, x: margin}, line{text: value, size: size, x: pageWidth / 2})
+}
+
+// Space leaves an empty gap of the given height.
+func (d *Document) Space(height float64) {
+	d.y -= height
+}
+
+// add writes the given lines side by side on the next row.
+func (d *Document) add(size float64, lines ...line) {
+	if d.y-size*1.5 < margin {
+		d.pages = append(d.pages, []line{})
+		d.y = pageHeight - margin
+	}
+
+	d.y -= size * 1.5
+	page := len(d.pages) - 1
+
+	for _, l := range lines {
+		l.y = d.y
+		d.pages[page] = append(d.pages[page], l)
+	}
+}
+
+// Bytes renders the document.
+func (d *Document) Bytes() []byte {
+	var (
+		buf     bytes.Buffer
+		offsets []int
+	)
+
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	// 1 catalog, 2 pages, 3 regular font, 4 bold font, then a page and its content stream per page
+	pageIds := make([]string, len(d.pages))
+	for i := range d.pages {
+		pageIds[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(pageIds, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, lines := range d.pages {
+		var content bytes.Buffer
+		for _, l := range lines {
+			font := "F1"
+			if l.bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&content, "BT /%s %.1f Tf %.1f %.1f Td (%s) Tj ET\n", font, l.size, l.x, l.y, escape(l.text))
+		}
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, 6+i*2))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.Bytes()
+}
+
+// escape keeps the text inside a PDF string literal, characters outside Latin-1 are replaced.
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteRune(' ')
+		case r > 255:
+			b.WriteRune('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,32 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// File writes every message as an .eml file into Directory instead of sending it.
+type File struct {
+	Directory string
+	From      string
+}
+
+func NewFile(directory, from string) Mailer {
+	return &File{
+		Directory: directory,
+		From:      from,
+	}
+}
+
+func (f *File) Send(ctx context.Context, message *Message) (err error) {
+	if err = os.MkdirAll(f.Directory, 0o755); err != nil {
+		return
+	}
+
+	filename := filepath.Join(f.Directory, fmt.Sprintf("%d.eml", time.Now().UnixNano()))
+
+	return os.WriteFile(filename, compose(f.From, message), 0o644)
+}
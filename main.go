@@ -1,21 +1,33 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/Difaal21/nebeng-dong/config"
 	"github.com/Difaal21/nebeng-dong/databases/mariadb"
+	"github.com/Difaal21/nebeng-dong/fare"
 	"github.com/Difaal21/nebeng-dong/jwt"
+	"github.com/Difaal21/nebeng-dong/mailer"
 	"github.com/Difaal21/nebeng-dong/middleware"
 	"github.com/Difaal21/nebeng-dong/modules/administrators"
+	"github.com/Difaal21/nebeng-dong/modules/audit"
+	"github.com/Difaal21/nebeng-dong/modules/otp"
 	"github.com/Difaal21/nebeng-dong/modules/passengers"
 	"github.com/Difaal21/nebeng-dong/modules/payment"
+	"github.com/Difaal21/nebeng-dong/modules/payment/gateway"
+	"github.com/Difaal21/nebeng-dong/modules/promotions"
+	"github.com/Difaal21/nebeng-dong/modules/ratings"
 	shareride "github.com/Difaal21/nebeng-dong/modules/share-ride"
 	"github.com/Difaal21/nebeng-dong/modules/users"
 	"github.com/Difaal21/nebeng-dong/modules/vehicles"
+	"github.com/Difaal21/nebeng-dong/modules/wallet"
+	"github.com/Difaal21/nebeng-dong/modules/withdrawals"
+	"github.com/Difaal21/nebeng-dong/pubsub"
 	"github.com/Difaal21/nebeng-dong/responses"
 	"github.com/Difaal21/nebeng-dong/server"
 	"github.com/gin-gonic/gin"
@@ -42,12 +54,9 @@ func main() {
 	publicKey := jwt.GetRSAPublicKey(cfg.JWT.PublicKey)
 	jsonWebToken := jwt.NewJWT(privateKey, publicKey)
 
-	session := middleware.NewSession(jsonWebToken)
-
 	privateKeyAdmin := jwt.GetRSAPrivateKey(cfg.JWTAdmin.PrivateKey)
 	publicKeyAdmin := jwt.GetRSAPublicKey(cfg.JWTAdmin.PublicKey)
 	jsonWebTokenAdmin := jwt.NewJWT(privateKeyAdmin, publicKeyAdmin)
-	sessionAdmin := middleware.NewSession(jsonWebTokenAdmin)
 
 	mariaDb := mariadb.NewClientImpl(cfg.MariaDb.Driver, cfg.MariaDb.DSN)
 	db, err := mariaDb.Connect(cfg.MariaDb.MaxOpenConnections, cfg.MariaDb.MaxIdleConnections)
@@ -55,32 +64,108 @@ func main() {
 		logger.Fatal(err)
 	}
 
+	userRepository := users.NewRepositoryImpl(db, logger)
+	refreshTokenRepository := users.NewRefreshTokenRepositoryImpl(db, logger)
+	revokedTokenRepository := users.NewRevokedTokenRepositoryImpl(db, logger)
+
+	session := middleware.NewSession(jsonWebToken, users.NewSessionRevocation(userRepository, revokedTokenRepository))
+	administratorRepository := administrators.NewRepositoryImpl(db, logger)
+	if err := administrators.Bootstrap(context.Background(), administratorRepository, cfg.Administrator.Name, cfg.Administrator.Email, cfg.Administrator.Password); err != nil {
+		logger.Fatal(err)
+	}
+
+	sessionAdmin := middleware.NewSession(jsonWebTokenAdmin, administrators.NewSessionRevocation(administratorRepository))
+	authorizationAdmin := middleware.NewAuthorization(sessionAdmin, administrators.Permissions)
+
 	gin.SetMode(cfg.Application.GinMode)
 	router := gin.New()
+	router.Use(middleware.RequestMetadata)
 
 	router.GET("/nebengdong-service", index)
 	router.NoRoute(notFound)
 
+	var mail mailer.Mailer = mailer.NewFile(cfg.Mailer.Directory, cfg.Mailer.From)
+	if cfg.Mailer.Driver == "smtp" {
+		mail = mailer.NewSMTP(cfg.Mailer.Host, cfg.Mailer.Port, cfg.Mailer.Username, cfg.Mailer.Password, cfg.Mailer.From)
+	}
+
 	vehicleRepository := vehicles.NewRepositoryImpl(db, logger)
 	vehicleUsecase := vehicles.NewUsecaseImpl(vehicleRepository, logger, jsonWebToken)
 	vehicles.NewHTTPHandler(router, session, vehicleUsecase)
 
-	userRepository := users.NewRepositoryImpl(db, logger)
-	userUsecase := users.NewUsecaseImpl(userRepository, logger, vehicleRepository, jsonWebToken)
+	otpRepository := otp.NewRepositoryImpl(db, logger)
+	otpService := otp.NewServiceImpl(otpRepository, logger)
+	otpUsecase := otp.NewUsecaseImpl(otpRepository, otp.NewLogSMSSender(logger), logger)
+	otp.NewHTTPHandler(router, basicAuth, otpUsecase)
+
+	passwordResetRepository := users.NewPasswordResetRepositoryImpl(db, logger)
+	userUsecase := users.NewUsecaseImpl(userRepository, logger, vehicleRepository, jsonWebToken, mail, passwordResetRepository, refreshTokenRepository, revokedTokenRepository, otpService)
 	users.NewHTTPHandler(router, basicAuth, session, userUsecase)
 
-	adminUsecase := administrators.NewUsecaseImpl(logger, jsonWebTokenAdmin, userRepository)
-	administrators.NewHTTPHandler(router, basicAuth, sessionAdmin, adminUsecase)
+	if cfg.PaymentGateway.ServerKey == "" {
+		logger.Fatal("PAYMENT_GATEWAY_SERVER_KEY is required")
+	}
+
+	var paymentGateway gateway.Gateway
+	switch cfg.PaymentGateway.Provider {
+	case "midtrans":
+		paymentGateway = gateway.NewMidtrans(cfg.PaymentGateway.ServerKey, cfg.PaymentGateway.SnapURL, cfg.PaymentGateway.APIURL, cfg.PaymentGateway.Timeout)
+	case "fake":
+		if !cfg.PaymentGateway.AllowFake || cfg.Application.Environment == "production" {
+			logger.Fatal("the fake payment gateway needs PAYMENT_GATEWAY_ALLOW_FAKE=true and a non-production ENVIRONMENT")
+		}
+		paymentGateway = gateway.NewFake(cfg.PaymentGateway.ServerKey)
+	default:
+		logger.Fatalf("unknown PAYMENT_GATEWAY_PROVIDER %q", cfg.PaymentGateway.Provider)
+	}
+
+	walletRepository := wallet.NewRepositoryImpl(db, logger)
+	walletTopUpRepository := wallet.NewTopUpRepositoryImpl(db, logger)
+	walletLedger := wallet.NewLedgerImpl(walletRepository, userRepository, logger)
+	walletUsecase := wallet.NewUsecaseImpl(walletRepository, walletTopUpRepository, walletLedger, paymentGateway, logger)
+	wallet.NewHTTPHandler(router, session, walletUsecase)
+
+	withdrawalRepository := withdrawals.NewRepositoryImpl(db, logger)
+	bankAccountRepository := withdrawals.NewBankAccountRepositoryImpl(db, logger)
+	withdrawalUsecase := withdrawals.NewUsecaseImpl(withdrawalRepository, bankAccountRepository, walletLedger, logger)
+	withdrawals.NewHTTPHandler(router, session, withdrawalUsecase)
 
 	passengersRepository := passengers.NewRepositoryImpl(db, logger)
 
 	paymentRepository := payment.NewRepositoryImpl(db, logger)
 	paymentDetailRepository := payment.NewPaymentDetailRepositoryImpl(db, logger)
 
+	routeEstimator := fare.NewHaversineEstimator(cfg.Fare.AverageSpeed)
+	fareEngine := fare.NewEngine(routeEstimator, cfg.Fare.Tariffs)
+
+	locationBroker := pubsub.NewLocalBroker()
+
+	promotionRepository := promotions.NewRepositoryImpl(db, logger)
+	promotionRedemptionRepository := promotions.NewRedemptionRepositoryImpl(db, logger)
+	promotionService := promotions.NewServiceImpl(promotionRepository, promotionRedemptionRepository, logger)
+
 	shareRideRepository := shareride.NewRepositoryImpl(db, logger)
-	shareRideUsecase := shareride.NewUsecaseImpl(shareRideRepository, logger, jsonWebToken, passengersRepository, paymentRepository, paymentDetailRepository, userRepository)
+	shareRideOfferRepository := shareride.NewOfferRepositoryImpl(db, logger)
+	shareRideTraceRepository := shareride.NewTraceRepositoryImpl(db, logger)
+	shareRideUsecase := shareride.NewUsecaseImpl(shareRideRepository, shareRideOfferRepository, shareRideTraceRepository, logger, jsonWebToken, passengersRepository, paymentRepository, paymentDetailRepository, userRepository, fareEngine, routeEstimator, locationBroker, walletLedger, promotionService, mail)
 	shareride.NewHTTPHandler(router, session, shareRideUsecase)
 
+	ratingRepository := ratings.NewRepositoryImpl(db, logger)
+	ratingUsecase := ratings.NewUsecaseImpl(ratingRepository, passengersRepository, userRepository, logger)
+	ratings.NewHTTPHandler(router, session, ratingUsecase)
+
+	auditRepository := audit.NewRepositoryImpl(db, logger)
+	auditRecorder := audit.NewRecorderImpl(auditRepository, logger)
+	auditUsecase := audit.NewUsecaseImpl(auditRepository, logger)
+	audit.NewHTTPHandler(router, authorizationAdmin, auditUsecase)
+
+	adminUsecase := administrators.NewUsecaseImpl(logger, jsonWebTokenAdmin, userRepository, shareRideTraceRepository, walletLedger, withdrawalRepository, promotionRepository, administratorRepository)
+	adminUsecase = audit.NewAdministrators(adminUsecase, auditRecorder, userRepository, withdrawalRepository, promotionRepository, administratorRepository, logger)
+	administrators.NewHTTPHandler(router, basicAuth, authorizationAdmin, adminUsecase)
+
+	offerExpiryWorker := shareride.NewOfferExpiryWorker(shareRideUsecase, logger, 5*time.Second)
+	offerExpiryWorker.Start()
+
 	handler := cors.New(cors.Options{
 		AllowedOrigins:   cfg.Application.AllowedOrigins,
 		AllowedMethods:   []string{http.MethodPost, http.MethodGet, http.MethodPut, http.MethodDelete},
@@ -97,7 +182,9 @@ func main() {
 	<-sigterm
 
 	// closing service for a gracefull shutdown.
+	locationBroker.Close()
 	server.Close()
+	offerExpiryWorker.Close()
 	mariaDb.Disconnect(db)
 }
 
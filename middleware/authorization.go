@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"github.com/Difaal21/nebeng-dong/responses"
+	"github.com/gin-gonic/gin"
+)
+
+// Authorization opens a session like Session.Verify, and lets it through only when the role in the token has the
+// permission of the route.
+type Authorization struct {
+	Session *Session
+	// Permissions lists the roles granted each permission.
+	Permissions map[string][]string
+}
+
+func NewAuthorization(session *Session, permissions map[string][]string) *Authorization {
+	return &Authorization{
+		Session:     session,
+		Permissions: permissions,
+	}
+}
+
+func (authorization *Authorization) Require(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := authorization.Session.authenticate(c)
+		if !ok {
+			return
+		}
+
+		if !authorization.granted(claims.Role, permission) {
+			responses.REST(c, httpResponse.Forbidden("").NewResponses(nil, "your role is not allowed to do this"))
+			return
+		}
+
+		next(c, claims)
+	}
+}
+
+func (authorization *Authorization) granted(role string, permission string) bool {
+	for _, granted := range authorization.Permissions[permission] {
+		if role == granted {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestMetadata puts the client IP and user agent in the request context for model.GetRequestMetadata.
+func RequestMetadata(c *gin.Context) {
+	metadata := model.RequestMetadata{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+
+	c.Request = c.Request.WithContext(model.WithRequestMetadata(c.Request.Context(), metadata))
+	c.Next()
+}
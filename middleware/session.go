@@ -10,17 +10,35 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// Revocation tells whether a valid session token was revoked before it expired, e.g. after a password reset.
+type Revocation interface {
+	Revoked(ctx context.Context, claims *model.UserBearer) (revoked bool, err error)
+}
+
 type Session struct {
 	JSONWebToken jwt.JSONWebToken
+	Revocation   Revocation
 }
 
-func NewSession(jwt jwt.JSONWebToken) *Session {
+// NewSession checks tokens against revocation when it is not nil.
+func NewSession(jwt jwt.JSONWebToken, revocation Revocation) *Session {
 	return &Session{
 		JSONWebToken: jwt,
+		Revocation:   revocation,
 	}
 }
 
 func (session *Session) Verify(c *gin.Context) {
+	claims, ok := session.authenticate(c)
+	if !ok {
+		return
+	}
+
+	next(c, claims)
+}
+
+// authenticate returns the claims of a valid and unrevoked token, otherwise it aborts with the error response.
+func (session *Session) authenticate(c *gin.Context) (claims *model.UserBearer, ok bool) {
 	ctx := c.Request.Context()
 
 	authorizationHeader := c.Request.Header.Get("Authorization")
@@ -37,13 +55,37 @@ func (session *Session) Verify(c *gin.Context) {
 
 	tokenString := bearerToken[1]
 
-	claims := &model.UserBearer{}
+	claims = &model.UserBearer{}
 	if err := session.JSONWebToken.VerifyToken(ctx, tokenString, claims); err != nil {
 		responses.REST(c, httpResponse.Unathorized("").NewResponses(nil, "Invalid token"))
 		return
 	}
 
-	ctx = context.WithValue(context.Background(), &model.Identifier{}, claims)
+	// Other tokens signed with the same key (e.g. fare quotes) carry no user id and must not open a session.
+	if claims.ID < 1 {
+		responses.REST(c, httpResponse.Unathorized("").NewResponses(nil, "Invalid token"))
+		return
+	}
+
+	if session.Revocation != nil {
+		revoked, err := session.Revocation.Revoked(ctx, claims)
+		if err != nil {
+			responses.REST(c, httpResponse.InternalServerError("").NewResponses(nil, "session could not be checked"))
+			return
+		}
+
+		if revoked {
+			responses.REST(c, httpResponse.Unathorized("").NewResponses(nil, "Session expired, please login again"))
+			return
+		}
+	}
+
+	return claims, true
+}
+
+// next puts the claims in the request context for model.GetRequester and runs the rest of the chain.
+func next(c *gin.Context, claims *model.UserBearer) {
+	ctx := context.WithValue(c.Request.Context(), &model.Identifier{}, claims)
 	c.Request = c.Request.WithContext(ctx)
 	c.Next()
 }
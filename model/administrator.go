@@ -0,0 +1,21 @@
+package model
+
+type CreateAdministrator struct {
+	Name     string `json:"name" binding:"required,max=100"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=8"`
+	Role     string `json:"role" binding:"required,oneof=superadmin finance support read-only"`
+}
+
+// UpdateAdministrator replaces the account, the password only changes when given.
+type UpdateAdministrator struct {
+	Name     string `json:"name" binding:"required,max=100"`
+	Role     string `json:"role" binding:"required,oneof=superadmin finance support read-only"`
+	IsActive bool   `json:"isActive"`
+	Password string `json:"password" binding:"omitempty,min=8"`
+}
+
+type GetAdministratorsParams struct {
+	Size int64 `json:"size" binding:"required,min=1,max=100"`
+	Page int64 `json:"page" binding:"required,min=1"`
+}
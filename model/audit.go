@@ -0,0 +1,36 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+type requestMetadataKey struct{}
+
+// RequestMetadata describes where a request came from.
+type RequestMetadata struct {
+	IPAddress string
+	UserAgent string
+}
+
+func WithRequestMetadata(ctx context.Context, metadata RequestMetadata) context.Context {
+	return context.WithValue(ctx, requestMetadataKey{}, metadata)
+}
+
+// GetRequestMetadata returns an empty RequestMetadata when the request went through no middleware.RequestMetadata.
+func GetRequestMetadata(ctx context.Context) RequestMetadata {
+	metadata, _ := ctx.Value(requestMetadataKey{}).(RequestMetadata)
+	return metadata
+}
+
+// GetAuditLogsParams filters audit logs, zero values do not filter.
+type GetAuditLogsParams struct {
+	ActorId    int64      `json:"actorId" binding:"min=0"`
+	Action     string     `json:"action" binding:"omitempty,max=50"`
+	TargetType string     `json:"targetType" binding:"omitempty,max=30"`
+	TargetId   int64      `json:"targetId" binding:"min=0"`
+	From       *time.Time `json:"from"`
+	To         *time.Time `json:"to"`
+	Size       int64      `json:"size" binding:"required,min=1,max=100"`
+	Page       int64      `json:"page" binding:"required,min=1"`
+}
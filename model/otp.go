@@ -0,0 +1,6 @@
+package model
+
+type RequestOTP struct {
+	PhoneNumber string `json:"phoneNumber" binding:"required,max=20"`
+	Purpose     string `json:"purpose" binding:"required,oneof=registration change_phone_number"`
+}
@@ -6,6 +6,12 @@ type UpdatePassengerStatus struct {
 	ShareRideID int64 `json:"shareRideID" binding:"required,min=1"`
 }
 
+type CancelBooking struct {
+	ID          int64  `json:"id" binding:"required,min=1"`
+	ShareRideID int64  `json:"shareRideID" binding:"required,min=1"`
+	Reason      string `json:"reason" binding:"required,max=255"`
+}
+
 type TopUpCoinBalance struct {
 	ID   int64 `json:"id" binding:"required,min=1"`
 	Coin int64 `json:"coin" binding:"required,min=1"`
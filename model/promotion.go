@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// SavePromotion creates or replaces a campaign. Zero limits, caps and minimum fare mean unlimited.
+type SavePromotion struct {
+	Code              string    `json:"code" binding:"required,alphanum,max=32"`
+	Name              string    `json:"name" binding:"required,max=100"`
+	DiscountType      string    `json:"discountType" binding:"required,oneof=percentage fixed"`
+	DiscountValue     int64     `json:"discountValue" binding:"required,min=1"`
+	MaxDiscount       int64     `json:"maxDiscount" binding:"min=0"`
+	MinimumFare       int64     `json:"minimumFare" binding:"min=0"`
+	FirstRideOnly     bool      `json:"firstRideOnly"`
+	UsageLimit        int64     `json:"usageLimit" binding:"min=0"`
+	UsageLimitPerUser int64     `json:"usageLimitPerUser" binding:"min=0"`
+	StartsAt          time.Time `json:"startsAt" binding:"required"`
+	EndsAt            time.Time `json:"endsAt" binding:"required,gtfield=StartsAt"`
+	IsActive          bool      `json:"isActive"`
+}
+
+type GetPromotionsParams struct {
+	Size int64 `json:"size" binding:"required,min=1,max=100"`
+	Page int64 `json:"page" binding:"required,min=1"`
+}
@@ -0,0 +1,14 @@
+package model
+
+type CreateRating struct {
+	ShareRideId int64    `json:"shareRideId" binding:"required,min=1"`
+	PassengerId int64    `json:"passengerId" binding:"required,min=1"`
+	Stars       int16    `json:"stars" binding:"required,min=1,max=5"`
+	Tags        []string `json:"tags" binding:"omitempty,max=5,dive,required,max=30,excludes=|"`
+	Comment     *string  `json:"comment" binding:"omitempty,max=500"`
+}
+
+type GetRatingsParams struct {
+	Size int64 `json:"size" binding:"required,min=1,max=100"`
+	Page int64 `json:"page" binding:"required,min=1"`
+}
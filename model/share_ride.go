@@ -1,5 +1,10 @@
 package model
 
+import (
+	"github.com/Difaal21/nebeng-dong/fare"
+	"github.com/golang-jwt/jwt/v5"
+)
+
 type ShareRideId struct {
 	ID int64 `json:"id" binding:"min=1,number"`
 }
@@ -9,7 +14,26 @@ type FinishFindPassenger struct {
 }
 
 type FindDriver struct {
+	PickupCoordinate      Coordinate `json:"pickupCoordinate" binding:"required"`
+	PickupNote            *string    `json:"pickupNote" binding:"omitempty,max=255"`
+	DestinationCoordinate Coordinate `json:"destinationCoordinate" binding:"required"`
+	QuoteToken            string     `json:"quoteToken" binding:"omitempty"`
+	PaymentMethod         string     `json:"paymentMethod" binding:"omitempty,oneof=cash coin split"`
+	CoinAmount            int64      `json:"coinAmount" binding:"omitempty,min=1"`
+	PromoCode             string     `json:"promoCode" binding:"omitempty,alphanum,max=32"`
+}
+
+type FareQuote struct {
+	PickupCoordinate      Coordinate `json:"pickupCoordinate" binding:"required"`
 	DestinationCoordinate Coordinate `json:"destinationCoordinate" binding:"required"`
-	Distance              float64    `json:"distance" binding:"required"`
-	CostPerKM             int64      `json:"costPerKm" binding:"required"`
+	VehicleType           string     `json:"vehicleType" binding:"omitempty,oneof=motorcycle car"`
+	PromoCode             string     `json:"promoCode" binding:"omitempty,alphanum,max=32"`
+}
+
+type QuoteBearer struct {
+	jwt.RegisteredClaims
+	UserId                int64      `json:"userId"`
+	PickupCoordinate      Coordinate `json:"pickupCoordinate"`
+	DestinationCoordinate Coordinate `json:"destinationCoordinate"`
+	Fare                  fare.Fare  `json:"fare"`
 }
@@ -24,16 +24,32 @@ type UserRegistration struct {
 	Name                string `json:"name" binding:"required"`
 	Email               string `json:"email" binding:"required,email"`
 	PhoneNumber         string `json:"phoneNumber" binding:"required"`
+	OTPCode             string `json:"otpCode" binding:"required,len=6,numeric"`
 	Password            string `json:"password" binding:"required"`
 	IsDriver            *bool  `json:"isDriver" binding:"required"`
 	VehicleModel        string `json:"vehicleModel" binding:"omitempty"`
 	VehicleManufature   string `json:"vehicleManufature" binding:"omitempty"`
 	VehicleLicensePlate string `json:"vehicleLicensePlate" binding:"omitempty,max=9"`
+	VehicleType         string `json:"vehicleType" binding:"omitempty,oneof=motorcycle car"`
+	VehicleCapacity     int    `json:"vehicleCapacity" binding:"omitempty,min=1,max=7"`
 }
 
 type UserLogin struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required"`
+	DeviceName string `json:"deviceName" binding:"omitempty,max=100"`
+	Device     Device `json:"-"`
+}
+
+// Device describes where a session was opened, it is taken from the request and not from the body.
+type Device struct {
+	UserAgent string
+	IPAddress string
+}
+
+type RefreshSession struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+	Device       Device `json:"-"`
 }
 
 type UserBearer struct {
@@ -42,6 +58,30 @@ type UserBearer struct {
 	Name     string `json:"name"`
 	Email    string `json:"email"`
 	IsDriver bool   `json:"isDriver"`
+	// SessionId is the refresh token family the access token was issued for.
+	SessionId string `json:"sid,omitempty"`
+	// Role is only set on administrator tokens.
+	Role string `json:"role,omitempty"`
+}
+
+// VerificationBearer proves ownership of Email, it has no id so it cannot be used as a session.
+type VerificationBearer struct {
+	jwt.RegisteredClaims
+	UserId int64  `json:"userId"`
+	Email  string `json:"email"`
+}
+
+type VerifyEmail struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ForgotPassword struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPassword struct {
+	Token string `json:"token" binding:"required"`
+	New   string `json:"new" binding:"required"`
 }
 
 type Coordinate struct {
@@ -50,7 +90,8 @@ type Coordinate struct {
 }
 
 type ChangePhoneNumber struct {
-	New string `json:"new" binding:"required"`
+	New     string `json:"new" binding:"required"`
+	OTPCode string `json:"otpCode" binding:"required,len=6,numeric"`
 }
 
 type ChangePassword struct {
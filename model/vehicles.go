@@ -4,4 +4,6 @@ type VehicleRegistration struct {
 	VehicleModel        string `json:"vehicleModel" binding:"required"`
 	VehicleManufature   string `json:"vehicleManufature" binding:"required"`
 	VehicleLicensePlate string `json:"vehicleLicensePlate" binding:"required,max=9"`
+	VehicleType         string `json:"vehicleType" binding:"omitempty,oneof=motorcycle car"`
+	VehicleCapacity     int    `json:"vehicleCapacity" binding:"omitempty,min=1,max=7"`
 }
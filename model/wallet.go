@@ -0,0 +1,17 @@
+package model
+
+type GetWalletTransactionsParams struct {
+	Size int64 `json:"size" binding:"required,min=1,max=100"`
+	Page int64 `json:"page" binding:"required,min=1"`
+}
+
+// ReconcileWallet confirms the difference an administrator reviewed before it is written to the ledger.
+type ReconcileWallet struct {
+	ID         int64  `json:"id" binding:"required,min=1"`
+	Difference int64  `json:"difference" binding:"required"`
+	Reason     string `json:"reason" binding:"required,max=200"`
+}
+
+type CreateTopUp struct {
+	Amount int64 `json:"amount" binding:"required,min=10000,max=10000000"`
+}
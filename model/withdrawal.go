@@ -0,0 +1,28 @@
+package model
+
+type CreateBankAccount struct {
+	BankCode      string `json:"bankCode" binding:"required,max=20"`
+	AccountNumber string `json:"accountNumber" binding:"required,numeric,min=5,max=30"`
+	AccountHolder string `json:"accountHolder" binding:"required,max=100"`
+}
+
+type CreateWithdrawal struct {
+	BankAccountId int64 `json:"bankAccountId" binding:"required,min=1"`
+	Amount        int64 `json:"amount" binding:"required,min=10000"`
+}
+
+type GetWithdrawalsParams struct {
+	Status string `json:"status" binding:"omitempty,oneof=requested approved paid rejected"`
+	Size   int64  `json:"size" binding:"required,min=1,max=100"`
+	Page   int64  `json:"page" binding:"required,min=1"`
+}
+
+type RejectWithdrawal struct {
+	ID     int64  `json:"id" binding:"required,min=1"`
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+type PayWithdrawal struct {
+	ID              int64  `json:"id" binding:"required,min=1"`
+	PayoutReference string `json:"payoutReference" binding:"required,max=100"`
+}
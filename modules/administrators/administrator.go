@@ -0,0 +1,281 @@
+package administrators
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/helpers/cryptography"
+	"github.com/Difaal21/nebeng-dong/helpers/date"
+	"github.com/Difaal21/nebeng-dong/middleware"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/responses"
+	"github.com/sirupsen/logrus"
+)
+
+// Bootstrap creates the first superadmin while the administrators table is empty, so a fresh install can log in.
+func Bootstrap(ctx context.Context, repo Repository, name, email, password string) (err error) {
+	if email == "" || password == "" {
+		return
+	}
+
+	total, err := repo.Count(ctx)
+	if err != nil || total > 0 {
+		return
+	}
+
+	hashPassword, err := cryptography.Hash([]byte(password))
+	if err != nil {
+		return
+	}
+
+	now := date.CurrentUTCTime()
+	administrator := &entity.Administrator{
+		Name:      name,
+		Email:     strings.ToLower(email),
+		Password:  hashPassword,
+		Role:      RoleSuperadmin,
+		IsActive:  true,
+		CreatedAt: *now,
+		UpdatedAt: *now,
+	}
+
+	_, err = repo.Insert(ctx, administrator)
+	return
+}
+
+// SessionRevocation rejects administrator tokens once the account is deleted, deactivated or given another role,
+// and tokens issued before its password was changed.
+type SessionRevocation struct {
+	Repository Repository
+}
+
+func NewSessionRevocation(repo Repository) middleware.Revocation {
+	return &SessionRevocation{
+		Repository: repo,
+	}
+}
+
+func (s *SessionRevocation) Revoked(ctx context.Context, claims *model.UserBearer) (revoked bool, err error) {
+	administrator, err := s.Repository.FindOne(ctx, nil, claims.ID)
+	if err == exception.ErrNotFound {
+		return true, nil
+	}
+	if err != nil {
+		return
+	}
+
+	if !administrator.IsActive || administrator.Role != claims.Role {
+		return true, nil
+	}
+
+	if administrator.SessionsInvalidatedAt == nil {
+		return false, nil
+	}
+
+	// kept in whole seconds like iat, so a login right after the change is still accepted
+	return claims.IssuedAt == nil || claims.IssuedAt.Time.Before(*administrator.SessionsInvalidatedAt), nil
+}
+
+func (u *UsecaseImpl) CreateAdministrator(ctx context.Context, payload *model.CreateAdministrator) responses.Responses {
+
+	hashPassword, err := cryptography.Hash([]byte(payload.Password))
+	if err != nil {
+		u.Logger.Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, "unexpected error")
+	}
+
+	now := date.CurrentUTCTime()
+	administrator := &entity.Administrator{
+		Name:      payload.Name,
+		Email:     strings.ToLower(payload.Email),
+		Password:  hashPassword,
+		Role:      payload.Role,
+		IsActive:  true,
+		CreatedAt: *now,
+		UpdatedAt: *now,
+	}
+
+	if administrator.ID, err = u.Repository.Insert(ctx, administrator); err != nil {
+		if err == exception.ErrConflict {
+			return httpResponse.Conflict("DUPLICATED_EMAIL").NewResponses(nil, "email already used by another administrator")
+		}
+		payload.Password = ""
+		u.Logger.WithField("payload", payload).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Created("").NewResponses(administrator, "administrator created")
+}
+
+func (u *UsecaseImpl) GetAdministrators(ctx context.Context, params *model.GetAdministratorsParams) responses.Responses {
+
+	totalData, err := u.Repository.Count(ctx)
+	if err != nil {
+		u.Logger.WithField("params", params).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	administrators, err := u.Repository.FindMany(ctx, params)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithField("params", params).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if administrators == nil {
+		return httpResponse.NotFound("").NewResponses(nil, "no administrator")
+	}
+
+	return httpResponse.Ok("").NewResponsesOffsetPagination(administrators, int64(len(administrators)), totalData, "get administrators success")
+}
+
+func (u *UsecaseImpl) GetAdministrator(ctx context.Context, administratorId int64) responses.Responses {
+
+	administrator, err := u.Repository.FindOne(ctx, nil, administratorId)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithField("administratorId", administratorId).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if administrator == nil {
+		return httpResponse.NotFound("").NewResponses(nil, "administrator not found")
+	}
+
+	return httpResponse.Ok("").NewResponses(administrator, "get administrator success")
+}
+
+// UpdateAdministrator refuses to demote or deactivate the last active superadmin.
+func (u *UsecaseImpl) UpdateAdministrator(ctx context.Context, administratorId int64, payload *model.UpdateAdministrator) responses.Responses {
+
+	var hashPassword string
+	if payload.Password != "" {
+		var err error
+		if hashPassword, err = cryptography.Hash([]byte(payload.Password)); err != nil {
+			u.Logger.Error(err.Error())
+			return httpResponse.InternalServerError("").NewResponses(nil, "unexpected error")
+		}
+	}
+
+	tx, err := u.Repository.BeginTx(ctx)
+	if err != nil {
+		u.Logger.WithContext(ctx).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	administrator, err := u.Repository.FindOne(ctx, tx, administratorId)
+	if err != nil {
+		u.Repository.RollbackTx(ctx, tx)
+		if err == exception.ErrNotFound {
+			return httpResponse.NotFound("").NewResponses(nil, "administrator not found")
+		}
+		u.Logger.WithContext(ctx).WithField("administratorId", administratorId).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if payload.Role != RoleSuperadmin || !payload.IsActive {
+		if resp := u.keepLastSuperadmin(ctx, tx, administrator); resp != nil {
+			u.Repository.RollbackTx(ctx, tx)
+			return resp
+		}
+	}
+
+	administrator.Name = payload.Name
+	administrator.Role = payload.Role
+	administrator.IsActive = payload.IsActive
+	administrator.UpdatedAt = *date.CurrentUTCTime()
+	if hashPassword != "" {
+		invalidatedAt := administrator.UpdatedAt.Truncate(time.Second)
+		administrator.Password = hashPassword
+		administrator.SessionsInvalidatedAt = &invalidatedAt
+	}
+
+	fields := logrus.Fields{"administratorId": administratorId, "role": payload.Role, "isActive": payload.IsActive}
+
+	if err := u.Repository.Update(ctx, tx, administrator); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if err := u.Repository.CommitTx(ctx, tx); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Ok("").NewResponses(administrator, "administrator updated")
+}
+
+// DeleteAdministrator refuses to delete the requester or the last active superadmin.
+func (u *UsecaseImpl) DeleteAdministrator(ctx context.Context, administratorId int64) responses.Responses {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if requester.ID == administratorId {
+		return httpResponse.Conflict("").NewResponses(nil, "you cannot delete your own account")
+	}
+
+	tx, err := u.Repository.BeginTx(ctx)
+	if err != nil {
+		u.Logger.WithContext(ctx).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	administrator, err := u.Repository.FindOne(ctx, tx, administratorId)
+	if err != nil {
+		u.Repository.RollbackTx(ctx, tx)
+		if err == exception.ErrNotFound {
+			return httpResponse.NotFound("").NewResponses(nil, "administrator not found")
+		}
+		u.Logger.WithContext(ctx).WithField("administratorId", administratorId).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if resp := u.keepLastSuperadmin(ctx, tx, administrator); resp != nil {
+		u.Repository.RollbackTx(ctx, tx)
+		return resp
+	}
+
+	if err := u.Repository.Delete(ctx, tx, administratorId); err != nil {
+		u.Repository.RollbackTx(ctx, tx)
+		if err == exception.ErrNotFound {
+			return httpResponse.NotFound("").NewResponses(nil, "administrator not found")
+		}
+		u.Logger.WithContext(ctx).WithField("administratorId", administratorId).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if err := u.Repository.CommitTx(ctx, tx); err != nil {
+		u.Logger.WithContext(ctx).WithField("administratorId", administratorId).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Ok("").NewResponses(nil, "administrator deleted")
+}
+
+// keepLastSuperadmin returns a conflict when administrator is the only active superadmin left.
+func (u *UsecaseImpl) keepLastSuperadmin(ctx context.Context, tx *sql.Tx, administrator *entity.Administrator) responses.Responses {
+	if administrator.Role != RoleSuperadmin || !administrator.IsActive {
+		return nil
+	}
+
+	total, err := u.Repository.CountActiveByRole(ctx, tx, RoleSuperadmin)
+	if err != nil {
+		u.Logger.WithContext(ctx).WithField("administratorId", administrator.ID).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if total <= 1 {
+		return httpResponse.Conflict("").NewResponses(nil, "the last active superadmin cannot be removed")
+	}
+
+	return nil
+}
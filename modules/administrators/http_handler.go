@@ -15,20 +15,42 @@ import (
 var httpResponse = responses.HttpResponseStatusCodesImpl{}
 
 type HTTPHandler struct {
-	Usecase Usecase
-	Session *middleware.Session
+	Usecase       Usecase
+	Authorization *middleware.Authorization
 }
 
-func NewHTTPHandler(router *gin.Engine, basicAuth *middleware.BasicAuth, session *middleware.Session, usecase Usecase) {
+func NewHTTPHandler(router *gin.Engine, basicAuth *middleware.BasicAuth, authorization *middleware.Authorization, usecase Usecase) {
 
 	handler := &HTTPHandler{
-		Usecase: usecase,
-		Session: session,
+		Usecase:       usecase,
+		Authorization: authorization,
 	}
 
+	read := authorization.Require(PermissionRead)
+	manageWallet := authorization.Require(PermissionManageWallet)
+	managePromotions := authorization.Require(PermissionManagePromotions)
+	manageAdministrators := authorization.Require(PermissionManageAdministrators)
+
 	router.POST("/nebengdong-service/administrators/v1/administrators/login", basicAuth.Verify, handler.Login)
-	router.GET("/nebengdong-service/administrators/v1/drivers", session.Verify, handler.GetManyDrivers)
-	router.POST("/nebengdong-service/administrators/v1/users/:id/top-up", session.Verify, handler.TopUpCoinBalance)
+	router.POST("/nebengdong-service/administrators/v1/administrators", manageAdministrators, handler.CreateAdministrator)
+	router.GET("/nebengdong-service/administrators/v1/administrators", manageAdministrators, handler.GetAdministrators)
+	router.GET("/nebengdong-service/administrators/v1/administrators/:id", manageAdministrators, handler.GetAdministrator)
+	router.PUT("/nebengdong-service/administrators/v1/administrators/:id", manageAdministrators, handler.UpdateAdministrator)
+	router.DELETE("/nebengdong-service/administrators/v1/administrators/:id", manageAdministrators, handler.DeleteAdministrator)
+	router.GET("/nebengdong-service/administrators/v1/drivers", read, handler.GetManyDrivers)
+	router.POST("/nebengdong-service/administrators/v1/users/:id/top-up", manageWallet, handler.TopUpCoinBalance)
+	router.GET("/nebengdong-service/administrators/v1/share-ride/:id/trace", read, handler.GetShareRideTrace)
+	router.GET("/nebengdong-service/administrators/v1/users/:id/wallet/reconcile", read, handler.GetWalletDiscrepancy)
+	router.POST("/nebengdong-service/administrators/v1/users/:id/wallet/reconcile", manageWallet, handler.ReconcileWallet)
+	router.GET("/nebengdong-service/administrators/v1/withdrawals", read, handler.GetWithdrawals)
+	router.PUT("/nebengdong-service/administrators/v1/withdrawals/:id/approve", manageWallet, handler.ApproveWithdrawal)
+	router.PUT("/nebengdong-service/administrators/v1/withdrawals/:id/reject", manageWallet, handler.RejectWithdrawal)
+	router.PUT("/nebengdong-service/administrators/v1/withdrawals/:id/paid", manageWallet, handler.PayWithdrawal)
+	router.POST("/nebengdong-service/administrators/v1/promotions", managePromotions, handler.CreatePromotion)
+	router.GET("/nebengdong-service/administrators/v1/promotions", read, handler.GetPromotions)
+	router.GET("/nebengdong-service/administrators/v1/promotions/:id", read, handler.GetPromotion)
+	router.PUT("/nebengdong-service/administrators/v1/promotions/:id", managePromotions, handler.UpdatePromotion)
+	router.DELETE("/nebengdong-service/administrators/v1/promotions/:id", managePromotions, handler.DeletePromotion)
 }
 
 func (handler *HTTPHandler) Login(c *gin.Context) {
@@ -113,3 +135,310 @@ func (handler *HTTPHandler) TopUpCoinBalance(c *gin.Context) {
 	resp := handler.Usecase.TopUpCoinBalance(context, payload)
 	responses.REST(c, resp)
 }
+
+func (handler *HTTPHandler) GetShareRideTrace(c *gin.Context) {
+	context := c.Request.Context()
+
+	shareRideIdStr := c.Param("id")
+	shareRideId, _ := strconv.ParseInt(shareRideIdStr, 10, 64)
+
+	resp := handler.Usecase.GetShareRideTrace(context, shareRideId)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) GetWalletDiscrepancy(c *gin.Context) {
+	context := c.Request.Context()
+
+	userIdStr := c.Param("id")
+	userId, _ := strconv.ParseInt(userIdStr, 10, 64)
+
+	resp := handler.Usecase.GetWalletDiscrepancy(context, userId)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) ReconcileWallet(c *gin.Context) {
+	context := c.Request.Context()
+
+	userIdStr := c.Param("id")
+	userId, _ := strconv.ParseInt(userIdStr, 10, 64)
+
+	payload := &model.ReconcileWallet{
+		ID: userId,
+	}
+
+	if err := c.ShouldBindJSON(payload); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, payload)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.ReconcileWallet(context, payload)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) GetWithdrawals(c *gin.Context) {
+	context := c.Request.Context()
+
+	queryString := c.Request.URL.Query()
+
+	page, _ := strconv.Atoi(queryString.Get("page"))
+	size, _ := strconv.Atoi(queryString.Get("size"))
+
+	params := model.GetWithdrawalsParams{
+		Status: queryString.Get("status"),
+		Page:   int64(page),
+		Size:   int64(size),
+	}
+
+	if err := c.ShouldBind(&params); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, params)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.GetWithdrawals(context, &params)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) ApproveWithdrawal(c *gin.Context) {
+	context := c.Request.Context()
+
+	withdrawalIdStr := c.Param("id")
+	withdrawalId, _ := strconv.ParseInt(withdrawalIdStr, 10, 64)
+
+	resp := handler.Usecase.ApproveWithdrawal(context, withdrawalId)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) RejectWithdrawal(c *gin.Context) {
+	context := c.Request.Context()
+
+	withdrawalIdStr := c.Param("id")
+	withdrawalId, _ := strconv.ParseInt(withdrawalIdStr, 10, 64)
+
+	payload := &model.RejectWithdrawal{
+		ID: withdrawalId,
+	}
+
+	if err := c.ShouldBindJSON(payload); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, payload)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.RejectWithdrawal(context, payload)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) PayWithdrawal(c *gin.Context) {
+	context := c.Request.Context()
+
+	withdrawalIdStr := c.Param("id")
+	withdrawalId, _ := strconv.ParseInt(withdrawalIdStr, 10, 64)
+
+	payload := &model.PayWithdrawal{
+		ID: withdrawalId,
+	}
+
+	if err := c.ShouldBindJSON(payload); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, payload)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.PayWithdrawal(context, payload)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) CreatePromotion(c *gin.Context) {
+	context := c.Request.Context()
+
+	var payload model.SavePromotion
+
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, payload)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.CreatePromotion(context, &payload)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) GetPromotions(c *gin.Context) {
+	context := c.Request.Context()
+
+	queryString := c.Request.URL.Query()
+
+	page, _ := strconv.Atoi(queryString.Get("page"))
+	size, _ := strconv.Atoi(queryString.Get("size"))
+
+	params := model.GetPromotionsParams{
+		Page: int64(page),
+		Size: int64(size),
+	}
+
+	if err := c.ShouldBind(&params); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, params)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.GetPromotions(context, &params)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) GetPromotion(c *gin.Context) {
+	context := c.Request.Context()
+
+	promotionIdStr := c.Param("id")
+	promotionId, _ := strconv.ParseInt(promotionIdStr, 10, 64)
+
+	resp := handler.Usecase.GetPromotion(context, promotionId)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) UpdatePromotion(c *gin.Context) {
+	context := c.Request.Context()
+
+	promotionIdStr := c.Param("id")
+	promotionId, _ := strconv.ParseInt(promotionIdStr, 10, 64)
+
+	var payload model.SavePromotion
+
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, payload)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.UpdatePromotion(context, promotionId, &payload)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) DeletePromotion(c *gin.Context) {
+	context := c.Request.Context()
+
+	promotionIdStr := c.Param("id")
+	promotionId, _ := strconv.ParseInt(promotionIdStr, 10, 64)
+
+	resp := handler.Usecase.DeletePromotion(context, promotionId)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) CreateAdministrator(c *gin.Context) {
+	context := c.Request.Context()
+
+	var payload model.CreateAdministrator
+
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, payload)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	payload.Email = strings.ToLower(payload.Email)
+	resp := handler.Usecase.CreateAdministrator(context, &payload)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) GetAdministrators(c *gin.Context) {
+	context := c.Request.Context()
+
+	queryString := c.Request.URL.Query()
+
+	page, _ := strconv.Atoi(queryString.Get("page"))
+	size, _ := strconv.Atoi(queryString.Get("size"))
+
+	params := model.GetAdministratorsParams{
+		Page: int64(page),
+		Size: int64(size),
+	}
+
+	if err := c.ShouldBind(&params); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, params)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.GetAdministrators(context, &params)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) GetAdministrator(c *gin.Context) {
+	context := c.Request.Context()
+
+	administratorIdStr := c.Param("id")
+	administratorId, _ := strconv.ParseInt(administratorIdStr, 10, 64)
+
+	resp := handler.Usecase.GetAdministrator(context, administratorId)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) UpdateAdministrator(c *gin.Context) {
+	context := c.Request.Context()
+
+	administratorIdStr := c.Param("id")
+	administratorId, _ := strconv.ParseInt(administratorIdStr, 10, 64)
+
+	var payload model.UpdateAdministrator
+
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, payload)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.UpdateAdministrator(context, administratorId, &payload)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) DeleteAdministrator(c *gin.Context) {
+	context := c.Request.Context()
+
+	administratorIdStr := c.Param("id")
+	administratorId, _ := strconv.ParseInt(administratorIdStr, 10, 64)
+
+	resp := handler.Usecase.DeleteAdministrator(context, administratorId)
+	responses.REST(c, resp)
+}
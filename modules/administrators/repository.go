@@ -0,0 +1,325 @@
+package administrators
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
+)
+
+type Repository interface {
+	BeginTx(ctx context.Context) (tx *sql.Tx, err error)
+	RollbackTx(ctx context.Context, tx *sql.Tx) (err error)
+	CommitTx(ctx context.Context, tx *sql.Tx) (err error)
+
+	// Insert returns exception.ErrConflict when the email is taken.
+	Insert(ctx context.Context, administrator *entity.Administrator) (id int64, err error)
+	Update(ctx context.Context, tx *sql.Tx, administrator *entity.Administrator) (err error)
+	// Delete only hides the account, withdrawals and top-ups keep pointing at it.
+	Delete(ctx context.Context, tx *sql.Tx, id int64) (err error)
+	// FindOne locks the administrator row when tx is given.
+	FindOne(ctx context.Context, tx *sql.Tx, id int64) (administrator *entity.Administrator, err error)
+	FindOneByEmail(ctx context.Context, email string) (administrator *entity.Administrator, err error)
+	FindMany(ctx context.Context, params *model.GetAdministratorsParams) (administrators []entity.Administrator, err error)
+	Count(ctx context.Context) (total int64, err error)
+	// CountActiveByRole locks the counted rows when tx is given.
+	CountActiveByRole(ctx context.Context, tx *sql.Tx, role string) (total int64, err error)
+}
+
+type RepositoryImpl struct {
+	DB        *sql.DB
+	Logger    *logrus.Logger
+	TableName string
+}
+
+func NewRepositoryImpl(db *sql.DB, logger *logrus.Logger) Repository {
+	return &RepositoryImpl{
+		DB:        db,
+		Logger:    logger,
+		TableName: "administrators",
+	}
+}
+
+func (repo *RepositoryImpl) BeginTx(ctx context.Context) (tx *sql.Tx, err error) {
+	return repo.DB.BeginTx(ctx, nil)
+}
+
+func (repo *RepositoryImpl) RollbackTx(ctx context.Context, tx *sql.Tx) (err error) {
+	return tx.Rollback()
+}
+
+func (repo *RepositoryImpl) CommitTx(ctx context.Context, tx *sql.Tx) (err error) {
+	return tx.Commit()
+}
+
+func (repo *RepositoryImpl) Insert(ctx context.Context, administrator *entity.Administrator) (id int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	command := fmt.Sprintf(`
+	INSERT INTO %s
+	SET
+		id = ?,
+		name = ?,
+		email = ?,
+		password = ?,
+		role = ?,
+		is_active = ?,
+		created_at = ?,
+		updated_at = ?
+	`, repo.TableName)
+
+	result, err := Exec(ctx, cmd, command, administrator.ID, administrator.Name, administrator.Email, administrator.Password, administrator.Role, administrator.IsActive, administrator.CreatedAt, administrator.UpdatedAt)
+	if err != nil {
+		if driverErr, ok := err.(*mysql.MySQLError); ok && driverErr.Number == 1062 {
+			return 0, exception.ErrConflict
+		}
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return
+	}
+
+	if id, err = result.LastInsertId(); err != nil {
+		return
+	}
+	return
+}
+
+func (repo *RepositoryImpl) Update(ctx context.Context, tx *sql.Tx, administrator *entity.Administrator) (err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`
+	UPDATE
+		%s
+	SET
+		name = ?,
+		password = ?,
+		role = ?,
+		is_active = ?,
+		sessions_invalidated_at = ?,
+		updated_at = ?
+	WHERE
+		id = ? AND deleted_at IS NULL
+	`, repo.TableName)
+
+	_, err = Exec(ctx, cmd, command, administrator.Name, administrator.Password, administrator.Role, administrator.IsActive, administrator.SessionsInvalidatedAt, administrator.UpdatedAt, administrator.ID)
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	return
+}
+
+func (repo *RepositoryImpl) Delete(ctx context.Context, tx *sql.Tx, id int64) (err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`UPDATE %s SET is_active = FALSE, deleted_at = UTC_TIMESTAMP() WHERE id = ? AND deleted_at IS NULL`, repo.TableName)
+
+	result, err := Exec(ctx, cmd, command, id)
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	if affected < 1 {
+		return exception.ErrNotFound
+	}
+
+	return
+}
+
+func (repo *RepositoryImpl) FindOne(ctx context.Context, tx *sql.Tx, id int64) (administrator *entity.Administrator, err error) {
+	var cmd SqlCommand = repo.DB
+
+	lock := ""
+	if tx != nil {
+		cmd = tx
+		lock = "FOR UPDATE"
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM
+		%s a
+	WHERE
+		a.id = ? AND a.deleted_at IS NULL
+	%s
+	`, administratorColumns, repo.TableName, lock)
+
+	administrators, err := repo.Query(ctx, cmd, query, id)
+	if err != nil {
+		return
+	}
+
+	administrator = &administrators[len(administrators)-1]
+
+	return
+}
+
+func (repo *RepositoryImpl) FindOneByEmail(ctx context.Context, email string) (administrator *entity.Administrator, err error) {
+	var cmd SqlCommand = repo.DB
+
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM
+		%s a
+	WHERE
+		a.email = ? AND a.deleted_at IS NULL
+	`, administratorColumns, repo.TableName)
+
+	administrators, err := repo.Query(ctx, cmd, query, email)
+	if err != nil {
+		return
+	}
+
+	administrator = &administrators[len(administrators)-1]
+
+	return
+}
+
+func (repo *RepositoryImpl) FindMany(ctx context.Context, params *model.GetAdministratorsParams) (administrators []entity.Administrator, err error) {
+	var cmd SqlCommand = repo.DB
+
+	var offset = (params.Page - 1) * params.Size
+
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM
+		%s a
+	WHERE
+		a.deleted_at IS NULL
+	ORDER BY
+		a.id ASC
+	LIMIT %d OFFSET %d
+	`, administratorColumns, repo.TableName, params.Size, offset)
+
+	return repo.Query(ctx, cmd, query)
+}
+
+func (repo *RepositoryImpl) Count(ctx context.Context) (total int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	query := fmt.Sprintf(`SELECT COUNT(a.id) FROM %s a WHERE a.deleted_at IS NULL`, repo.TableName)
+
+	if err = cmd.QueryRowContext(ctx, query).Scan(&total); err != nil {
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return
+	}
+
+	return
+}
+
+func (repo *RepositoryImpl) CountActiveByRole(ctx context.Context, tx *sql.Tx, role string) (total int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	lock := ""
+	if tx != nil {
+		cmd = tx
+		lock = "FOR UPDATE"
+	}
+
+	query := fmt.Sprintf(`SELECT COUNT(a.id) FROM %s a WHERE a.role = ? AND a.is_active = TRUE AND a.deleted_at IS NULL %s`, repo.TableName, lock)
+
+	if err = cmd.QueryRowContext(ctx, query, role).Scan(&total); err != nil {
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return
+	}
+
+	return
+}
+
+const administratorColumns = `
+		a.id,
+		a.name,
+		a.email,
+		a.password,
+		a.role,
+		a.is_active,
+		a.sessions_invalidated_at,
+		a.created_at,
+		a.updated_at`
+
+// ==================================================================================================================== //
+type SqlCommand interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// ==================================================================================================================== //
+
+func Exec(ctx context.Context, cmd SqlCommand, command string, args ...interface{}) (result sql.Result, err error) {
+	var stmt *sql.Stmt
+	if stmt, err = cmd.PrepareContext(ctx, command); err != nil {
+		return
+	}
+
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			return
+		}
+	}()
+
+	if result, err = stmt.ExecContext(ctx, args...); err != nil {
+		return
+	}
+
+	return
+}
+
+func (repo *RepositoryImpl) Query(ctx context.Context, cmd SqlCommand, query string, args ...interface{}) (administrators []entity.Administrator, err error) {
+
+	var rows *sql.Rows
+	if rows, err = cmd.QueryContext(ctx, query, args...); err != nil {
+		repo.Logger.Error(err.Error())
+		return
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+	}()
+
+	for rows.Next() {
+		var administrator entity.Administrator
+
+		err = rows.Scan(&administrator.ID, &administrator.Name, &administrator.Email, &administrator.Password, &administrator.Role, &administrator.IsActive, &administrator.SessionsInvalidatedAt, &administrator.CreatedAt, &administrator.UpdatedAt)
+		if err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+
+		administrators = append(administrators, administrator)
+	}
+
+	if administrators == nil {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
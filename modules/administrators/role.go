@@ -0,0 +1,25 @@
+package administrators
+
+const (
+	RoleSuperadmin = "superadmin"
+	RoleFinance    = "finance"
+	RoleSupport    = "support"
+	RoleReadOnly   = "read-only"
+)
+
+const (
+	PermissionRead                 = "read"
+	PermissionManageWallet         = "wallet:manage"
+	PermissionManagePromotions     = "promotions:manage"
+	PermissionManageAdministrators = "administrators:manage"
+	PermissionReadAuditLogs        = "audit-logs:read"
+)
+
+// Permissions lists the roles granted each permission, for middleware.NewAuthorization.
+var Permissions = map[string][]string{
+	PermissionRead:                 {RoleSuperadmin, RoleFinance, RoleSupport, RoleReadOnly},
+	PermissionManageWallet:         {RoleSuperadmin, RoleFinance},
+	PermissionManagePromotions:     {RoleSuperadmin, RoleSupport},
+	PermissionManageAdministrators: {RoleSuperadmin},
+	PermissionReadAuditLogs:        {RoleSuperadmin},
+}
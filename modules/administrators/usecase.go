@@ -3,13 +3,21 @@ package administrators
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/Difaal21/nebeng-dong/entity"
 	"github.com/Difaal21/nebeng-dong/exception"
 	"github.com/Difaal21/nebeng-dong/helpers/cryptography"
+	"github.com/Difaal21/nebeng-dong/helpers/date"
 	"github.com/Difaal21/nebeng-dong/jwt"
 	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/modules/promotions"
+	shareride "github.com/Difaal21/nebeng-dong/modules/share-ride"
 	"github.com/Difaal21/nebeng-dong/modules/users"
+	"github.com/Difaal21/nebeng-dong/modules/wallet"
+	"github.com/Difaal21/nebeng-dong/modules/withdrawals"
 	"github.com/Difaal21/nebeng-dong/responses"
 	jwtv5 "github.com/golang-jwt/jwt/v5"
 	"github.com/sirupsen/logrus"
@@ -19,44 +27,74 @@ type Usecase interface {
 	AdminLogin(ctx context.Context, payload *model.UserLogin) responses.Responses
 	GetManyDrivers(ctx context.Context, query *model.GetManyUserParams) responses.Responses
 	TopUpCoinBalance(ctx context.Context, payload *model.TopUpCoinBalance) responses.Responses
+	GetShareRideTrace(ctx context.Context, shareRideId int64) responses.Responses
+	GetWalletDiscrepancy(ctx context.Context, userId int64) responses.Responses
+	ReconcileWallet(ctx context.Context, payload *model.ReconcileWallet) responses.Responses
+	GetWithdrawals(ctx context.Context, params *model.GetWithdrawalsParams) responses.Responses
+	ApproveWithdrawal(ctx context.Context, withdrawalId int64) responses.Responses
+	RejectWithdrawal(ctx context.Context, payload *model.RejectWithdrawal) responses.Responses
+	PayWithdrawal(ctx context.Context, payload *model.PayWithdrawal) responses.Responses
+	CreatePromotion(ctx context.Context, payload *model.SavePromotion) responses.Responses
+	GetPromotions(ctx context.Context, params *model.GetPromotionsParams) responses.Responses
+	GetPromotion(ctx context.Context, promotionId int64) responses.Responses
+	UpdatePromotion(ctx context.Context, promotionId int64, payload *model.SavePromotion) responses.Responses
+	DeletePromotion(ctx context.Context, promotionId int64) responses.Responses
+	CreateAdministrator(ctx context.Context, payload *model.CreateAdministrator) responses.Responses
+	GetAdministrators(ctx context.Context, params *model.GetAdministratorsParams) responses.Responses
+	GetAdministrator(ctx context.Context, administratorId int64) responses.Responses
+	UpdateAdministrator(ctx context.Context, administratorId int64, payload *model.UpdateAdministrator) responses.Responses
+	DeleteAdministrator(ctx context.Context, administratorId int64) responses.Responses
 }
 
 type UsecaseImpl struct {
-	Logger         *logrus.Logger
-	JSONWebToken   jwt.JSONWebToken
-	UserRepository users.Repository
+	Logger               *logrus.Logger
+	JSONWebToken         jwt.JSONWebToken
+	UserRepository       users.Repository
+	TraceRepository      shareride.TraceRepository
+	Ledger               wallet.Ledger
+	WithdrawalRepository withdrawals.Repository
+	PromotionRepository  promotions.Repository
+	Repository           Repository
 }
 
-func NewUsecaseImpl(logger *logrus.Logger, jwt jwt.JSONWebToken, userRepository users.Repository) Usecase {
+func NewUsecaseImpl(logger *logrus.Logger, jwt jwt.JSONWebToken, userRepository users.Repository, traceRepository shareride.TraceRepository, ledger wallet.Ledger, withdrawalRepository withdrawals.Repository, promotionRepository promotions.Repository, repo Repository) Usecase {
 	return &UsecaseImpl{
-		Logger:         logger,
-		JSONWebToken:   jwt,
-		UserRepository: userRepository,
+		Logger:               logger,
+		JSONWebToken:         jwt,
+		UserRepository:       userRepository,
+		TraceRepository:      traceRepository,
+		Ledger:               ledger,
+		WithdrawalRepository: withdrawalRepository,
+		PromotionRepository:  promotionRepository,
+		Repository:           repo,
 	}
 }
 
 func (u *UsecaseImpl) AdminLogin(ctx context.Context, payload *model.UserLogin) responses.Responses {
 
-	account := map[string]string{
-		"name":     "superadmin",
-		"email":    "admin@gmail.com",
-		"password": "$2a$04$vcdIUZjTQe1MzEom97ySlekUy/vU.vBs.WrDED77YJNuqhNcolMTy",
+	account, err := u.Repository.FindOneByEmail(ctx, payload.Email)
+	if err != nil && err != exception.ErrNotFound {
+		payload.Password = ""
+		u.Logger.WithField("payload", payload).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
 
-	if payload.Email != account["email"] {
+	if account == nil || !account.IsActive {
 		return httpResponse.BadRequest("").NewResponses(nil, "invalid credential")
 	}
 
-	passwordMatch := cryptography.Verify(account["password"], []byte(payload.Password))
+	passwordMatch := cryptography.Verify(account.Password, []byte(payload.Password))
 	if !passwordMatch {
 		return httpResponse.BadRequest("").NewResponses(nil, "invalid credential")
 	}
 
 	expiresAt := time.Now().Add(time.Hour * 7)
 	claims := &model.UserBearer{}
-	claims.ID = 1
-	claims.Email = account["email"]
-	claims.Name = account["name"]
+	claims.ID = account.ID
+	claims.Email = account.Email
+	claims.Name = account.Name
+	claims.Role = account.Role
+	claims.IssuedAt = jwtv5.NewNumericDate(time.Now())
 	claims.ExpiresAt = jwtv5.NewNumericDate(expiresAt)
 
 	tokenString, err := u.JSONWebToken.CreateToken(ctx, claims)
@@ -67,8 +105,9 @@ func (u *UsecaseImpl) AdminLogin(ctx context.Context, payload *model.UserLogin)
 	}
 
 	result := map[string]any{
-		"name":  account["name"],
-		"email": account["email"],
+		"name":  account.Name,
+		"email": account.Email,
+		"role":  account.Role,
 		"token": map[string]any{
 			"value":     tokenString,
 			"expiresAt": expiresAt.Unix(),
@@ -115,14 +154,336 @@ func (u *UsecaseImpl) TopUpCoinBalance(ctx context.Context, payload *model.TopUp
 		return httpResponse.NotFound("").NewResponses(nil, err.Error())
 	}
 
-	topUpBalance := map[string]any{
-		"coin": user.Coin + payload.Coin,
+	// no record causes an admin top-up, the acting administrator is kept in the audit log
+	topUp := &wallet.Entry{
+		UserId:        payload.ID,
+		Amount:        payload.Coin,
+		ReferenceType: wallet.ReferenceAdmin,
+		Description:   "top up by administrator",
 	}
 
-	if err := u.UserRepository.Update(ctx, tx, payload.ID, topUpBalance); err != nil {
+	if _, err := u.Ledger.Credit(ctx, tx, topUp); err != nil {
 		u.Logger.WithField("requester", payload).Error(err.Error())
 		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
 
 	return httpResponse.Ok("").NewResponses(nil, "Top up success")
 }
+
+func (u *UsecaseImpl) GetShareRideTrace(ctx context.Context, shareRideId int64) responses.Responses {
+
+	points, err := u.TraceRepository.FindByShareRide(ctx, shareRideId, nil, nil)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithField("shareRideId", shareRideId).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Ok("").NewResponses(shareride.NewTrace(shareRideId, points), "")
+}
+
+// GetWalletDiscrepancy reports how far the coin balance drifted from the ledger, nothing is written.
+func (u *UsecaseImpl) GetWalletDiscrepancy(ctx context.Context, userId int64) responses.Responses {
+
+	discrepancy, err := u.Ledger.Compare(ctx, userId)
+	if err == exception.ErrNotFound {
+		return httpResponse.NotFound("").NewResponses(nil, "User not found")
+	}
+
+	if err != nil {
+		u.Logger.WithField("userId", userId).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if discrepancy.Difference == 0 {
+		return httpResponse.Ok("").NewResponses(discrepancy, "balance matches ledger")
+	}
+
+	return httpResponse.Ok("").NewResponses(discrepancy, "balance differs from ledger")
+}
+
+// ReconcileWallet writes the reviewed difference to the ledger as an adjustment with the administrator's reason.
+func (u *UsecaseImpl) ReconcileWallet(ctx context.Context, payload *model.ReconcileWallet) responses.Responses {
+
+	adjustment, err := u.Ledger.Reconcile(ctx, payload.ID, payload.Difference, payload.Reason)
+	if err == exception.ErrNotFound {
+		return httpResponse.NotFound("").NewResponses(nil, "User not found")
+	}
+
+	if err == exception.ErrConflict {
+		return httpResponse.Conflict("").NewResponses(nil, "balance changed since it was reviewed, compare it again")
+	}
+
+	if err != nil {
+		u.Logger.WithField("payload", payload).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Ok("").NewResponses(adjustment, "balance reconciled")
+}
+
+func (u *UsecaseImpl) GetWithdrawals(ctx context.Context, params *model.GetWithdrawalsParams) responses.Responses {
+
+	totalData, err := u.WithdrawalRepository.Count(ctx, nil, params)
+	if err != nil {
+		u.Logger.WithField("params", params).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	withdrawals, err := u.WithdrawalRepository.FindMany(ctx, nil, params)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithField("params", params).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if withdrawals == nil {
+		return httpResponse.NotFound("").NewResponses(nil, "no withdrawal")
+	}
+
+	return httpResponse.Ok("").NewResponsesOffsetPagination(withdrawals, int64(len(withdrawals)), totalData, "get withdrawals success")
+}
+
+func (u *UsecaseImpl) ApproveWithdrawal(ctx context.Context, withdrawalId int64) responses.Responses {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	fields := map[string]any{
+		"approved_at": date.CurrentUTCTime(),
+		"approved_by": requester.ID,
+	}
+
+	return u.transitionWithdrawal(ctx, withdrawalId, withdrawals.StatusApproved, fields, withdrawals.StatusRequested)
+}
+
+// RejectWithdrawal gives the held amount back to the driver.
+func (u *UsecaseImpl) RejectWithdrawal(ctx context.Context, payload *model.RejectWithdrawal) responses.Responses {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	fields := map[string]any{
+		"rejected_at":      date.CurrentUTCTime(),
+		"rejected_by":      requester.ID,
+		"rejection_reason": payload.Reason,
+	}
+
+	return u.transitionWithdrawal(ctx, payload.ID, withdrawals.StatusRejected, fields, withdrawals.StatusRequested, withdrawals.StatusApproved)
+}
+
+// PayWithdrawal records the bank transfer of an approved withdrawal, the held amount has already left the balance.
+func (u *UsecaseImpl) PayWithdrawal(ctx context.Context, payload *model.PayWithdrawal) responses.Responses {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	fields := map[string]any{
+		"paid_at":          date.CurrentUTCTime(),
+		"paid_by":          requester.ID,
+		"payout_reference": payload.PayoutReference,
+	}
+
+	return u.transitionWithdrawal(ctx, payload.ID, withdrawals.StatusPaid, fields, withdrawals.StatusApproved)
+}
+
+func (u *UsecaseImpl) transitionWithdrawal(ctx context.Context, withdrawalId int64, toStatus string, updateFields map[string]any, fromStatuses ...string) responses.Responses {
+
+	tx, err := u.WithdrawalRepository.BeginTx(ctx)
+	if err != nil {
+		u.Logger.WithContext(ctx).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	withdrawal, err := u.WithdrawalRepository.FindOne(ctx, tx, withdrawalId)
+	if err != nil {
+		u.WithdrawalRepository.RollbackTx(ctx, tx)
+		if err == exception.ErrNotFound {
+			return httpResponse.NotFound("").NewResponses(nil, "withdrawal not found")
+		}
+		u.Logger.WithContext(ctx).WithField("withdrawalId", withdrawalId).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	allowed := false
+	for _, status := range fromStatuses {
+		if withdrawal.Status == status {
+			allowed = true
+		}
+	}
+
+	if !allowed {
+		u.WithdrawalRepository.RollbackTx(ctx, tx)
+		return httpResponse.Conflict("").NewResponses(nil, fmt.Sprintf("a %s withdrawal cannot be %s", withdrawal.Status, toStatus))
+	}
+
+	fields := logrus.Fields{"withdrawal": withdrawal, "toStatus": toStatus}
+
+	if err = u.WithdrawalRepository.UpdateStatus(ctx, tx, withdrawal.ID, withdrawal.Status, toStatus, updateFields); err != nil {
+		u.WithdrawalRepository.RollbackTx(ctx, tx)
+		if err == exception.ErrConflict {
+			return httpResponse.Conflict("").NewResponses(nil, "withdrawal changed, please retry")
+		}
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if toStatus == withdrawals.StatusRejected {
+		refund := &wallet.Entry{
+			UserId:        withdrawal.UserId,
+			Amount:        withdrawal.Amount,
+			ReferenceType: wallet.ReferenceWithdrawal,
+			ReferenceId:   &withdrawal.ID,
+			Description:   "withdrawal rejected",
+		}
+
+		if _, err = u.Ledger.Credit(ctx, tx, refund); err != nil {
+			u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+			u.WithdrawalRepository.RollbackTx(ctx, tx)
+			return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+		}
+	}
+
+	if err = u.WithdrawalRepository.CommitTx(ctx, tx); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.WithdrawalRepository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Ok("").NewResponses(nil, fmt.Sprintf("withdrawal %s", toStatus))
+}
+
+func (u *UsecaseImpl) CreatePromotion(ctx context.Context, payload *model.SavePromotion) responses.Responses {
+
+	if resp := validatePromotion(payload); resp != nil {
+		return resp
+	}
+
+	now := date.CurrentUTCTime()
+	promotion := newPromotion(payload)
+	promotion.CreatedAt = *now
+	promotion.UpdatedAt = *now
+
+	var err error
+	if promotion.ID, err = u.PromotionRepository.Insert(ctx, promotion); err != nil {
+		if err == exception.ErrConflict {
+			return httpResponse.Conflict("").NewResponses(nil, "promo code already exists")
+		}
+		u.Logger.WithField("payload", payload).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Created("").NewResponses(promotion, "promotion created")
+}
+
+func (u *UsecaseImpl) GetPromotions(ctx context.Context, params *model.GetPromotionsParams) responses.Responses {
+
+	totalData, err := u.PromotionRepository.Count(ctx)
+	if err != nil {
+		u.Logger.WithField("params", params).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	promotions, err := u.PromotionRepository.FindMany(ctx, params)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithField("params", params).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if promotions == nil {
+		return httpResponse.NotFound("").NewResponses(nil, "no promotion")
+	}
+
+	return httpResponse.Ok("").NewResponsesOffsetPagination(promotions, int64(len(promotions)), totalData, "get promotions success")
+}
+
+func (u *UsecaseImpl) GetPromotion(ctx context.Context, promotionId int64) responses.Responses {
+
+	promotion, err := u.PromotionRepository.FindOne(ctx, promotionId)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithField("promotionId", promotionId).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if promotion == nil {
+		return httpResponse.NotFound("").NewResponses(nil, "promotion not found")
+	}
+
+	return httpResponse.Ok("").NewResponses(promotion, "get promotion success")
+}
+
+func (u *UsecaseImpl) UpdatePromotion(ctx context.Context, promotionId int64, payload *model.SavePromotion) responses.Responses {
+
+	if resp := validatePromotion(payload); resp != nil {
+		return resp
+	}
+
+	existing, err := u.PromotionRepository.FindOne(ctx, promotionId)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithField("promotionId", promotionId).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if existing == nil {
+		return httpResponse.NotFound("").NewResponses(nil, "promotion not found")
+	}
+
+	promotion := newPromotion(payload)
+	promotion.ID = existing.ID
+	promotion.CreatedAt = existing.CreatedAt
+	promotion.UpdatedAt = *date.CurrentUTCTime()
+
+	if err := u.PromotionRepository.Update(ctx, promotion); err != nil {
+		if err == exception.ErrConflict {
+			return httpResponse.Conflict("").NewResponses(nil, "promo code already exists")
+		}
+		u.Logger.WithField("payload", payload).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Ok("").NewResponses(promotion, "promotion updated")
+}
+
+func (u *UsecaseImpl) DeletePromotion(ctx context.Context, promotionId int64) responses.Responses {
+
+	if err := u.PromotionRepository.Delete(ctx, promotionId); err != nil {
+		if err == exception.ErrNotFound {
+			return httpResponse.NotFound("").NewResponses(nil, "promotion not found")
+		}
+		u.Logger.WithField("promotionId", promotionId).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Ok("").NewResponses(nil, "promotion deleted")
+}
+
+func validatePromotion(payload *model.SavePromotion) responses.Responses {
+	if payload.DiscountType == promotions.DiscountPercentage && payload.DiscountValue > 100 {
+		return httpResponse.BadRequest("").NewResponses(nil, "percentage discount cannot be more than 100")
+	}
+	return nil
+}
+
+func newPromotion(payload *model.SavePromotion) *entity.Promotion {
+	return &entity.Promotion{
+		Code:              strings.ToUpper(payload.Code),
+		Name:              payload.Name,
+		DiscountType:      payload.DiscountType,
+		DiscountValue:     payload.DiscountValue,
+		MaxDiscount:       payload.MaxDiscount,
+		MinimumFare:       payload.MinimumFare,
+		FirstRideOnly:     payload.FirstRideOnly,
+		UsageLimit:        payload.UsageLimit,
+		UsageLimitPerUser: payload.UsageLimitPerUser,
+		StartsAt:          payload.StartsAt,
+		EndsAt:            payload.EndsAt,
+		IsActive:          payload.IsActive,
+	}
+}
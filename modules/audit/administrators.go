@@ -0,0 +1,183 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/modules/administrators"
+	"github.com/Difaal21/nebeng-dong/modules/promotions"
+	"github.com/Difaal21/nebeng-dong/modules/users"
+	"github.com/Difaal21/nebeng-dong/modules/withdrawals"
+	"github.com/Difaal21/nebeng-dong/responses"
+	"github.com/sirupsen/logrus"
+)
+
+// Administrators decorates the administrators usecase: mutations are recorded with a snapshot of their target
+// taken before and after, reads go straight to the wrapped usecase. A new mutation needs an override here.
+type Administrators struct {
+	administrators.Usecase
+	Recorder                Recorder
+	UserRepository          users.Repository
+	WithdrawalRepository    withdrawals.Repository
+	PromotionRepository     promotions.Repository
+	AdministratorRepository administrators.Repository
+	Logger                  *logrus.Logger
+}
+
+func NewAdministrators(usecase administrators.Usecase, recorder Recorder, userRepository users.Repository, withdrawalRepository withdrawals.Repository, promotionRepository promotions.Repository, administratorRepository administrators.Repository, logger *logrus.Logger) administrators.Usecase {
+	return &Administrators{
+		Usecase:                 usecase,
+		Recorder:                recorder,
+		UserRepository:          userRepository,
+		WithdrawalRepository:    withdrawalRepository,
+		PromotionRepository:     promotionRepository,
+		AdministratorRepository: administratorRepository,
+		Logger:                  logger,
+	}
+}
+
+// userSnapshot leaves the password hash and profile details out of the log.
+type userSnapshot struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Coin  int64  `json:"coin"`
+}
+
+func (a *Administrators) TopUpCoinBalance(ctx context.Context, payload *model.TopUpCoinBalance) responses.Responses {
+	before := a.user(ctx, payload.ID)
+	resp := a.Usecase.TopUpCoinBalance(ctx, payload)
+	a.Recorder.Record(ctx, &Entry{ActionTopUpCoinBalance, TargetUser, &payload.ID, before, a.user(ctx, payload.ID), resp})
+	return resp
+}
+
+func (a *Administrators) ReconcileWallet(ctx context.Context, payload *model.ReconcileWallet) responses.Responses {
+	before := a.user(ctx, payload.ID)
+	resp := a.Usecase.ReconcileWallet(ctx, payload)
+	a.Recorder.Record(ctx, &Entry{ActionReconcileWallet, TargetUser, &payload.ID, before, a.user(ctx, payload.ID), resp})
+	return resp
+}
+
+func (a *Administrators) ApproveWithdrawal(ctx context.Context, withdrawalId int64) responses.Responses {
+	before := a.withdrawal(ctx, withdrawalId)
+	resp := a.Usecase.ApproveWithdrawal(ctx, withdrawalId)
+	a.Recorder.Record(ctx, &Entry{ActionApproveWithdrawal, TargetWithdrawal, &withdrawalId, before, a.withdrawal(ctx, withdrawalId), resp})
+	return resp
+}
+
+func (a *Administrators) RejectWithdrawal(ctx context.Context, payload *model.RejectWithdrawal) responses.Responses {
+	before := a.withdrawal(ctx, payload.ID)
+	resp := a.Usecase.RejectWithdrawal(ctx, payload)
+	a.Recorder.Record(ctx, &Entry{ActionRejectWithdrawal, TargetWithdrawal, &payload.ID, before, a.withdrawal(ctx, payload.ID), resp})
+	return resp
+}
+
+func (a *Administrators) PayWithdrawal(ctx context.Context, payload *model.PayWithdrawal) responses.Responses {
+	before := a.withdrawal(ctx, payload.ID)
+	resp := a.Usecase.PayWithdrawal(ctx, payload)
+	a.Recorder.Record(ctx, &Entry{ActionPayWithdrawal, TargetWithdrawal, &payload.ID, before, a.withdrawal(ctx, payload.ID), resp})
+	return resp
+}
+
+func (a *Administrators) CreatePromotion(ctx context.Context, payload *model.SavePromotion) responses.Responses {
+	resp := a.Usecase.CreatePromotion(ctx, payload)
+
+	var targetId *int64
+	if promotion, ok := resp.DataProperty().(*entity.Promotion); ok {
+		targetId = &promotion.ID
+	}
+
+	a.Recorder.Record(ctx, &Entry{ActionCreatePromotion, TargetPromotion, targetId, nil, resp.DataProperty(), resp})
+	return resp
+}
+
+func (a *Administrators) UpdatePromotion(ctx context.Context, promotionId int64, payload *model.SavePromotion) responses.Responses {
+	before := a.promotion(ctx, promotionId)
+	resp := a.Usecase.UpdatePromotion(ctx, promotionId, payload)
+	a.Recorder.Record(ctx, &Entry{ActionUpdatePromotion, TargetPromotion, &promotionId, before, a.promotion(ctx, promotionId), resp})
+	return resp
+}
+
+func (a *Administrators) DeletePromotion(ctx context.Context, promotionId int64) responses.Responses {
+	before := a.promotion(ctx, promotionId)
+	resp := a.Usecase.DeletePromotion(ctx, promotionId)
+	a.Recorder.Record(ctx, &Entry{ActionDeletePromotion, TargetPromotion, &promotionId, before, a.promotion(ctx, promotionId), resp})
+	return resp
+}
+
+func (a *Administrators) CreateAdministrator(ctx context.Context, payload *model.CreateAdministrator) responses.Responses {
+	resp := a.Usecase.CreateAdministrator(ctx, payload)
+
+	var targetId *int64
+	if administrator, ok := resp.DataProperty().(*entity.Administrator); ok {
+		targetId = &administrator.ID
+	}
+
+	a.Recorder.Record(ctx, &Entry{ActionCreateAdministrator, TargetAdministrator, targetId, nil, resp.DataProperty(), resp})
+	return resp
+}
+
+func (a *Administrators) UpdateAdministrator(ctx context.Context, administratorId int64, payload *model.UpdateAdministrator) responses.Responses {
+	before := a.administrator(ctx, administratorId)
+	resp := a.Usecase.UpdateAdministrator(ctx, administratorId, payload)
+	a.Recorder.Record(ctx, &Entry{ActionUpdateAdministrator, TargetAdministrator, &administratorId, before, a.administrator(ctx, administratorId), resp})
+	return resp
+}
+
+func (a *Administrators) DeleteAdministrator(ctx context.Context, administratorId int64) responses.Responses {
+	before := a.administrator(ctx, administratorId)
+	resp := a.Usecase.DeleteAdministrator(ctx, administratorId)
+	a.Recorder.Record(ctx, &Entry{ActionDeleteAdministrator, TargetAdministrator, &administratorId, before, a.administrator(ctx, administratorId), resp})
+	return resp
+}
+
+// The snapshot loaders return nil when the target does not exist (anymore) or cannot be read.
+
+func (a *Administrators) user(ctx context.Context, userId int64) any {
+	user, err := a.UserRepository.FindOneById(ctx, userId)
+	if err != nil {
+		a.logSnapshotError(ctx, err, TargetUser, userId)
+		return nil
+	}
+
+	return &userSnapshot{ID: user.ID, Name: user.Name, Email: user.Email, Coin: user.Coin}
+}
+
+func (a *Administrators) withdrawal(ctx context.Context, withdrawalId int64) any {
+	withdrawal, err := a.WithdrawalRepository.FindOne(ctx, nil, withdrawalId)
+	if err != nil {
+		a.logSnapshotError(ctx, err, TargetWithdrawal, withdrawalId)
+		return nil
+	}
+
+	return withdrawal
+}
+
+func (a *Administrators) promotion(ctx context.Context, promotionId int64) any {
+	promotion, err := a.PromotionRepository.FindOne(ctx, promotionId)
+	if err != nil {
+		a.logSnapshotError(ctx, err, TargetPromotion, promotionId)
+		return nil
+	}
+
+	return promotion
+}
+
+func (a *Administrators) administrator(ctx context.Context, administratorId int64) any {
+	administrator, err := a.AdministratorRepository.FindOne(ctx, nil, administratorId)
+	if err != nil {
+		a.logSnapshotError(ctx, err, TargetAdministrator, administratorId)
+		return nil
+	}
+
+	return administrator
+}
+
+func (a *Administrators) logSnapshotError(ctx context.Context, err error, targetType string, targetId int64) {
+	if err == exception.ErrNotFound {
+		return
+	}
+	a.Logger.WithContext(ctx).WithFields(logrus.Fields{"targetType": targetType, "targetId": targetId}).Error(err)
+}
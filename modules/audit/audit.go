@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/helpers/date"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/responses"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ActionTopUpCoinBalance    = "user.top_up"
+	ActionReconcileWallet     = "user.reconcile_wallet"
+	ActionApproveWithdrawal   = "withdrawal.approve"
+	ActionRejectWithdrawal    = "withdrawal.reject"
+	ActionPayWithdrawal       = "withdrawal.pay"
+	ActionCreatePromotion     = "promotion.create"
+	ActionUpdatePromotion     = "promotion.update"
+	ActionDeletePromotion     = "promotion.delete"
+	ActionCreateAdministrator = "administrator.create"
+	ActionUpdateAdministrator = "administrator.update"
+	ActionDeleteAdministrator = "administrator.delete"
+
+	TargetUser          = "user"
+	TargetWithdrawal    = "withdrawal"
+	TargetPromotion     = "promotion"
+	TargetAdministrator = "administrator"
+)
+
+// widths of the ip_address and user_agent columns, in characters
+const (
+	ipAddressLength = 45
+	userAgentLength = 255
+)
+
+// Entry is what a decorated usecase knows about one mutation, the recorder adds the actor and where the request
+// came from.
+type Entry struct {
+	Action     string
+	TargetType string
+	TargetId   *int64
+	Before     any
+	After      any
+	Response   responses.Responses
+}
+
+// Recorder stores entries, failed attempts included. The mutation already happened, so a failure is only logged.
+type Recorder interface {
+	Record(ctx context.Context, entry *Entry)
+}
+
+type RecorderImpl struct {
+	Repository Repository
+	Logger     *logrus.Logger
+}
+
+func NewRecorderImpl(repo Repository, logger *logrus.Logger) Recorder {
+	return &RecorderImpl{
+		Repository: repo,
+		Logger:     logger,
+	}
+}
+
+func (r *RecorderImpl) Record(ctx context.Context, entry *Entry) {
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		r.Logger.WithContext(ctx).WithField("action", entry.Action).Error(err)
+		return
+	}
+
+	metadata := model.GetRequestMetadata(ctx)
+
+	log := &entity.AuditLog{
+		ActorId:    requester.ID,
+		ActorEmail: requester.Email,
+		ActorRole:  requester.Role,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetId:   entry.TargetId,
+		Before:     r.snapshot(ctx, entry.Before),
+		After:      r.snapshot(ctx, entry.After),
+		StatusCode: entry.Response.CodeProperty(),
+		IPAddress:  truncate(metadata.IPAddress, ipAddressLength),
+		UserAgent:  truncate(metadata.UserAgent, userAgentLength),
+		CreatedAt:  *date.CurrentUTCTime(),
+	}
+
+	if _, err := r.Repository.Insert(ctx, log); err != nil {
+		r.Logger.WithContext(ctx).WithFields(logrus.Fields{"action": entry.Action, "actorId": requester.ID, "targetId": entry.TargetId}).Error(err)
+	}
+}
+
+// truncate cuts value to length characters, so an oversized header does not fail the insert.
+func truncate(value string, length int) string {
+	runes := []rune(value)
+	if len(runes) <= length {
+		return value
+	}
+	return string(runes[:length])
+}
+
+// snapshot returns nil for a missing target, so the column stays NULL instead of holding "null".
+func (r *RecorderImpl) snapshot(ctx context.Context, value any) json.RawMessage {
+	if value == nil {
+		return nil
+	}
+
+	snapshot, err := json.Marshal(value)
+	if err != nil {
+		r.Logger.WithContext(ctx).Error(err)
+		return nil
+	}
+
+	if string(snapshot) == "null" {
+		return nil
+	}
+
+	return snapshot
+}
@@ -0,0 +1,77 @@
+package audit
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/helpers/validation"
+	"github.com/Difaal21/nebeng-dong/middleware"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/modules/administrators"
+	"github.com/Difaal21/nebeng-dong/responses"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+var httpResponse = responses.HttpResponseStatusCodesImpl{}
+
+type HTTPHandler struct {
+	Usecase       Usecase
+	Authorization *middleware.Authorization
+}
+
+func NewHTTPHandler(router *gin.Engine, authorization *middleware.Authorization, usecase Usecase) {
+
+	handler := &HTTPHandler{
+		Usecase:       usecase,
+		Authorization: authorization,
+	}
+
+	router.GET("/nebengdong-service/administrators/v1/audit-logs", authorization.Require(administrators.PermissionReadAuditLogs), handler.GetAuditLogs)
+}
+
+func (handler *HTTPHandler) GetAuditLogs(c *gin.Context) {
+	context := c.Request.Context()
+
+	queryString := c.Request.URL.Query()
+
+	page, _ := strconv.Atoi(queryString.Get("page"))
+	size, _ := strconv.Atoi(queryString.Get("size"))
+	actorId, _ := strconv.ParseInt(queryString.Get("actorId"), 10, 64)
+	targetId, _ := strconv.ParseInt(queryString.Get("targetId"), 10, 64)
+
+	params := model.GetAuditLogsParams{
+		ActorId:    actorId,
+		Action:     queryString.Get("action"),
+		TargetType: queryString.Get("targetType"),
+		TargetId:   targetId,
+		Page:       int64(page),
+		Size:       int64(size),
+	}
+
+	for key, value := range map[string]**time.Time{"from": &params.From, "to": &params.To} {
+		if queryString.Get(key) == "" {
+			continue
+		}
+
+		at, err := time.Parse(time.RFC3339, queryString.Get(key))
+		if err != nil {
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(nil, key+" must be an RFC 3339 time"))
+			return
+		}
+		*value = &at
+	}
+
+	if err := c.ShouldBind(&params); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, params)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.GetAuditLogs(context, &params)
+	responses.REST(c, resp)
+}
@@ -0,0 +1,226 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/sirupsen/logrus"
+)
+
+type Repository interface {
+	Insert(ctx context.Context, log *entity.AuditLog) (id int64, err error)
+	FindMany(ctx context.Context, params *model.GetAuditLogsParams) (logs []entity.AuditLog, err error)
+	Count(ctx context.Context, params *model.GetAuditLogsParams) (total int64, err error)
+}
+
+type RepositoryImpl struct {
+	DB        *sql.DB
+	Logger    *logrus.Logger
+	TableName string
+}
+
+func NewRepositoryImpl(db *sql.DB, logger *logrus.Logger) Repository {
+	return &RepositoryImpl{
+		DB:        db,
+		Logger:    logger,
+		TableName: "audit_logs",
+	}
+}
+
+func (repo *RepositoryImpl) Insert(ctx context.Context, log *entity.AuditLog) (id int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	command := fmt.Sprintf(`
+	INSERT INTO %s
+	SET
+		id = ?,
+		actor_id = ?,
+		actor_email = ?,
+		actor_role = ?,
+		action = ?,
+		target_type = ?,
+		target_id = ?,
+		before_snapshot = ?,
+		after_snapshot = ?,
+		status_code = ?,
+		ip_address = ?,
+		user_agent = ?,
+		created_at = ?
+	`, repo.TableName)
+
+	result, err := Exec(ctx, cmd, command, log.ID, log.ActorId, log.ActorEmail, log.ActorRole, log.Action, log.TargetType, log.TargetId, []byte(log.Before), []byte(log.After), log.StatusCode, log.IPAddress, log.UserAgent, log.CreatedAt)
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return
+	}
+
+	if id, err = result.LastInsertId(); err != nil {
+		return
+	}
+	return
+}
+
+func (repo *RepositoryImpl) FindMany(ctx context.Context, params *model.GetAuditLogsParams) (logs []entity.AuditLog, err error) {
+	var cmd SqlCommand = repo.DB
+
+	var offset = (params.Page - 1) * params.Size
+
+	where, args := filter(params)
+
+	query := fmt.Sprintf(`
+	SELECT
+		al.id,
+		al.actor_id,
+		al.actor_email,
+		al.actor_role,
+		al.action,
+		al.target_type,
+		al.target_id,
+		al.before_snapshot,
+		al.after_snapshot,
+		al.status_code,
+		al.ip_address,
+		al.user_agent,
+		al.created_at
+	FROM
+		%s al
+	%s
+	ORDER BY
+		al.id DESC
+	LIMIT %d OFFSET %d
+	`, repo.TableName, where, params.Size, offset)
+
+	return repo.Query(ctx, cmd, query, args...)
+}
+
+func (repo *RepositoryImpl) Count(ctx context.Context, params *model.GetAuditLogsParams) (total int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	where, args := filter(params)
+
+	query := fmt.Sprintf(`SELECT COUNT(al.id) FROM %s al %s`, repo.TableName, where)
+
+	if err = cmd.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return
+	}
+
+	return
+}
+
+// filter turns the set params into a WHERE clause and its arguments.
+func filter(params *model.GetAuditLogsParams) (where string, args []interface{}) {
+	var conditions []string
+
+	if params.ActorId > 0 {
+		conditions = append(conditions, "al.actor_id = ?")
+		args = append(args, params.ActorId)
+	}
+
+	if params.Action != "" {
+		conditions = append(conditions, "al.action = ?")
+		args = append(args, params.Action)
+	}
+
+	if params.TargetType != "" {
+		conditions = append(conditions, "al.target_type = ?")
+		args = append(args, params.TargetType)
+	}
+
+	if params.TargetId > 0 {
+		conditions = append(conditions, "al.target_id = ?")
+		args = append(args, params.TargetId)
+	}
+
+	if params.From != nil {
+		conditions = append(conditions, "al.created_at >= ?")
+		args = append(args, params.From.UTC())
+	}
+
+	if params.To != nil {
+		conditions = append(conditions, "al.created_at < ?")
+		args = append(args, params.To.UTC())
+	}
+
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	return
+}
+
+// ==================================================================================================================== //
+type SqlCommand interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// ==================================================================================================================== //
+
+func Exec(ctx context.Context, cmd SqlCommand, command string, args ...interface{}) (result sql.Result, err error) {
+	var stmt *sql.Stmt
+	if stmt, err = cmd.PrepareContext(ctx, command); err != nil {
+		return
+	}
+
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			return
+		}
+	}()
+
+	if result, err = stmt.ExecContext(ctx, args...); err != nil {
+		return
+	}
+
+	return
+}
+
+func (repo *RepositoryImpl) Query(ctx context.Context, cmd SqlCommand, query string, args ...interface{}) (logs []entity.AuditLog, err error) {
+
+	var rows *sql.Rows
+	if rows, err = cmd.QueryContext(ctx, query, args...); err != nil {
+		repo.Logger.Error(err.Error())
+		return
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+	}()
+
+	for rows.Next() {
+		var (
+			log    entity.AuditLog
+			before []byte
+			after  []byte
+		)
+
+		err = rows.Scan(&log.ID, &log.ActorId, &log.ActorEmail, &log.ActorRole, &log.Action, &log.TargetType, &log.TargetId, &before, &after, &log.StatusCode, &log.IPAddress, &log.UserAgent, &log.CreatedAt)
+		if err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+
+		log.Before = before
+		log.After = after
+
+		logs = append(logs, log)
+	}
+
+	if logs == nil {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
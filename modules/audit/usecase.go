@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/responses"
+	"github.com/sirupsen/logrus"
+)
+
+type Usecase interface {
+	GetAuditLogs(ctx context.Context, params *model.GetAuditLogsParams) responses.Responses
+}
+
+type UsecaseImpl struct {
+	Repository Repository
+	Logger     *logrus.Logger
+}
+
+func NewUsecaseImpl(repo Repository, logger *logrus.Logger) Usecase {
+	return &UsecaseImpl{
+		Repository: repo,
+		Logger:     logger,
+	}
+}
+
+func (u *UsecaseImpl) GetAuditLogs(ctx context.Context, params *model.GetAuditLogsParams) responses.Responses {
+
+	totalData, err := u.Repository.Count(ctx, params)
+	if err != nil {
+		u.Logger.WithField("params", params).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	logs, err := u.Repository.FindMany(ctx, params)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithField("params", params).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if logs == nil {
+		return httpResponse.NotFound("").NewResponses(nil, "no audit log")
+	}
+
+	return httpResponse.Ok("").NewResponsesOffsetPagination(logs, int64(len(logs)), totalData, "get audit logs success")
+}
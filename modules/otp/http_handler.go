@@ -0,0 +1,47 @@
+package otp
+
+import (
+	"strings"
+
+	"github.com/Difaal21/nebeng-dong/helpers/validation"
+	"github.com/Difaal21/nebeng-dong/middleware"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/responses"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+var httpResponse = responses.HttpResponseStatusCodesImpl{}
+
+type HTTPHandler struct {
+	Usecase Usecase
+}
+
+func NewHTTPHandler(router *gin.Engine, basicAuth *middleware.BasicAuth, usecase Usecase) {
+
+	handler := &HTTPHandler{
+		Usecase: usecase,
+	}
+
+	router.POST("/nebengdong-service/v1/otp", basicAuth.Verify, handler.RequestOTP)
+}
+
+func (handler *HTTPHandler) RequestOTP(c *gin.Context) {
+	context := c.Request.Context()
+
+	var payload model.RequestOTP
+
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, payload)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	payload.PhoneNumber = strings.ReplaceAll(payload.PhoneNumber, " ", "")
+	resp := handler.Usecase.RequestOTP(context, &payload)
+	responses.REST(c, resp)
+}
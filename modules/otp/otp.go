@@ -0,0 +1,145 @@
+package otp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/helpers/date"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	PurposeRegistration      = "registration"
+	PurposeChangePhoneNumber = "change_phone_number"
+)
+
+const (
+	codeLength           = 6
+	defaultTTL           = 5 * time.Minute
+	defaultMaxAttempts   = 5
+	defaultResend        = time.Minute
+	defaultLimitPerHour  = 5
+	verificationSMSText  = "%s is your Nebeng Dong verification code, valid for %s. Never share it with anyone."
+	invalidOTPReason     = "verification code is invalid or expired"
+	tooManyAttemptReason = "too many wrong verification codes, please request a new one"
+)
+
+func ttl() time.Duration {
+	if minutes, err := strconv.ParseInt(os.Getenv("OTP_TTL_MINUTES"), 10, 64); err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return defaultTTL
+}
+
+func maxAttempts() int64 {
+	if attempts, err := strconv.ParseInt(os.Getenv("OTP_MAX_ATTEMPTS"), 10, 64); err == nil && attempts > 0 {
+		return attempts
+	}
+	return defaultMaxAttempts
+}
+
+func resendInterval() time.Duration {
+	if seconds, err := strconv.ParseInt(os.Getenv("OTP_RESEND_SECONDS"), 10, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultResend
+}
+
+func limitPerHour() int64 {
+	if limit, err := strconv.ParseInt(os.Getenv("OTP_LIMIT_PER_HOUR"), 10, 64); err == nil && limit > 0 {
+		return limit
+	}
+	return defaultLimitPerHour
+}
+
+// hashCode binds the code to its phone number and purpose, so a code is worthless anywhere else.
+func hashCode(phoneNumber, purpose, code string) string {
+	digest := sha256.Sum256([]byte(purpose + ":" + phoneNumber + ":" + code))
+	return hex.EncodeToString(digest[:])
+}
+
+func generateCode() (code string, err error) {
+	max := big.NewInt(1)
+	for i := 0; i < codeLength; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return
+	}
+
+	return fmt.Sprintf("%0*d", codeLength, n.Int64()), nil
+}
+
+// Invalid explains why a code was refused, the reason is safe to show to the user.
+type Invalid struct {
+	Reason string
+}
+
+func (e *Invalid) Error() string {
+	return e.Reason
+}
+
+// Service checks the codes texted by the usecase.
+type Service interface {
+	// Verify uses the latest code sent to the phone number for the purpose. The code is only used up when tx
+	// commits, while wrong guesses count against the code right away.
+	Verify(ctx context.Context, tx *sql.Tx, phoneNumber string, purpose string, code string) (err error)
+}
+
+type ServiceImpl struct {
+	Repository Repository
+	Logger     *logrus.Logger
+}
+
+func NewServiceImpl(repo Repository, logger *logrus.Logger) Service {
+	return &ServiceImpl{
+		Repository: repo,
+		Logger:     logger,
+	}
+}
+
+func (s *ServiceImpl) Verify(ctx context.Context, tx *sql.Tx, phoneNumber string, purpose string, code string) (err error) {
+	otp, err := s.Repository.FindLatest(ctx, phoneNumber, purpose)
+	if err == exception.ErrNotFound {
+		return &Invalid{Reason: invalidOTPReason}
+	}
+	if err != nil {
+		return
+	}
+
+	now := date.CurrentUTCTime()
+	if otp.UsedAt != nil || now.After(otp.ExpiresAt) {
+		return &Invalid{Reason: invalidOTPReason}
+	}
+
+	if otp.Attempts >= maxAttempts() {
+		return &Invalid{Reason: tooManyAttemptReason}
+	}
+
+	if subtle.ConstantTimeCompare([]byte(otp.CodeHash), []byte(hashCode(phoneNumber, purpose, code))) != 1 {
+		if err := s.Repository.AddAttempt(ctx, otp.ID, maxAttempts()); err == exception.ErrConflict {
+			return &Invalid{Reason: tooManyAttemptReason}
+		} else if err != nil {
+			return err
+		}
+		return &Invalid{Reason: invalidOTPReason}
+	}
+
+	if err = s.Repository.MarkUsed(ctx, tx, otp.ID, *now); err == exception.ErrConflict {
+		return &Invalid{Reason: invalidOTPReason}
+	}
+
+	return
+}
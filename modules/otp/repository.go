@@ -0,0 +1,197 @@
+package otp
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/sirupsen/logrus"
+)
+
+type Repository interface {
+	Insert(ctx context.Context, tx *sql.Tx, otp *entity.OTP) (id int64, err error)
+	// FindLatest returns the last code sent to the phone number for the purpose.
+	FindLatest(ctx context.Context, phoneNumber string, purpose string) (otp *entity.OTP, err error)
+	CountSince(ctx context.Context, phoneNumber string, since time.Time) (total int64, err error)
+	// AddAttempt returns exception.ErrConflict when the code has no attempt left.
+	AddAttempt(ctx context.Context, id int64, maxAttempts int64) (err error)
+	// MarkUsed returns exception.ErrConflict when the code was already used.
+	MarkUsed(ctx context.Context, tx *sql.Tx, id int64, usedAt time.Time) (err error)
+}
+
+type RepositoryImpl struct {
+	DB        *sql.DB
+	Logger    *logrus.Logger
+	TableName string
+}
+
+func NewRepositoryImpl(db *sql.DB, logger *logrus.Logger) Repository {
+	return &RepositoryImpl{
+		DB:        db,
+		Logger:    logger,
+		TableName: "otps",
+	}
+}
+
+func (repo *RepositoryImpl) Insert(ctx context.Context, tx *sql.Tx, otp *entity.OTP) (id int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`
+	INSERT INTO %s
+	SET
+		id = ?,
+		phone_number = ?,
+		purpose = ?,
+		code_hash = ?,
+		attempts = ?,
+		expires_at = ?,
+		created_at = ?
+	`, repo.TableName)
+
+	result, err := Exec(ctx, cmd, command, otp.ID, otp.PhoneNumber, otp.Purpose, otp.CodeHash, otp.Attempts, otp.ExpiresAt, otp.CreatedAt)
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return
+	}
+
+	if id, err = result.LastInsertId(); err != nil {
+		return
+	}
+	return
+}
+
+func (repo *RepositoryImpl) FindLatest(ctx context.Context, phoneNumber string, purpose string) (otp *entity.OTP, err error) {
+	var cmd SqlCommand = repo.DB
+
+	query := fmt.Sprintf(`
+	SELECT
+		o.id,
+		o.phone_number,
+		o.purpose,
+		o.code_hash,
+		o.attempts,
+		o.expires_at,
+		o.used_at,
+		o.created_at
+	FROM
+		%s o
+	WHERE
+		o.phone_number = ? AND o.purpose = ?
+	ORDER BY o.id DESC
+	LIMIT 1
+	`, repo.TableName)
+
+	otp = &entity.OTP{}
+
+	err = cmd.QueryRowContext(ctx, query, phoneNumber, purpose).Scan(&otp.ID, &otp.PhoneNumber, &otp.Purpose, &otp.CodeHash, &otp.Attempts, &otp.ExpiresAt, &otp.UsedAt, &otp.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, exception.ErrNotFound
+	}
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return nil, err
+	}
+
+	return
+}
+
+func (repo *RepositoryImpl) CountSince(ctx context.Context, phoneNumber string, since time.Time) (total int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	query := fmt.Sprintf(`SELECT COUNT(o.id) FROM %s o WHERE o.phone_number = ? AND o.created_at >= ?`, repo.TableName)
+
+	if err = cmd.QueryRowContext(ctx, query, phoneNumber, since).Scan(&total); err != nil {
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return
+	}
+
+	return
+}
+
+func (repo *RepositoryImpl) AddAttempt(ctx context.Context, id int64, maxAttempts int64) (err error) {
+	var cmd SqlCommand = repo.DB
+
+	command := fmt.Sprintf(`UPDATE %s SET attempts = attempts + 1 WHERE id = ? AND attempts < ?`, repo.TableName)
+
+	result, err := Exec(ctx, cmd, command, id, maxAttempts)
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	if affected < 1 {
+		return exception.ErrConflict
+	}
+
+	return
+}
+
+func (repo *RepositoryImpl) MarkUsed(ctx context.Context, tx *sql.Tx, id int64, usedAt time.Time) (err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`UPDATE %s SET used_at = ? WHERE id = ? AND used_at IS NULL`, repo.TableName)
+
+	result, err := Exec(ctx, cmd, command, usedAt, id)
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	if affected < 1 {
+		return exception.ErrConflict
+	}
+
+	return
+}
+
+// ==================================================================================================================== //
+type SqlCommand interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// ==================================================================================================================== //
+
+func Exec(ctx context.Context, cmd SqlCommand, command string, args ...interface{}) (result sql.Result, err error) {
+	var stmt *sql.Stmt
+	if stmt, err = cmd.PrepareContext(ctx, command); err != nil {
+		return
+	}
+
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			return
+		}
+	}()
+
+	if result, err = stmt.ExecContext(ctx, args...); err != nil {
+		return
+	}
+
+	return
+}
@@ -0,0 +1,28 @@
+package otp
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SMSSender texts a message to a phone number.
+type SMSSender interface {
+	Send(ctx context.Context, phoneNumber string, text string) (err error)
+}
+
+// LogSMSSender only logs the messages, for local runs until an SMS provider is plugged in.
+type LogSMSSender struct {
+	Logger *logrus.Logger
+}
+
+func NewLogSMSSender(logger *logrus.Logger) SMSSender {
+	return &LogSMSSender{
+		Logger: logger,
+	}
+}
+
+func (s *LogSMSSender) Send(ctx context.Context, phoneNumber string, text string) (err error) {
+	s.Logger.WithContext(ctx).WithField("phoneNumber", phoneNumber).Info(text)
+	return
+}
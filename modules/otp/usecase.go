@@ -0,0 +1,90 @@
+package otp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/helpers/date"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/responses"
+	"github.com/sirupsen/logrus"
+)
+
+type Usecase interface {
+	RequestOTP(ctx context.Context, payload *model.RequestOTP) responses.Responses
+}
+
+type UsecaseImpl struct {
+	Repository Repository
+	SMSSender  SMSSender
+	Logger     *logrus.Logger
+}
+
+func NewUsecaseImpl(repo Repository, smsSender SMSSender, logger *logrus.Logger) Usecase {
+	return &UsecaseImpl{
+		Repository: repo,
+		SMSSender:  smsSender,
+		Logger:     logger,
+	}
+}
+
+// RequestOTP texts a new code to the phone number, a previous code of the same purpose stops working.
+func (u *UsecaseImpl) RequestOTP(ctx context.Context, payload *model.RequestOTP) responses.Responses {
+
+	now := date.CurrentUTCTime()
+
+	latest, err := u.Repository.FindLatest(ctx, payload.PhoneNumber, payload.Purpose)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithContext(ctx).WithField("payload", payload).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if latest != nil && now.Before(latest.CreatedAt.Add(resendInterval())) {
+		return httpResponse.TooManyRequests("").NewResponses(nil, "verification code was just sent, please retry later")
+	}
+
+	sent, err := u.Repository.CountSince(ctx, payload.PhoneNumber, now.Add(-time.Hour))
+	if err != nil {
+		u.Logger.WithContext(ctx).WithField("payload", payload).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if sent >= limitPerHour() {
+		return httpResponse.TooManyRequests("").NewResponses(nil, "too many verification codes requested, please retry later")
+	}
+
+	code, err := generateCode()
+	if err != nil {
+		u.Logger.WithContext(ctx).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, "unexpected error")
+	}
+
+	codeTTL := ttl()
+	otp := &entity.OTP{
+		PhoneNumber: payload.PhoneNumber,
+		Purpose:     payload.Purpose,
+		CodeHash:    hashCode(payload.PhoneNumber, payload.Purpose, code),
+		Attempts:    0,
+		ExpiresAt:   now.Add(codeTTL),
+		CreatedAt:   *now,
+	}
+
+	if otp.ID, err = u.Repository.Insert(ctx, nil, otp); err != nil {
+		u.Logger.WithContext(ctx).WithField("payload", payload).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if err := u.SMSSender.Send(ctx, payload.PhoneNumber, fmt.Sprintf(verificationSMSText, code, codeTTL)); err != nil {
+		u.Logger.WithContext(ctx).WithField("payload", payload).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, "verification code could not be sent, please retry")
+	}
+
+	result := map[string]any{
+		"expiresIn": int64(codeTTL.Seconds()),
+	}
+
+	return httpResponse.Ok("").NewResponses(result, "verification code sent")
+}
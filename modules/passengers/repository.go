@@ -22,6 +22,8 @@ type Repository interface {
 	FindActivePassengerByShareRideId(ctx context.Context, shareRideId int64) (passenger *entity.Passengers, err error)
 	UpdateOne(ctx context.Context, tx *sql.Tx, id int64, updateFields map[string]any) (err error)
 	FindOnePassengerOnShareRide(ctx context.Context, shareRideId int64, passengerId int64) (passenger *entity.Passengers, err error)
+	FindLatestByShareRideAndUser(ctx context.Context, shareRideId int64, userId int64) (passenger *entity.Passengers, err error)
+	LockStatus(ctx context.Context, tx *sql.Tx, id int64) (status int16, err error)
 }
 
 type RepositoryImpl struct {
@@ -64,13 +66,15 @@ func (repo *RepositoryImpl) Insert(ctx context.Context, tx *sql.Tx, passenger *e
 		user_id = ?,
 		share_ride_id = ?,
 		status = ?,
+		pickup_coordinate = POINT(?, ?),
+		pickup_note = ?,
 		destination_coordinate = POINT(?, ?),
 		distance = ?,
 		created_at = ?,
 		dropped_at = ?
 	`, repo.TableName)
 
-	result, err := Exec(ctx, cmd, command, passenger.ID, passenger.UserId, passenger.ShareRideId, passenger.Status, passenger.DestinationCoordinate.Latitude, passenger.DestinationCoordinate.Longitude, passenger.Distance, passenger.CreatedAt, passenger.DroppedAt)
+	result, err := Exec(ctx, cmd, command, passenger.ID, passenger.UserId, passenger.ShareRideId, passenger.Status, passenger.PickupCoordinate.Latitude, passenger.PickupCoordinate.Longitude, passenger.PickupNote, passenger.DestinationCoordinate.Latitude, passenger.DestinationCoordinate.Longitude, passenger.Distance, passenger.CreatedAt, passenger.DroppedAt)
 	if err != nil {
 		repo.Logger.WithContext(ctx).Error(command, err.Error())
 		return
@@ -90,6 +94,9 @@ func (repo *RepositoryImpl) FindActivePassenger(ctx context.Context, shareRideId
 		p.id,
 		p.user_id,
 		p.status,
+		ST_X (p.pickup_coordinate),
+		ST_Y (p.pickup_coordinate),
+		p.pickup_note,
 		ST_X (p.destination_coordinate),
 		ST_Y (p.destination_coordinate),
 		p.distance,
@@ -128,6 +135,9 @@ func (repo *RepositoryImpl) FindActivePassengerByShareRideId(ctx context.Context
 		p.id,
 		p.user_id,
 		p.status,
+		ST_X (p.pickup_coordinate),
+		ST_Y (p.pickup_coordinate),
+		p.pickup_note,
 		ST_X (p.destination_coordinate),
 		ST_Y (p.destination_coordinate),
 		p.distance,
@@ -196,6 +206,22 @@ func (repo *RepositoryImpl) UpdateOne(ctx context.Context, tx *sql.Tx, id int64,
 	return
 }
 
+// LockStatus locks the passenger row until tx ends and returns its current status, so a status change decided on
+// an earlier read can be checked against concurrent ones.
+func (repo *RepositoryImpl) LockStatus(ctx context.Context, tx *sql.Tx, id int64) (status int16, err error) {
+	query := fmt.Sprintf(`SELECT status FROM %s WHERE id = ? FOR UPDATE`, repo.TableName)
+
+	if err = tx.QueryRowContext(ctx, query, id).Scan(&status); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, exception.ErrNotFound
+		}
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return
+	}
+
+	return
+}
+
 func (repo *RepositoryImpl) FindOnePassengerOnShareRide(ctx context.Context, shareRideId int64, passengerId int64) (passenger *entity.Passengers, err error) {
 	var cmd SqlCommand = repo.DB
 
@@ -204,14 +230,22 @@ func (repo *RepositoryImpl) FindOnePassengerOnShareRide(ctx context.Context, sha
 		p.id,
 		p.user_id,
 		p.status,
+		ST_X (p.pickup_coordinate),
+		ST_Y (p.pickup_coordinate),
+		p.pickup_note,
 		ST_X (p.destination_coordinate),
 		ST_Y (p.destination_coordinate),
 		p.distance,
 		p.created_at,
 		p.dropped_at,
 		p.share_ride_id,
+		p.picked_up_at,
+		p.travelled_distance,
+		pyt.id,
+		pyt.recipient_id,
 		pyt.status,
 		pyt.total_amount,
+		pyt.commission,
 		pytd.payment_method,
 		pytd.amount
 	FROM
@@ -238,6 +272,59 @@ func (repo *RepositoryImpl) FindOnePassengerOnShareRide(ctx context.Context, sha
 	return
 }
 
+// FindLatestByShareRideAndUser returns the user's latest booking on the share ride that was not skipped or cancelled.
+func (repo *RepositoryImpl) FindLatestByShareRideAndUser(ctx context.Context, shareRideId int64, userId int64) (passenger *entity.Passengers, err error) {
+	var cmd SqlCommand = repo.DB
+
+	query := fmt.Sprintf(`
+	SELECT
+		p.id,
+		p.user_id,
+		p.status,
+		ST_X (p.pickup_coordinate),
+		ST_Y (p.pickup_coordinate),
+		p.pickup_note,
+		ST_X (p.destination_coordinate),
+		ST_Y (p.destination_coordinate),
+		p.distance,
+		p.created_at,
+		p.dropped_at,
+		p.share_ride_id,
+		p.picked_up_at,
+		p.travelled_distance,
+		pyt.id,
+		pyt.recipient_id,
+		pyt.status,
+		pyt.total_amount,
+		pyt.commission,
+		pytd.payment_method,
+		pytd.amount
+	FROM
+	%s p
+		LEFT JOIN payment pyt ON pyt.passenger_id = p.id
+		LEFT JOIN payment_detail pytd ON pytd.payment_id = pyt.id
+	WHERE
+		p.share_ride_id = ? AND p.user_id = ? AND p.status IN (1, 2, 3, 4, 5)
+	ORDER BY
+		p.id ASC
+	`, repo.TableName)
+
+	passengers, err := repo.QueryRelationship(ctx, cmd, query, shareRideId, userId)
+	if err != nil {
+		return
+	}
+
+	lengthOfPassengers := len(passengers)
+	if lengthOfPassengers < 1 {
+		err = exception.ErrNotFound
+		return
+	}
+
+	passenger = &passengers[lengthOfPassengers-1]
+
+	return
+}
+
 // ==================================================================================================================== //
 type SqlCommand interface {
 	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
@@ -285,7 +372,7 @@ func (repo *RepositoryImpl) Query(ctx context.Context, cmd SqlCommand, query str
 	var passenger entity.Passengers
 
 	for rows.Next() {
-		err = rows.Scan(&passenger.ID, &passenger.UserId, &passenger.Status, &passenger.DestinationCoordinate.Latitude, &passenger.DestinationCoordinate.Longitude, &passenger.Distance, &passenger.CreatedAt, &passenger.DroppedAt, &passenger.ShareRideId)
+		err = rows.Scan(&passenger.ID, &passenger.UserId, &passenger.Status, &passenger.PickupCoordinate.Latitude, &passenger.PickupCoordinate.Longitude, &passenger.PickupNote, &passenger.DestinationCoordinate.Latitude, &passenger.DestinationCoordinate.Longitude, &passenger.Distance, &passenger.CreatedAt, &passenger.DroppedAt, &passenger.ShareRideId)
 		if err != nil {
 			repo.Logger.Error(err.Error())
 			return
@@ -317,15 +404,20 @@ func (repo *RepositoryImpl) QueryRelationship(ctx context.Context, cmd SqlComman
 		}
 	}()
 
-	var passenger entity.Passengers
-	var payment entity.Payment
-	var paymentDetails entity.PaymentDetails
+	// one row per payment detail, so rows of the same passenger and payment are merged
+	passengerIndexById := make(map[int64]int)
+	paymentsById := make(map[int64]*entity.Payment)
 
 	for rows.Next() {
 
+		var passenger entity.Passengers
+
 		var (
+			paymentId          sql.NullInt64
+			paymentRecipientId sql.NullInt64
 			paymentStatus      sql.NullString
 			paymentTotalAmount sql.NullInt64
+			paymentCommission  sql.NullInt64
 		)
 
 		var (
@@ -333,30 +425,44 @@ func (repo *RepositoryImpl) QueryRelationship(ctx context.Context, cmd SqlComman
 			paymentDetailAmount        sql.NullInt64
 		)
 
-		err = rows.Scan(&passenger.ID, &passenger.UserId, &passenger.Status, &passenger.DestinationCoordinate.Latitude, &passenger.DestinationCoordinate.Longitude, &passenger.Distance, &passenger.CreatedAt, &passenger.DroppedAt, &passenger.ShareRideId, &paymentStatus, &paymentTotalAmount, &paymentDetailPaymentMethod, &paymentDetailAmount)
+		err = rows.Scan(&passenger.ID, &passenger.UserId, &passenger.Status, &passenger.PickupCoordinate.Latitude, &passenger.PickupCoordinate.Longitude, &passenger.PickupNote, &passenger.DestinationCoordinate.Latitude, &passenger.DestinationCoordinate.Longitude, &passenger.Distance, &passenger.CreatedAt, &passenger.DroppedAt, &passenger.ShareRideId, &passenger.PickedUpAt, &passenger.TravelledDistance, &paymentId, &paymentRecipientId, &paymentStatus, &paymentTotalAmount, &paymentCommission, &paymentDetailPaymentMethod, &paymentDetailAmount)
 		if err != nil {
 			repo.Logger.Error(err.Error())
 			return
 		}
 
-		if paymentStatus.Valid {
-			payment = entity.Payment{
+		index, ok := passengerIndexById[passenger.ID]
+		if !ok {
+			passengers = append(passengers, passenger)
+			index = len(passengers) - 1
+			passengerIndexById[passenger.ID] = index
+		}
+
+		if !paymentId.Valid {
+			continue
+		}
+
+		payment := paymentsById[paymentId.Int64]
+		if payment == nil {
+			payment = &entity.Payment{
+				ID:          paymentId.Int64,
+				RecipientId: paymentRecipientId.Int64,
 				Status:      paymentStatus.String,
 				TotalAmount: paymentTotalAmount.Int64,
+				Commission:  paymentCommission.Int64,
 			}
 
-			passenger.Payment = append(passenger.Payment, &payment)
+			paymentsById[paymentId.Int64] = payment
+			passengers[index].Payment = append(passengers[index].Payment, payment)
 		}
 
-		if paymentStatus.Valid {
-			paymentDetails = entity.PaymentDetails{
+		if paymentDetailPaymentMethod.Valid {
+			paymentDetails := entity.PaymentDetails{
 				PaymentMethod: paymentDetailPaymentMethod.String,
 				Amount:        paymentDetailAmount.Int64,
 			}
 			payment.PaymentDetails = append(payment.PaymentDetails, paymentDetails)
 		}
-
-		passengers = append(passengers, passenger)
 	}
 
 	if passengers == nil {
@@ -0,0 +1,134 @@
+package gateway
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// Fake keeps charges in memory for local development and tests. Notifications are JSON bodies of
+// order_id, status and gross_amount signed with SHA256(order_id + status + gross_amount + secret),
+// Notify builds one so a charge can be settled without a real provider.
+type Fake struct {
+	Secret string
+
+	mu      sync.Mutex
+	charges map[string]*Notification
+}
+
+func NewFake(secret string) *Fake {
+	return &Fake{
+		Secret:  secret,
+		charges: make(map[string]*Notification),
+	}
+}
+
+type fakeNotification struct {
+	OrderId      string `json:"order_id"`
+	Status       string `json:"status"`
+	GrossAmount  string `json:"gross_amount"`
+	SignatureKey string `json:"signature_key"`
+}
+
+func (f *Fake) Name() string {
+	return "fake"
+}
+
+func (f *Fake) CreateCharge(ctx context.Context, charge *Charge) (result *ChargeResult, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, exists := f.charges[charge.OrderId]; exists {
+		return nil, fmt.Errorf("order %s already charged", charge.OrderId)
+	}
+
+	f.charges[charge.OrderId] = &Notification{
+		OrderId:       charge.OrderId,
+		TransactionId: "fake-" + charge.OrderId,
+		Amount:        charge.Amount,
+		Status:        StatusPending,
+	}
+
+	result = &ChargeResult{
+		OrderId:     charge.OrderId,
+		Token:       "fake-" + charge.OrderId,
+		RedirectURL: "fake://checkout/" + charge.OrderId,
+		Status:      StatusPending,
+	}
+	return
+}
+
+func (f *Fake) VerifyNotification(ctx context.Context, body []byte) (notification *Notification, err error) {
+	payload := fakeNotification{}
+	if err = json.Unmarshal(body, &payload); err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	expected := f.sign(payload.OrderId, payload.Status, payload.GrossAmount)
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(payload.SignatureKey)) != 1 {
+		return nil, ErrInvalidSignature
+	}
+
+	amount, err := strconv.ParseInt(payload.GrossAmount, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gross amount %q", payload.GrossAmount)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	notification = &Notification{
+		OrderId:       payload.OrderId,
+		TransactionId: "fake-" + payload.OrderId,
+		Amount:        amount,
+		Status:        payload.Status,
+	}
+
+	if charge, exists := f.charges[payload.OrderId]; exists {
+		charge.Status = payload.Status
+	}
+	return
+}
+
+func (f *Fake) Status(ctx context.Context, orderId string) (notification *Notification, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	charge, exists := f.charges[orderId]
+	if !exists {
+		return nil, ErrChargeNotFound
+	}
+
+	copied := *charge
+	return &copied, nil
+}
+
+// Notify returns a signed webhook body reporting status for the charge.
+func (f *Fake) Notify(orderId string, status string) (body []byte, err error) {
+	f.mu.Lock()
+	charge, exists := f.charges[orderId]
+	f.mu.Unlock()
+
+	if !exists {
+		return nil, ErrChargeNotFound
+	}
+
+	grossAmount := strconv.FormatInt(charge.Amount, 10)
+
+	return json.Marshal(fakeNotification{
+		OrderId:      orderId,
+		Status:       status,
+		GrossAmount:  grossAmount,
+		SignatureKey: f.sign(orderId, status, grossAmount),
+	})
+}
+
+func (f *Fake) sign(orderId, status, grossAmount string) string {
+	digest := sha256.Sum256([]byte(orderId + status + grossAmount + f.Secret))
+	return hex.EncodeToString(digest[:])
+}
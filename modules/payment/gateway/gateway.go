@@ -0,0 +1,53 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	StatusPending = "pending"
+	StatusSettled = "settled"
+	StatusFailed  = "failed"
+	StatusExpired = "expired"
+)
+
+var (
+	ErrInvalidSignature error = fmt.Errorf("invalid notification signature")
+	ErrChargeNotFound   error = fmt.Errorf("charge not found")
+)
+
+// Charge is a request to collect money from a customer, OrderId must be unique per charge.
+type Charge struct {
+	OrderId       string
+	Amount        int64
+	CustomerName  string
+	CustomerEmail string
+	ExpiresIn     time.Duration
+}
+
+// ChargeResult tells the customer where to complete the payment.
+type ChargeResult struct {
+	OrderId     string `json:"orderId"`
+	Token       string `json:"token"`
+	RedirectURL string `json:"redirectUrl"`
+	Status      string `json:"status"`
+}
+
+// Notification is the state of a charge reported by the provider, either through a webhook or a status query.
+type Notification struct {
+	OrderId       string
+	TransactionId string
+	Amount        int64
+	Status        string
+}
+
+// Gateway is a payment provider able to collect top-ups.
+type Gateway interface {
+	Name() string
+	CreateCharge(ctx context.Context, charge *Charge) (result *ChargeResult, err error)
+	// VerifyNotification authenticates a webhook body and returns what it reports, ErrInvalidSignature when it cannot be trusted.
+	VerifyNotification(ctx context.Context, body []byte) (notification *Notification, err error)
+	Status(ctx context.Context, orderId string) (notification *Notification, err error)
+}
@@ -0,0 +1,202 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	MidtransSandboxSnapURL    = "https://app.sandbox.midtrans.com"
+	MidtransSandboxAPIURL     = "https://api.sandbox.midtrans.com"
+	MidtransProductionSnapURL = "https://app.midtrans.com"
+	MidtransProductionAPIURL  = "https://api.midtrans.com"
+)
+
+// Midtrans collects payments through the Snap flow, the customer picks a virtual account, e-wallet or card on the
+// redirect page and the outcome arrives on the HTTP notification URL configured in the Midtrans dashboard.
+type Midtrans struct {
+	ServerKey  string
+	SnapURL    string
+	APIURL     string
+	HTTPClient *http.Client
+}
+
+// NewMidtrans talks to the sandbox unless the production URLs are given.
+func NewMidtrans(serverKey, snapURL, apiURL string, timeout time.Duration) Gateway {
+	if snapURL == "" {
+		snapURL = MidtransSandboxSnapURL
+	}
+	if apiURL == "" {
+		apiURL = MidtransSandboxAPIURL
+	}
+
+	return &Midtrans{
+		ServerKey:  serverKey,
+		SnapURL:    snapURL,
+		APIURL:     apiURL,
+		HTTPClient: &http.Client{Timeout: timeout},
+	}
+}
+
+type midtransNotification struct {
+	OrderId           string `json:"order_id"`
+	TransactionId     string `json:"transaction_id"`
+	StatusCode        string `json:"status_code"`
+	GrossAmount       string `json:"gross_amount"`
+	SignatureKey      string `json:"signature_key"`
+	TransactionStatus string `json:"transaction_status"`
+	FraudStatus       string `json:"fraud_status"`
+}
+
+func (m *Midtrans) Name() string {
+	return "midtrans"
+}
+
+func (m *Midtrans) CreateCharge(ctx context.Context, charge *Charge) (result *ChargeResult, err error) {
+	payload := map[string]any{
+		"transaction_details": map[string]any{
+			"order_id":     charge.OrderId,
+			"gross_amount": charge.Amount,
+		},
+		"customer_details": map[string]any{
+			"first_name": charge.CustomerName,
+			"email":      charge.CustomerEmail,
+		},
+	}
+
+	if charge.ExpiresIn > 0 {
+		payload["expiry"] = map[string]any{
+			"duration": int64(math.Ceil(charge.ExpiresIn.Minutes())),
+			"unit":     "minute",
+		}
+	}
+
+	response := struct {
+		Token         string   `json:"token"`
+		RedirectURL   string   `json:"redirect_url"`
+		ErrorMessages []string `json:"error_messages"`
+	}{}
+
+	if err = m.do(ctx, http.MethodPost, m.SnapURL+"/snap/v1/transactions", payload, http.StatusCreated, &response); err != nil {
+		return
+	}
+
+	result = &ChargeResult{
+		OrderId:     charge.OrderId,
+		Token:       response.Token,
+		RedirectURL: response.RedirectURL,
+		Status:      StatusPending,
+	}
+	return
+}
+
+func (m *Midtrans) VerifyNotification(ctx context.Context, body []byte) (notification *Notification, err error) {
+	payload := midtransNotification{}
+	if err = json.Unmarshal(body, &payload); err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	if !m.validSignature(&payload) {
+		return nil, ErrInvalidSignature
+	}
+
+	return payload.notification()
+}
+
+func (m *Midtrans) Status(ctx context.Context, orderId string) (notification *Notification, err error) {
+	payload := midtransNotification{}
+	if err = m.do(ctx, http.MethodGet, fmt.Sprintf("%s/v2/%s/status", m.APIURL, orderId), nil, http.StatusOK, &payload); err != nil {
+		return
+	}
+
+	if payload.StatusCode == "404" {
+		return nil, ErrChargeNotFound
+	}
+
+	return payload.notification()
+}
+
+// validSignature checks signature_key, SHA512(order_id + status_code + gross_amount + server key).
+func (m *Midtrans) validSignature(payload *midtransNotification) bool {
+	digest := sha512.Sum512([]byte(payload.OrderId + payload.StatusCode + payload.GrossAmount + m.ServerKey))
+	expected := hex.EncodeToString(digest[:])
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(payload.SignatureKey)) == 1
+}
+
+func (m *Midtrans) do(ctx context.Context, method, url string, payload any, expectedStatus int, response any) (err error) {
+	var body io.Reader
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(raw)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return
+	}
+
+	request.SetBasicAuth(m.ServerKey, "")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Content-Type", "application/json")
+
+	res, err := m.HTTPClient.Do(request)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+
+	raw, err := io.ReadAll(res.Body)
+	if err != nil {
+		return
+	}
+
+	if res.StatusCode != expectedStatus {
+		return fmt.Errorf("midtrans responded %d: %s", res.StatusCode, raw)
+	}
+
+	return json.Unmarshal(raw, response)
+}
+
+func (payload *midtransNotification) notification() (notification *Notification, err error) {
+	amount, err := strconv.ParseFloat(payload.GrossAmount, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gross amount %q", payload.GrossAmount)
+	}
+
+	notification = &Notification{
+		OrderId:       payload.OrderId,
+		TransactionId: payload.TransactionId,
+		Amount:        int64(math.Round(amount)),
+		Status:        StatusPending,
+	}
+
+	switch payload.TransactionStatus {
+	case "settlement":
+		notification.Status = StatusSettled
+	case "capture":
+		// card payments are captured first, only accepted ones are safe to credit
+		if payload.FraudStatus == "" || payload.FraudStatus == "accept" {
+			notification.Status = StatusSettled
+		}
+	case "deny", "cancel", "failure":
+		notification.Status = StatusFailed
+	case "expire":
+		notification.Status = StatusExpired
+	}
+
+	return
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/go-sql-driver/mysql"
 	"github.com/sirupsen/logrus"
 )
 
@@ -16,6 +19,7 @@ type Repository interface {
 
 	Insert(ctx context.Context, tx *sql.Tx, payment *entity.Payment) (id int64, err error)
 	UpdatePaidStatusByPassengerId(ctx context.Context, tx *sql.Tx, passengerId int64) (err error)
+	UpdateByPassengerId(ctx context.Context, tx *sql.Tx, passengerId int64, updateFields map[string]any) (err error)
 }
 
 type RepositoryImpl struct {
@@ -68,6 +72,45 @@ func (repo *RepositoryImpl) UpdatePaidStatusByPassengerId(ctx context.Context, t
 
 	return
 }
+func (repo *RepositoryImpl) UpdateByPassengerId(ctx context.Context, tx *sql.Tx, passengerId int64, updateFields map[string]any) (err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	var (
+		placeholders []string
+		values       []interface{}
+	)
+
+	for field, value := range updateFields {
+		placeholders = append(placeholders, field+" = ?")
+		values = append(values, value)
+	}
+
+	placeholdersStr := strings.Join(placeholders, ", ")
+
+	command := fmt.Sprintf("UPDATE %s SET %s WHERE passenger_id = ?", repo.TableName, placeholdersStr)
+	values = append(values, passengerId)
+
+	_, err = Exec(ctx, cmd, command, values...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return exception.ErrNotFound
+		}
+		if driverErr, ok := err.(*mysql.MySQLError); ok {
+			if driverErr.Number == 1062 {
+				repo.Logger.Error(err.Error())
+				return exception.ErrConflict
+			}
+		}
+		repo.Logger.Error(err.Error())
+		return exception.ErrInternalServer
+	}
+	return
+}
+
 func (repo *RepositoryImpl) Insert(ctx context.Context, tx *sql.Tx, payment *entity.Payment) (id int64, err error) {
 	var cmd SqlCommand = repo.DB
 
@@ -83,11 +126,14 @@ func (repo *RepositoryImpl) Insert(ctx context.Context, tx *sql.Tx, payment *ent
 		recipient_id = ?,
 		user_id = ?,
 		status = ?,
+		base_fare = ?,
+		distance_fare = ?,
 		total_amount = ?,
+		commission = ?,
 		created_at = ?
 	`, repo.TableName)
 
-	result, err := Exec(ctx, cmd, command, payment.ID, payment.PassengerId, payment.RecipientId, payment.UserId, payment.Status, payment.TotalAmount, payment.CreatedAt)
+	result, err := Exec(ctx, cmd, command, payment.ID, payment.PassengerId, payment.RecipientId, payment.UserId, payment.Status, payment.BaseFare, payment.DistanceFare, payment.TotalAmount, payment.Commission, payment.CreatedAt)
 	if err != nil {
 		repo.Logger.WithContext(ctx).Error(command, err.Error())
 		return
@@ -0,0 +1,173 @@
+package promotions
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/helpers/date"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DiscountPercentage = "percentage"
+	DiscountFixed      = "fixed"
+
+	RedemptionApplied = "applied"
+	RedemptionVoided  = "voided"
+)
+
+// Ineligible explains why a promo code cannot be used, the reason is safe to show to the passenger.
+type Ineligible struct {
+	Reason string
+}
+
+func (e *Ineligible) Error() string {
+	return e.Reason
+}
+
+type Discount struct {
+	PromotionId int64  `json:"promotionId"`
+	Code        string `json:"code"`
+	Amount      int64  `json:"amount"`
+}
+
+// Service applies promo codes to fares.
+type Service interface {
+	// Quote returns the discount a code gives the user on a fare without using it.
+	Quote(ctx context.Context, code string, userId int64, fareAmount int64) (discount *Discount, err error)
+	// Redeem checks the code again while holding a lock on the promotion and records its use by the payment.
+	Redeem(ctx context.Context, tx *sql.Tx, code string, userId int64, paymentId int64, fareAmount int64) (discount *Discount, err error)
+	// Release gives the use back when the booking of the payment does not happen.
+	Release(ctx context.Context, tx *sql.Tx, paymentId int64) (err error)
+}
+
+type ServiceImpl struct {
+	Repository           Repository
+	RedemptionRepository RedemptionRepository
+	Logger               *logrus.Logger
+}
+
+func NewServiceImpl(repo Repository, redemptionRepository RedemptionRepository, logger *logrus.Logger) Service {
+	return &ServiceImpl{
+		Repository:           repo,
+		RedemptionRepository: redemptionRepository,
+		Logger:               logger,
+	}
+}
+
+func (s *ServiceImpl) Quote(ctx context.Context, code string, userId int64, fareAmount int64) (discount *Discount, err error) {
+	promotion, err := s.Repository.FindByCode(ctx, nil, strings.ToUpper(code))
+	if err == exception.ErrNotFound {
+		return nil, &Ineligible{Reason: "promo code not found"}
+	}
+	if err != nil {
+		return
+	}
+
+	return s.apply(ctx, nil, promotion, userId, fareAmount)
+}
+
+func (s *ServiceImpl) Redeem(ctx context.Context, tx *sql.Tx, code string, userId int64, paymentId int64, fareAmount int64) (discount *Discount, err error) {
+	promotion, err := s.Repository.FindByCode(ctx, tx, strings.ToUpper(code))
+	if err == exception.ErrNotFound {
+		return nil, &Ineligible{Reason: "promo code not found"}
+	}
+	if err != nil {
+		return
+	}
+
+	if discount, err = s.apply(ctx, tx, promotion, userId, fareAmount); err != nil {
+		return
+	}
+
+	redemption := &entity.PromotionRedemption{
+		PromotionId: promotion.ID,
+		UserId:      userId,
+		PaymentId:   paymentId,
+		Discount:    discount.Amount,
+		Status:      RedemptionApplied,
+		CreatedAt:   *date.CurrentUTCTime(),
+	}
+
+	if _, err = s.RedemptionRepository.Insert(ctx, tx, redemption); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+func (s *ServiceImpl) Release(ctx context.Context, tx *sql.Tx, paymentId int64) (err error) {
+	return s.RedemptionRepository.VoidByPayment(ctx, tx, paymentId)
+}
+
+// apply checks the eligibility rules of the promotion for the user and computes the discount on the fare.
+func (s *ServiceImpl) apply(ctx context.Context, tx *sql.Tx, promotion *entity.Promotion, userId int64, fareAmount int64) (discount *Discount, err error) {
+	now := time.Now()
+
+	if !promotion.IsActive || now.Before(promotion.StartsAt) || now.After(promotion.EndsAt) {
+		return nil, &Ineligible{Reason: "promo code is not active"}
+	}
+
+	if fareAmount < promotion.MinimumFare {
+		return nil, &Ineligible{Reason: "fare is below the minimum of the promo"}
+	}
+
+	if promotion.FirstRideOnly {
+		rides, err := s.Repository.CountCompletedRides(ctx, tx, userId)
+		if err != nil {
+			return nil, err
+		}
+		if rides > 0 {
+			return nil, &Ineligible{Reason: "promo code is only for the first ride"}
+		}
+	}
+
+	if promotion.UsageLimit > 0 {
+		used, err := s.RedemptionRepository.Count(ctx, tx, promotion.ID, nil)
+		if err != nil {
+			return nil, err
+		}
+		if used >= promotion.UsageLimit {
+			return nil, &Ineligible{Reason: "promo code has run out"}
+		}
+	}
+
+	if promotion.UsageLimitPerUser > 0 {
+		used, err := s.RedemptionRepository.Count(ctx, tx, promotion.ID, &userId)
+		if err != nil {
+			return nil, err
+		}
+		if used >= promotion.UsageLimitPerUser {
+			return nil, &Ineligible{Reason: "promo code already used"}
+		}
+	}
+
+	return &Discount{
+		PromotionId: promotion.ID,
+		Code:        promotion.Code,
+		Amount:      DiscountFor(promotion, fareAmount),
+	}, nil
+}
+
+// DiscountFor is the discount of the promotion on a fare, capped by MaxDiscount when set and never more than the fare.
+func DiscountFor(promotion *entity.Promotion, fareAmount int64) (amount int64) {
+	amount = promotion.DiscountValue
+	if promotion.DiscountType == DiscountPercentage {
+		amount = int64(math.Round(float64(fareAmount) * float64(promotion.DiscountValue) / 100))
+	}
+
+	if promotion.MaxDiscount > 0 && amount > promotion.MaxDiscount {
+		amount = promotion.MaxDiscount
+	}
+
+	if amount > fareAmount {
+		amount = fareAmount
+	}
+
+	return
+}
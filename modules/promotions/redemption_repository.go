@@ -0,0 +1,102 @@
+package promotions
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/sirupsen/logrus"
+)
+
+type RedemptionRepository interface {
+	Insert(ctx context.Context, tx *sql.Tx, redemption *entity.PromotionRedemption) (id int64, err error)
+	// Count counts the applied redemptions of a promotion, of one user when userId is given.
+	Count(ctx context.Context, tx *sql.Tx, promotionId int64, userId *int64) (total int64, err error)
+	VoidByPayment(ctx context.Context, tx *sql.Tx, paymentId int64) (err error)
+}
+
+type RedemptionRepositoryImpl struct {
+	DB        *sql.DB
+	Logger    *logrus.Logger
+	TableName string
+}
+
+func NewRedemptionRepositoryImpl(db *sql.DB, logger *logrus.Logger) RedemptionRepository {
+	return &RedemptionRepositoryImpl{
+		DB:        db,
+		Logger:    logger,
+		TableName: "promotion_redemptions",
+	}
+}
+
+func (repo *RedemptionRepositoryImpl) Insert(ctx context.Context, tx *sql.Tx, redemption *entity.PromotionRedemption) (id int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`
+	INSERT INTO %s
+	SET
+		id = ?,
+		promotion_id = ?,
+		user_id = ?,
+		payment_id = ?,
+		discount = ?,
+		status = ?,
+		created_at = ?
+	`, repo.TableName)
+
+	result, err := Exec(ctx, cmd, command, redemption.ID, redemption.PromotionId, redemption.UserId, redemption.PaymentId, redemption.Discount, redemption.Status, redemption.CreatedAt)
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return
+	}
+
+	if id, err = result.LastInsertId(); err != nil {
+		return
+	}
+	return
+}
+
+func (repo *RedemptionRepositoryImpl) Count(ctx context.Context, tx *sql.Tx, promotionId int64, userId *int64) (total int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := fmt.Sprintf(`SELECT COUNT(r.id) FROM %s r WHERE r.promotion_id = ? AND r.status = ?`, repo.TableName)
+	args := []interface{}{promotionId, RedemptionApplied}
+
+	if userId != nil {
+		query += " AND r.user_id = ?"
+		args = append(args, *userId)
+	}
+
+	if err = cmd.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return
+	}
+
+	return
+}
+
+func (repo *RedemptionRepositoryImpl) VoidByPayment(ctx context.Context, tx *sql.Tx, paymentId int64) (err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`UPDATE %s SET status = ? WHERE payment_id = ? AND status = ?`, repo.TableName)
+
+	if _, err = Exec(ctx, cmd, command, RedemptionVoided, paymentId, RedemptionApplied); err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return
+	}
+
+	return
+}
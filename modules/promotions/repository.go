@@ -0,0 +1,321 @@
+package promotions
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
+)
+
+type Repository interface {
+	Insert(ctx context.Context, promotion *entity.Promotion) (id int64, err error)
+	Update(ctx context.Context, promotion *entity.Promotion) (err error)
+	Delete(ctx context.Context, id int64) (err error)
+	FindOne(ctx context.Context, id int64) (promotion *entity.Promotion, err error)
+	// FindByCode locks the promotion row when tx is given, so usage limits are checked one redemption at a time.
+	FindByCode(ctx context.Context, tx *sql.Tx, code string) (promotion *entity.Promotion, err error)
+	FindMany(ctx context.Context, params *model.GetPromotionsParams) (promotions []entity.Promotion, err error)
+	Count(ctx context.Context) (total int64, err error)
+	CountCompletedRides(ctx context.Context, tx *sql.Tx, userId int64) (total int64, err error)
+}
+
+type RepositoryImpl struct {
+	DB        *sql.DB
+	Logger    *logrus.Logger
+	TableName string
+}
+
+func NewRepositoryImpl(db *sql.DB, logger *logrus.Logger) Repository {
+	return &RepositoryImpl{
+		DB:        db,
+		Logger:    logger,
+		TableName: "promotions",
+	}
+}
+
+func (repo *RepositoryImpl) Insert(ctx context.Context, promotion *entity.Promotion) (id int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	command := fmt.Sprintf(`
+	INSERT INTO %s
+	SET
+		id = ?,
+		code = ?,
+		name = ?,
+		discount_type = ?,
+		discount_value = ?,
+		max_discount = ?,
+		minimum_fare = ?,
+		first_ride_only = ?,
+		usage_limit = ?,
+		usage_limit_per_user = ?,
+		starts_at = ?,
+		ends_at = ?,
+		is_active = ?,
+		created_at = ?,
+		updated_at = ?
+	`, repo.TableName)
+
+	result, err := Exec(ctx, cmd, command, promotion.ID, promotion.Code, promotion.Name, promotion.DiscountType, promotion.DiscountValue, promotion.MaxDiscount, promotion.MinimumFare, promotion.FirstRideOnly, promotion.UsageLimit, promotion.UsageLimitPerUser, promotion.StartsAt, promotion.EndsAt, promotion.IsActive, promotion.CreatedAt, promotion.UpdatedAt)
+	if err != nil {
+		if driverErr, ok := err.(*mysql.MySQLError); ok && driverErr.Number == 1062 {
+			return 0, exception.ErrConflict
+		}
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return
+	}
+
+	if id, err = result.LastInsertId(); err != nil {
+		return
+	}
+	return
+}
+
+func (repo *RepositoryImpl) Update(ctx context.Context, promotion *entity.Promotion) (err error) {
+	var cmd SqlCommand = repo.DB
+
+	command := fmt.Sprintf(`
+	UPDATE
+		%s
+	SET
+		code = ?,
+		name = ?,
+		discount_type = ?,
+		discount_value = ?,
+		max_discount = ?,
+		minimum_fare = ?,
+		first_ride_only = ?,
+		usage_limit = ?,
+		usage_limit_per_user = ?,
+		starts_at = ?,
+		ends_at = ?,
+		is_active = ?,
+		updated_at = ?
+	WHERE
+		id = ? AND deleted_at IS NULL
+	`, repo.TableName)
+
+	_, err = Exec(ctx, cmd, command, promotion.Code, promotion.Name, promotion.DiscountType, promotion.DiscountValue, promotion.MaxDiscount, promotion.MinimumFare, promotion.FirstRideOnly, promotion.UsageLimit, promotion.UsageLimitPerUser, promotion.StartsAt, promotion.EndsAt, promotion.IsActive, promotion.UpdatedAt, promotion.ID)
+	if err != nil {
+		if driverErr, ok := err.(*mysql.MySQLError); ok && driverErr.Number == 1062 {
+			return exception.ErrConflict
+		}
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	return
+}
+
+// Delete only hides the promotion, its redemptions keep pointing at it.
+func (repo *RepositoryImpl) Delete(ctx context.Context, id int64) (err error) {
+	var cmd SqlCommand = repo.DB
+
+	command := fmt.Sprintf(`UPDATE %s SET is_active = FALSE, deleted_at = UTC_TIMESTAMP() WHERE id = ? AND deleted_at IS NULL`, repo.TableName)
+
+	result, err := Exec(ctx, cmd, command, id)
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	if affected < 1 {
+		return exception.ErrNotFound
+	}
+
+	return
+}
+
+func (repo *RepositoryImpl) FindOne(ctx context.Context, id int64) (promotion *entity.Promotion, err error) {
+	var cmd SqlCommand = repo.DB
+
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM
+		%s p
+	WHERE
+		p.id = ? AND p.deleted_at IS NULL
+	`, promotionColumns, repo.TableName)
+
+	promotions, err := repo.Query(ctx, cmd, query, id)
+	if err != nil {
+		return
+	}
+
+	promotion = &promotions[len(promotions)-1]
+
+	return
+}
+
+func (repo *RepositoryImpl) FindByCode(ctx context.Context, tx *sql.Tx, code string) (promotion *entity.Promotion, err error) {
+	var cmd SqlCommand = repo.DB
+
+	lock := ""
+	if tx != nil {
+		cmd = tx
+		lock = "FOR UPDATE"
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM
+		%s p
+	WHERE
+		p.code = ? AND p.deleted_at IS NULL
+	%s
+	`, promotionColumns, repo.TableName, lock)
+
+	promotions, err := repo.Query(ctx, cmd, query, code)
+	if err != nil {
+		return
+	}
+
+	promotion = &promotions[len(promotions)-1]
+
+	return
+}
+
+func (repo *RepositoryImpl) FindMany(ctx context.Context, params *model.GetPromotionsParams) (promotions []entity.Promotion, err error) {
+	var cmd SqlCommand = repo.DB
+
+	var offset = (params.Page - 1) * params.Size
+
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM
+		%s p
+	WHERE
+		p.deleted_at IS NULL
+	ORDER BY
+		p.id DESC
+	LIMIT %d OFFSET %d
+	`, promotionColumns, repo.TableName, params.Size, offset)
+
+	return repo.Query(ctx, cmd, query)
+}
+
+func (repo *RepositoryImpl) Count(ctx context.Context) (total int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	query := fmt.Sprintf(`SELECT COUNT(p.id) FROM %s p WHERE p.deleted_at IS NULL`, repo.TableName)
+
+	if err = cmd.QueryRowContext(ctx, query).Scan(&total); err != nil {
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return
+	}
+
+	return
+}
+
+// CountCompletedRides counts the bookings the user has been dropped off from.
+func (repo *RepositoryImpl) CountCompletedRides(ctx context.Context, tx *sql.Tx, userId int64) (total int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `SELECT COUNT(p.id) FROM passengers p WHERE p.user_id = ? AND p.status = 5`
+
+	if err = cmd.QueryRowContext(ctx, query, userId).Scan(&total); err != nil {
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return
+	}
+
+	return
+}
+
+const promotionColumns = `
+		p.id,
+		p.code,
+		p.name,
+		p.discount_type,
+		p.discount_value,
+		p.max_discount,
+		p.minimum_fare,
+		p.first_ride_only,
+		p.usage_limit,
+		p.usage_limit_per_user,
+		p.starts_at,
+		p.ends_at,
+		p.is_active,
+		p.created_at,
+		p.updated_at`
+
+// ==================================================================================================================== //
+type SqlCommand interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// ==================================================================================================================== //
+
+func Exec(ctx context.Context, cmd SqlCommand, command string, args ...interface{}) (result sql.Result, err error) {
+	var stmt *sql.Stmt
+	if stmt, err = cmd.PrepareContext(ctx, command); err != nil {
+		return
+	}
+
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			return
+		}
+	}()
+
+	if result, err = stmt.ExecContext(ctx, args...); err != nil {
+		return
+	}
+
+	return
+}
+
+func (repo *RepositoryImpl) Query(ctx context.Context, cmd SqlCommand, query string, args ...interface{}) (promotions []entity.Promotion, err error) {
+
+	var rows *sql.Rows
+	if rows, err = cmd.QueryContext(ctx, query, args...); err != nil {
+		repo.Logger.Error(err.Error())
+		return
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+	}()
+
+	for rows.Next() {
+		var promotion entity.Promotion
+
+		err = rows.Scan(&promotion.ID, &promotion.Code, &promotion.Name, &promotion.DiscountType, &promotion.DiscountValue, &promotion.MaxDiscount, &promotion.MinimumFare, &promotion.FirstRideOnly, &promotion.UsageLimit, &promotion.UsageLimitPerUser, &promotion.StartsAt, &promotion.EndsAt, &promotion.IsActive, &promotion.CreatedAt, &promotion.UpdatedAt)
+		if err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+
+		promotions = append(promotions, promotion)
+	}
+
+	if promotions == nil {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
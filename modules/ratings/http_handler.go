@@ -0,0 +1,84 @@
+package ratings
+
+import (
+	"strconv"
+
+	"github.com/Difaal21/nebeng-dong/helpers/validation"
+	"github.com/Difaal21/nebeng-dong/middleware"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/responses"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+var httpResponse = responses.HttpResponseStatusCodesImpl{}
+
+type HTTPHandler struct {
+	Usecase Usecase
+	Session *middleware.Session
+}
+
+func NewHTTPHandler(router *gin.Engine, session *middleware.Session, usecase Usecase) {
+
+	handler := &HTTPHandler{
+		Usecase: usecase,
+		Session: session,
+	}
+
+	router.POST("/nebengdong-service/v1/share-ride/:id/passenger/:passengerId/rating", session.Verify, handler.Rate)
+	router.GET("/nebengdong-service/v1/users/:id/ratings", session.Verify, handler.GetRatings)
+}
+
+func (handler *HTTPHandler) Rate(c *gin.Context) {
+	context := c.Request.Context()
+
+	shareRideId, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	passengerId, _ := strconv.ParseInt(c.Param("passengerId"), 10, 64)
+
+	payload := model.CreateRating{
+		ShareRideId: shareRideId,
+		PassengerId: passengerId,
+	}
+
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, payload)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.Rate(context, &payload)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) GetRatings(c *gin.Context) {
+	context := c.Request.Context()
+
+	userId, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	queryString := c.Request.URL.Query()
+
+	page, _ := strconv.Atoi(queryString.Get("page"))
+	size, _ := strconv.Atoi(queryString.Get("size"))
+
+	params := model.GetRatingsParams{
+		Page: int64(page),
+		Size: int64(size),
+	}
+
+	if err := c.ShouldBind(&params); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, params)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.GetRatings(context, userId, &params)
+	responses.REST(c, resp)
+}
@@ -0,0 +1,223 @@
+package ratings
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
+)
+
+// tags are kept in a single column, joined by tagSeparator
+const tagSeparator = "|"
+
+type Repository interface {
+	BeginTx(ctx context.Context) (tx *sql.Tx, err error)
+	RollbackTx(ctx context.Context, tx *sql.Tx) (err error)
+	CommitTx(ctx context.Context, tx *sql.Tx) (err error)
+
+	// Insert returns exception.ErrConflict when the rater already rated the passenger trip.
+	Insert(ctx context.Context, tx *sql.Tx, rating *entity.Rating) (id int64, err error)
+	FindManyByRatee(ctx context.Context, rateeId int64, params *model.GetRatingsParams) (ratings []entity.Rating, err error)
+	CountByRatee(ctx context.Context, rateeId int64) (total int64, err error)
+	FindDriverId(ctx context.Context, shareRideId int64) (driverId int64, err error)
+}
+
+type RepositoryImpl struct {
+	DB        *sql.DB
+	Logger    *logrus.Logger
+	TableName string
+}
+
+func NewRepositoryImpl(db *sql.DB, logger *logrus.Logger) Repository {
+	return &RepositoryImpl{
+		DB:        db,
+		Logger:    logger,
+		TableName: "ratings",
+	}
+}
+
+func (repo *RepositoryImpl) BeginTx(ctx context.Context) (tx *sql.Tx, err error) {
+	return repo.DB.BeginTx(ctx, nil)
+}
+
+func (repo *RepositoryImpl) RollbackTx(ctx context.Context, tx *sql.Tx) (err error) {
+	return tx.Rollback()
+}
+
+func (repo *RepositoryImpl) CommitTx(ctx context.Context, tx *sql.Tx) (err error) {
+	return tx.Commit()
+}
+
+func (repo *RepositoryImpl) Insert(ctx context.Context, tx *sql.Tx, rating *entity.Rating) (id int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`
+	INSERT INTO %s
+	SET
+		id = ?,
+		share_ride_id = ?,
+		passenger_id = ?,
+		rater_id = ?,
+		ratee_id = ?,
+		rater_role = ?,
+		stars = ?,
+		tags = ?,
+		comment = ?,
+		created_at = ?
+	`, repo.TableName)
+
+	result, err := Exec(ctx, cmd, command, rating.ID, rating.ShareRideId, rating.PassengerId, rating.RaterId, rating.RateeId, rating.RaterRole, rating.Stars, strings.Join(rating.Tags, tagSeparator), rating.Comment, rating.CreatedAt)
+	if err != nil {
+		if driverErr, ok := err.(*mysql.MySQLError); ok && driverErr.Number == 1062 {
+			return 0, exception.ErrConflict
+		}
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return
+	}
+
+	if id, err = result.LastInsertId(); err != nil {
+		return
+	}
+	return
+}
+
+func (repo *RepositoryImpl) FindManyByRatee(ctx context.Context, rateeId int64, params *model.GetRatingsParams) (ratings []entity.Rating, err error) {
+	var cmd SqlCommand = repo.DB
+
+	var offset = (params.Page - 1) * params.Size
+
+	query := fmt.Sprintf(`
+	SELECT
+		r.id,
+		r.share_ride_id,
+		r.passenger_id,
+		r.rater_id,
+		r.ratee_id,
+		r.rater_role,
+		r.stars,
+		r.tags,
+		r.comment,
+		r.created_at
+	FROM
+		%s r
+	WHERE
+		r.ratee_id = ?
+	ORDER BY
+		r.id DESC
+	LIMIT %d OFFSET %d
+	`, repo.TableName, params.Size, offset)
+
+	return repo.Query(ctx, cmd, query, rateeId)
+}
+
+func (repo *RepositoryImpl) CountByRatee(ctx context.Context, rateeId int64) (total int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	query := fmt.Sprintf(`SELECT COUNT(r.id) FROM %s r WHERE r.ratee_id = ?`, repo.TableName)
+
+	if err = cmd.QueryRowContext(ctx, query, rateeId).Scan(&total); err != nil {
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return
+	}
+
+	return
+}
+
+// FindDriverId returns the driver of the share ride.
+func (repo *RepositoryImpl) FindDriverId(ctx context.Context, shareRideId int64) (driverId int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	query := `SELECT sr.driver_id FROM share_ride sr WHERE sr.id = ?`
+
+	if err = cmd.QueryRowContext(ctx, query, shareRideId).Scan(&driverId); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, exception.ErrNotFound
+		}
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return
+	}
+
+	return
+}
+
+// ==================================================================================================================== //
+type SqlCommand interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// ==================================================================================================================== //
+
+func Exec(ctx context.Context, cmd SqlCommand, command string, args ...interface{}) (result sql.Result, err error) {
+	var stmt *sql.Stmt
+	if stmt, err = cmd.PrepareContext(ctx, command); err != nil {
+		return
+	}
+
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			return
+		}
+	}()
+
+	if result, err = stmt.ExecContext(ctx, args...); err != nil {
+		return
+	}
+
+	return
+}
+
+func (repo *RepositoryImpl) Query(ctx context.Context, cmd SqlCommand, query string, args ...interface{}) (ratings []entity.Rating, err error) {
+
+	var rows *sql.Rows
+	if rows, err = cmd.QueryContext(ctx, query, args...); err != nil {
+		repo.Logger.Error(err.Error())
+		return
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+	}()
+
+	for rows.Next() {
+		var (
+			rating entity.Rating
+			tags   string
+		)
+
+		err = rows.Scan(&rating.ID, &rating.ShareRideId, &rating.PassengerId, &rating.RaterId, &rating.RateeId, &rating.RaterRole, &rating.Stars, &tags, &rating.Comment, &rating.CreatedAt)
+		if err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+
+		rating.Tags = []string{}
+		if tags != "" {
+			rating.Tags = strings.Split(tags, tagSeparator)
+		}
+
+		ratings = append(ratings, rating)
+	}
+
+	if ratings == nil {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
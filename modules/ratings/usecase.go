@@ -0,0 +1,149 @@
+package ratings
+
+import (
+	"context"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/helpers/date"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/modules/passengers"
+	"github.com/Difaal21/nebeng-dong/modules/users"
+	"github.com/Difaal21/nebeng-dong/responses"
+	"github.com/sirupsen/logrus"
+)
+
+// The passenger rates the driver and the driver rates the passenger, each once per passenger trip.
+const (
+	RolePassenger = "passenger"
+	RoleDriver    = "driver"
+)
+
+// passengerStatusDone is the passenger status once dropped off at the destination.
+const passengerStatusDone = 5
+
+type Usecase interface {
+	Rate(ctx context.Context, payload *model.CreateRating) responses.Responses
+	GetRatings(ctx context.Context, userId int64, params *model.GetRatingsParams) responses.Responses
+}
+
+type UsecaseImpl struct {
+	Repository          Repository
+	PassengerRepository passengers.Repository
+	UserRepository      users.Repository
+	Logger              *logrus.Logger
+}
+
+func NewUsecaseImpl(repo Repository, passengerRepository passengers.Repository, userRepository users.Repository, logger *logrus.Logger) Usecase {
+	return &UsecaseImpl{
+		Repository:          repo,
+		PassengerRepository: passengerRepository,
+		UserRepository:      userRepository,
+		Logger:              logger,
+	}
+}
+
+func (u *UsecaseImpl) Rate(ctx context.Context, payload *model.CreateRating) responses.Responses {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	passenger, err := u.PassengerRepository.FindOnePassengerOnShareRide(ctx, payload.ShareRideId, payload.PassengerId)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithContext(ctx).WithField("payload", payload).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if passenger == nil {
+		return httpResponse.NotFound("").NewResponses(nil, "passenger not found")
+	}
+
+	driverId, err := u.Repository.FindDriverId(ctx, passenger.ShareRideId)
+	if err != nil {
+		u.Logger.WithContext(ctx).WithField("payload", payload).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	rating := &entity.Rating{
+		ShareRideId: payload.ShareRideId,
+		PassengerId: passenger.ID,
+		RaterId:     requester.ID,
+		Stars:       payload.Stars,
+		Tags:        payload.Tags,
+		Comment:     payload.Comment,
+		CreatedAt:   *date.CurrentUTCTime(),
+	}
+
+	switch requester.ID {
+	case passenger.UserId:
+		rating.RaterRole, rating.RateeId = RolePassenger, driverId
+	case driverId:
+		rating.RaterRole, rating.RateeId = RoleDriver, passenger.UserId
+	default:
+		return httpResponse.Forbidden("").NewResponses(nil, "only the passenger and the driver of the trip can rate it")
+	}
+
+	if passenger.Status != passengerStatusDone {
+		return httpResponse.BadRequest("").NewResponses(nil, "the trip can be rated once the passenger arrived")
+	}
+
+	if rating.Tags == nil {
+		rating.Tags = []string{}
+	}
+
+	fields := logrus.Fields{"requester": requester, "rating": rating}
+
+	tx, err := u.Repository.BeginTx(ctx)
+	if err != nil {
+		u.Logger.WithContext(ctx).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if rating.ID, err = u.Repository.Insert(ctx, tx, rating); err != nil {
+		u.Repository.RollbackTx(ctx, tx)
+		if err == exception.ErrConflict {
+			return httpResponse.Conflict("").NewResponses(nil, "trip already rated")
+		}
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if err = u.UserRepository.RefreshRating(ctx, tx, rating.RateeId); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if err = u.Repository.CommitTx(ctx, tx); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Created("").NewResponses(rating, "rating submitted")
+}
+
+// GetRatings lists the ratings a user received, newest first.
+func (u *UsecaseImpl) GetRatings(ctx context.Context, userId int64, params *model.GetRatingsParams) responses.Responses {
+
+	totalData, err := u.Repository.CountByRatee(ctx, userId)
+	if err != nil {
+		u.Logger.WithFields(logrus.Fields{"userId": userId, "params": params}).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	ratings, err := u.Repository.FindManyByRatee(ctx, userId, params)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithFields(logrus.Fields{"userId": userId, "params": params}).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if ratings == nil {
+		return httpResponse.NotFound("").NewResponses(nil, "no rating")
+	}
+
+	return httpResponse.Ok("").NewResponsesOffsetPagination(ratings, int64(len(ratings)), totalData, "get ratings success")
+}
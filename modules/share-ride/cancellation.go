@@ -0,0 +1,193 @@
+package shareride
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/helpers/date"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/responses"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	passengerCancelled = -1
+	cancelledByUser    = "passenger"
+
+	paymentVoid             = "void"
+	paymentPartiallyCharged = "partially_charged"
+
+	defaultCancellationFreeWindow = 5 * time.Minute
+	defaultCancellationFee        = 5000
+)
+
+// CancellationPolicy decides how much a passenger pays when cancelling a booking.
+type CancellationPolicy struct {
+	FreeWindow time.Duration
+	Fee        int64
+}
+
+func cancellationPolicy() CancellationPolicy {
+	policy := CancellationPolicy{
+		FreeWindow: defaultCancellationFreeWindow,
+		Fee:        defaultCancellationFee,
+	}
+
+	if minutes, err := strconv.ParseInt(os.Getenv("CANCELLATION_FREE_MINUTES"), 10, 64); err == nil && minutes >= 0 {
+		policy.FreeWindow = time.Duration(minutes) * time.Minute
+	}
+
+	if fee, err := strconv.ParseInt(os.Getenv("CANCELLATION_FEE"), 10, 64); err == nil && fee >= 0 {
+		policy.Fee = fee
+	}
+
+	return policy
+}
+
+// FeeFor is free while the driver has not arrived and the booking is younger than FreeWindow,
+// otherwise the flat fee capped at the fare of the booking.
+func (p CancellationPolicy) FeeFor(passenger *entity.Passengers, totalAmount int64, now time.Time) int64 {
+	if passenger.Status < 3 && now.Sub(passenger.CreatedAt) <= p.FreeWindow {
+		return 0
+	}
+
+	if p.Fee > totalAmount {
+		return totalAmount
+	}
+	return p.Fee
+}
+
+func (u *UsecaseImpl) CancelBooking(ctx context.Context, payload *model.CancelBooking) responses.Responses {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	passenger, err := u.PassengerRepository.FindOnePassengerOnShareRide(ctx, payload.ShareRideID, payload.ID)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithField("payload", payload).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if passenger == nil {
+		return httpResponse.NotFound("").NewResponses(nil, "passenger not found")
+	}
+
+	if passenger.UserId != requester.ID {
+		return httpResponse.Forbidden("").NewResponses(nil, "not eligible passenger to cancel")
+	}
+
+	tx, err := u.Repository.BeginTx(ctx)
+	if err != nil {
+		u.Logger.WithContext(ctx).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	// the driver may move the passenger on meanwhile, the locked status is the one the cancellation is decided on
+	if passenger.Status, err = u.PassengerRepository.LockStatus(ctx, tx, passenger.ID); err != nil {
+		u.Logger.WithContext(ctx).WithField("payload", payload).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if passenger.Status < 1 || passenger.Status > 3 {
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.BadRequest(invalidRule).NewResponses(nil, "booking can only be cancelled before the trip starts")
+	}
+
+	offer, err := u.OfferRepository.FindLatestByPassenger(ctx, passenger.ID)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithContext(ctx).WithField("payload", payload).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	var totalAmount int64
+	if len(passenger.Payment) > 0 {
+		totalAmount = passenger.Payment[0].TotalAmount
+	}
+
+	now := date.CurrentUTCTime()
+	cancellation := Cancellation{
+		PassengerID:   passenger.ID,
+		PaymentStatus: paymentVoid,
+	}
+
+	// no driver took the booking while its offer is pending, bookings made before offers existed have no offer row
+	if offer == nil || offer.Status == offerAccepted {
+		cancellation.CancellationFee = cancellationPolicy().FeeFor(passenger, totalAmount, *now)
+	}
+
+	if cancellation.CancellationFee > 0 {
+		cancellation.PaymentStatus = paymentPartiallyCharged
+	}
+
+	fields := logrus.Fields{"payload": payload, "passenger": passenger, "cancellation": cancellation}
+
+	updatedFieldOnPassenger := map[string]any{
+		"status":              passengerCancelled,
+		"cancelled_by":        cancelledByUser,
+		"cancellation_reason": payload.Reason,
+		"cancelled_at":        now,
+	}
+
+	if err := u.PassengerRepository.UpdateOne(ctx, tx, passenger.ID, updatedFieldOnPassenger); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	updatedFieldOnPayment := map[string]any{
+		"status":           cancellation.PaymentStatus,
+		"cancellation_fee": cancellation.CancellationFee,
+	}
+
+	if err := u.PaymentRepository.UpdateByPassengerId(ctx, tx, passenger.ID, updatedFieldOnPayment); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	var driverId int64
+	if len(passenger.Payment) > 0 {
+		driverId = passenger.Payment[0].RecipientId
+	}
+
+	if err := u.releasePayment(ctx, tx, passenger, driverId, cancellation.CancellationFee); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if err := u.Repository.RefreshIsFull(ctx, tx, passenger.ShareRideId); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if offer != nil && (offer.Status == offerPending || offer.Status == offerAccepted) {
+		if err := u.OfferRepository.UpdateStatus(ctx, tx, offer.ID, offer.Status, offerCanceled); err != nil {
+			if err == exception.ErrConflict {
+				u.Repository.RollbackTx(ctx, tx)
+				return httpResponse.Conflict("").NewResponses(nil, "booking changed while cancelling, please retry")
+			}
+			u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+			u.Repository.RollbackTx(ctx, tx)
+			return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+		}
+	}
+
+	if err := u.Repository.CommitTx(ctx, tx); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Ok("").NewResponses(cancellation, "booking cancelled")
+}
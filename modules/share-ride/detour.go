@@ -0,0 +1,270 @@
+package shareride
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/fare"
+	"github.com/Difaal21/nebeng-dong/helpers/geo"
+)
+
+const (
+	defaultMaxDetourPercentage = 40.0
+	defaultMaxDetour           = 10 * time.Minute
+)
+
+// Detour is what serving one more passenger adds to the route the driver is already committed to.
+type Detour struct {
+	Distance   float64 `json:"distance"`   // km
+	Duration   int64   `json:"duration"`   // seconds
+	Percentage float64 `json:"percentage"` // of the existing route
+}
+
+type DetourLimit struct {
+	Percentage float64
+	Duration   time.Duration
+}
+
+func detourLimit() DetourLimit {
+	limit := DetourLimit{
+		Percentage: defaultMaxDetourPercentage,
+		Duration:   defaultMaxDetour,
+	}
+
+	if percentage, err := strconv.ParseFloat(os.Getenv("MAX_DETOUR_PERCENTAGE"), 64); err == nil && percentage >= 0 {
+		limit.Percentage = percentage
+	}
+
+	if minutes, err := strconv.ParseFloat(os.Getenv("MAX_DETOUR_MINUTES"), 64); err == nil && minutes >= 0 {
+		limit.Duration = time.Duration(minutes * float64(time.Minute))
+	}
+
+	return limit
+}
+
+func (l DetourLimit) Allows(detour *Detour) bool {
+	return detour.Percentage <= l.Percentage && time.Duration(detour.Duration)*time.Second <= l.Duration
+}
+
+type routeStop struct {
+	coordinate  entity.Coordinate
+	passengerId int64
+	pickup      bool
+}
+
+// detourScorer measures routes with the route estimator, remembering legs it has already estimated.
+type detourScorer struct {
+	estimator fare.RouteEstimator
+	legs      map[[2]entity.Coordinate]*fare.Route
+}
+
+func newDetourScorer(estimator fare.RouteEstimator) *detourScorer {
+	return &detourScorer{
+		estimator: estimator,
+		legs:      make(map[[2]entity.Coordinate]*fare.Route),
+	}
+}
+
+func (d *detourScorer) leg(ctx context.Context, origin, destination entity.Coordinate) (route *fare.Route, err error) {
+	key := [2]entity.Coordinate{origin, destination}
+	if route, ok := d.legs[key]; ok {
+		return route, nil
+	}
+
+	if route, err = d.estimator.Estimate(ctx, origin, destination); err != nil {
+		return
+	}
+
+	d.legs[key] = route
+	return
+}
+
+func (d *detourScorer) route(ctx context.Context, origin entity.Coordinate, stops []routeStop) (route *fare.Route, err error) {
+	route = &fare.Route{}
+	current := origin
+
+	for _, stop := range stops {
+		leg, err := d.leg(ctx, current, stop.coordinate)
+		if err != nil {
+			return nil, err
+		}
+
+		route.Distance += leg.Distance
+		route.Duration += leg.Duration
+		current = stop.coordinate
+	}
+
+	return
+}
+
+// Score inserts the new pickup and destination into the existing route at the cheapest position
+// (pickup always before destination) and returns how much longer the route gets.
+func (d *detourScorer) Score(ctx context.Context, driver entity.Coordinate, passengers []entity.Passengers, pickup, destination entity.Coordinate) (detour *Detour, err error) {
+	detour = &Detour{}
+
+	stops := plannedStops(driver, passengers)
+	if len(stops) < 1 {
+		return
+	}
+
+	base, err := d.route(ctx, driver, stops)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *fare.Route
+
+	for i := 0; i <= len(stops); i++ {
+		for j := i; j <= len(stops); j++ {
+			candidate := make([]routeStop, 0, len(stops)+2)
+			candidate = append(candidate, stops[:i]...)
+			candidate = append(candidate, routeStop{coordinate: pickup, pickup: true})
+			candidate = append(candidate, stops[i:j]...)
+			candidate = append(candidate, routeStop{coordinate: destination})
+			candidate = append(candidate, stops[j:]...)
+
+			route, err := d.route(ctx, driver, candidate)
+			if err != nil {
+				return nil, err
+			}
+
+			if best == nil || route.Distance < best.Distance {
+				best = route
+			}
+		}
+	}
+
+	detour.Distance = math.Max(best.Distance-base.Distance, 0)
+	detour.Duration = int64(math.Max((best.Duration - base.Duration).Seconds(), 0))
+	if base.Distance > 0 {
+		detour.Percentage = detour.Distance / base.Distance * 100
+	}
+
+	detour.Distance = math.Round(detour.Distance*100) / 100
+	detour.Percentage = math.Round(detour.Percentage*100) / 100
+
+	return
+}
+
+// plannedStops orders the stops of the passengers already on the share ride greedily from the driver's position,
+// picking a passenger up before dropping them off. Passengers on the way (4) only have their drop-off left, as do
+// bookings made before pickup coordinates were stored.
+func plannedStops(driver entity.Coordinate, passengers []entity.Passengers) (stops []routeStop) {
+	var pending []routeStop
+	pickedUp := make(map[int64]bool)
+
+	for _, passenger := range passengers {
+		hasPickup := passenger.Status < 4 && passenger.PickupCoordinate != (entity.Coordinate{})
+		if hasPickup {
+			pending = append(pending, routeStop{coordinate: passenger.PickupCoordinate, passengerId: passenger.ID, pickup: true})
+		} else {
+			pickedUp[passenger.ID] = true
+		}
+		pending = append(pending, routeStop{coordinate: passenger.DestinationCoordinate, passengerId: passenger.ID})
+	}
+
+	current := driver
+	for len(pending) > 0 {
+		next := -1
+		for i, stop := range pending {
+			if !stop.pickup && !pickedUp[stop.passengerId] {
+				continue
+			}
+			if next < 0 || geo.Distance(current, stop.coordinate) < geo.Distance(current, pending[next].coordinate) {
+				next = i
+			}
+		}
+
+		stop := pending[next]
+		if stop.pickup {
+			pickedUp[stop.passengerId] = true
+		}
+
+		stops = append(stops, stop)
+		current = stop.coordinate
+		pending = append(pending[:next], pending[next+1:]...)
+	}
+
+	return
+}
+
+// reserveRouteCompatible picks a candidate like pickRouteCompatible and reserves a seat on it within tx.
+// A candidate filled by a concurrent booking is dropped and the next one is tried.
+func (u *UsecaseImpl) reserveRouteCompatible(ctx context.Context, tx *sql.Tx, candidates []entity.ShareRide, pickup, destination entity.Coordinate) (shareRide *entity.ShareRide, detour *Detour, err error) {
+	for len(candidates) > 0 {
+		if shareRide, detour, err = u.pickRouteCompatible(ctx, candidates, pickup, destination); err != nil {
+			return nil, nil, err
+		}
+
+		err = u.Repository.ReserveSeat(ctx, tx, shareRide.ID)
+		if err == nil {
+			return shareRide, detour, nil
+		}
+
+		if err != exception.ErrConflict && err != exception.ErrNotFound {
+			return nil, nil, err
+		}
+
+		remaining := make([]entity.ShareRide, 0, len(candidates)-1)
+		for _, candidate := range candidates {
+			if candidate.ID != shareRide.ID {
+				remaining = append(remaining, candidate)
+			}
+		}
+		candidates = remaining
+	}
+
+	return nil, nil, exception.ErrNotFound
+}
+
+// pickRouteCompatible returns the nearest candidate whose existing route can absorb the new passenger within the detour limit.
+// Empty share rides need no detour.
+func (u *UsecaseImpl) pickRouteCompatible(ctx context.Context, candidates []entity.ShareRide, pickup, destination entity.Coordinate) (shareRide *entity.ShareRide, detour *Detour, err error) {
+	if len(candidates) < 1 {
+		return nil, nil, exception.ErrNotFound
+	}
+
+	shareRideIds := make([]int64, 0, len(candidates))
+	for _, candidate := range candidates {
+		shareRideIds = append(shareRideIds, candidate.ID)
+	}
+
+	passengers, err := u.Repository.FindRoutePassengers(ctx, shareRideIds)
+	if err != nil && err != exception.ErrNotFound {
+		return nil, nil, err
+	}
+
+	routePassengers := make(map[int64][]entity.Passengers)
+	for _, passenger := range passengers {
+		routePassengers[passenger.ShareRideId] = append(routePassengers[passenger.ShareRideId], passenger)
+	}
+
+	limit := detourLimit()
+	scorer := newDetourScorer(u.RouteEstimator)
+
+	for i := range candidates {
+		candidate := &candidates[i]
+
+		passengers := routePassengers[candidate.ID]
+		if len(passengers) < 1 || candidate.Driver == nil || candidate.Driver.Coordinate == nil {
+			return candidate, &Detour{}, nil
+		}
+
+		detour, err := scorer.Score(ctx, *candidate.Driver.Coordinate, passengers, pickup, destination)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if limit.Allows(detour) {
+			return candidate, detour, nil
+		}
+	}
+
+	return nil, nil, exception.ErrNotFound
+}
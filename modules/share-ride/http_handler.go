@@ -1,7 +1,11 @@
 package shareride
 
 import (
+	"fmt"
+	"io"
+	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/Difaal21/nebeng-dong/helpers/validation"
 	"github.com/Difaal21/nebeng-dong/middleware"
@@ -29,9 +33,20 @@ func NewHTTPHandler(router *gin.Engine, session *middleware.Session, usecase Use
 	router.POST("/nebengdong-service/v1/share-ride/:id/find-passenger/finish", session.Verify, handler.FinishFindPassenger)
 	router.PUT("/nebengdong-service/v1/share-ride/:shareRideId/passenger/:passengerId/status", session.Verify, handler.UpdatePassengerStatusOnShareRide)
 	router.GET("/nebengdong-service/v1/share-ride/driver", session.Verify, handler.GetShareRideByDriver)
-
+	router.GET("/nebengdong-service/v1/share-ride/offers", session.Verify, handler.GetPendingOffers)
+	router.PUT("/nebengdong-service/v1/share-ride/offers/:id/accept", session.Verify, handler.AcceptOffer)
+	router.PUT("/nebengdong-service/v1/share-ride/offers/:id/decline", session.Verify, handler.DeclineOffer)
+	router.POST("/nebengdong-service/v1/share-ride/:id/location", session.Verify, handler.PublishLocation)
+	router.GET("/nebengdong-service/v1/share-ride/:id/location/stream", session.Verify, handler.StreamLocation)
+	router.GET("/nebengdong-service/v1/share-ride/:id/trace", session.Verify, handler.GetTrace)
+
+	router.POST("/nebengdong-service/v1/share-ride/quote", session.Verify, handler.QuoteFare)
 	router.POST("/nebengdong-service/v1/share-ride/find-driver", session.Verify, handler.FindDriver)
 	router.GET("/nebengdong-service/v1/share-ride/passenger", session.Verify, handler.GetShareRideByPassanger)
+	router.PUT("/nebengdong-service/v1/share-ride/:shareRideId/passenger/:passengerId/cancel", session.Verify, handler.CancelBooking)
+	router.GET("/nebengdong-service/v1/share-ride/:id/passenger/:passengerId/receipt", session.Verify, handler.GetReceipt)
+	router.GET("/nebengdong-service/v1/share-ride/:id/passenger/:passengerId/receipt/pdf", session.Verify, handler.DownloadReceipt)
+	router.POST("/nebengdong-service/v1/share-ride/:id/passenger/:passengerId/receipt/email", session.Verify, handler.EmailReceipt)
 
 }
 
@@ -94,6 +109,35 @@ func (handler *HTTPHandler) UpdatePassengerStatusOnShareRide(c *gin.Context) {
 	responses.REST(c, resp)
 }
 
+func (handler *HTTPHandler) CancelBooking(c *gin.Context) {
+
+	context := c.Request.Context()
+
+	shareRideIdStr := c.Param("shareRideId")
+	shareRideId, _ := strconv.ParseInt(shareRideIdStr, 10, 64)
+	passengerIdStr := c.Param("passengerId")
+	passengerId, _ := strconv.ParseInt(passengerIdStr, 10, 64)
+
+	payload := &model.CancelBooking{
+		ID:          passengerId,
+		ShareRideID: shareRideId,
+	}
+
+	if err := c.ShouldBind(&payload); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, payload)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.CancelBooking(context, payload)
+	responses.REST(c, resp)
+}
+
 func (handler *HTTPHandler) FindDriver(c *gin.Context) {
 
 	context := c.Request.Context()
@@ -119,6 +163,31 @@ func (handler *HTTPHandler) FindDriver(c *gin.Context) {
 	responses.REST(c, resp)
 }
 
+func (handler *HTTPHandler) QuoteFare(c *gin.Context) {
+
+	context := c.Request.Context()
+
+	var payload *model.FareQuote
+
+	if c.Request.ContentLength < 1 {
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, "request body empty"))
+		return
+	}
+
+	if err := c.ShouldBind(&payload); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, payload)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.QuoteFare(context, payload)
+	responses.REST(c, resp)
+}
+
 func (handler *HTTPHandler) GetShareRideByDriver(c *gin.Context) {
 	context := c.Request.Context()
 
@@ -126,9 +195,152 @@ func (handler *HTTPHandler) GetShareRideByDriver(c *gin.Context) {
 	responses.REST(c, resp)
 }
 
+func (handler *HTTPHandler) GetPendingOffers(c *gin.Context) {
+	context := c.Request.Context()
+
+	resp := handler.Usecase.GetPendingOffers(context)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) AcceptOffer(c *gin.Context) {
+	context := c.Request.Context()
+
+	offerIdStr := c.Param("id")
+	offerId, _ := strconv.ParseInt(offerIdStr, 10, 64)
+
+	resp := handler.Usecase.AcceptOffer(context, offerId)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) DeclineOffer(c *gin.Context) {
+	context := c.Request.Context()
+
+	offerIdStr := c.Param("id")
+	offerId, _ := strconv.ParseInt(offerIdStr, 10, 64)
+
+	resp := handler.Usecase.DeclineOffer(context, offerId)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) PublishLocation(c *gin.Context) {
+
+	context := c.Request.Context()
+
+	shareRideIdStr := c.Param("id")
+	shareRideId, _ := strconv.ParseInt(shareRideIdStr, 10, 64)
+
+	var payload *model.Coordinate
+
+	if err := c.ShouldBind(&payload); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, payload)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.PublishLocation(context, shareRideId, payload)
+	responses.REST(c, resp)
+}
+
+// StreamLocation sends the driver's location as Server-Sent Events until the client leaves or the stream lifetime ends.
+func (handler *HTTPHandler) StreamLocation(c *gin.Context) {
+
+	context := c.Request.Context()
+
+	shareRideIdStr := c.Param("id")
+	shareRideId, _ := strconv.ParseInt(shareRideIdStr, 10, 64)
+
+	subscription, resp := handler.Usecase.SubscribeLocation(context, shareRideId)
+	if resp != nil {
+		responses.REST(c, resp)
+		return
+	}
+	defer subscription.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	heartbeat := time.NewTicker(locationHeartbeat)
+	defer heartbeat.Stop()
+
+	lifetime := time.NewTimer(locationStreamLifetime)
+	defer lifetime.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-context.Done():
+			return false
+		case <-lifetime.C:
+			return false
+		case <-heartbeat.C:
+			c.SSEvent("heartbeat", "")
+			return true
+		case message, ok := <-subscription.Messages():
+			if !ok {
+				return false
+			}
+			c.SSEvent("location", string(message))
+			return true
+		}
+	})
+}
+
+func (handler *HTTPHandler) GetTrace(c *gin.Context) {
+	context := c.Request.Context()
+
+	shareRideIdStr := c.Param("id")
+	shareRideId, _ := strconv.ParseInt(shareRideIdStr, 10, 64)
+
+	resp := handler.Usecase.GetTrace(context, shareRideId)
+	responses.REST(c, resp)
+}
+
 func (handler *HTTPHandler) GetShareRideByPassanger(c *gin.Context) {
 	context := c.Request.Context()
 
 	resp := handler.Usecase.GetShareRideByPassanger(context)
 	responses.REST(c, resp)
 }
+
+func (handler *HTTPHandler) GetReceipt(c *gin.Context) {
+
+	context := c.Request.Context()
+
+	shareRideId, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	passengerId, _ := strconv.ParseInt(c.Param("passengerId"), 10, 64)
+
+	resp := handler.Usecase.GetReceipt(context, shareRideId, passengerId)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) DownloadReceipt(c *gin.Context) {
+
+	context := c.Request.Context()
+
+	shareRideId, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	passengerId, _ := strconv.ParseInt(c.Param("passengerId"), 10, 64)
+
+	document, filename, resp := handler.Usecase.ReceiptPDF(context, shareRideId, passengerId)
+	if resp != nil {
+		responses.REST(c, resp)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "application/pdf", document)
+}
+
+func (handler *HTTPHandler) EmailReceipt(c *gin.Context) {
+
+	context := c.Request.Context()
+
+	shareRideId, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	passengerId, _ := strconv.ParseInt(c.Param("passengerId"), 10, 64)
+
+	resp := handler.Usecase.EmailReceipt(context, shareRideId, passengerId)
+	responses.REST(c, resp)
+}
@@ -0,0 +1,150 @@
+package shareride
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/pubsub"
+	"github.com/Difaal21/nebeng-dong/responses"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	locationHeartbeat = 15 * time.Second
+	// streams are closed before the server write timeout, EventSource clients reconnect on their own
+	locationStreamLifetime = 55 * time.Second
+)
+
+func locationTopic(shareRideId int64) string {
+	return fmt.Sprintf("share-ride:%d:location", shareRideId)
+}
+
+type LocationUpdate struct {
+	ShareRideID int64             `json:"shareRideId"`
+	DriverID    int64             `json:"driverId"`
+	Coordinate  entity.Coordinate `json:"coordinate"`
+	RecordedAt  time.Time         `json:"recordedAt"`
+}
+
+// PublishLocation stores the driver's coordinate and pushes it to everyone following the share ride.
+func (u *UsecaseImpl) PublishLocation(ctx context.Context, shareRideId int64, payload *model.Coordinate) responses.Responses {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	shareRide, err := u.Repository.FindOne(ctx, "id", shareRideId)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithField("shareRideId", shareRideId).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if shareRide == nil {
+		return httpResponse.NotFound("").NewResponses(nil, "share ride not found")
+	}
+
+	if shareRide.DriverId != requester.ID {
+		return httpResponse.Forbidden("NOT_ELIGIBLE").NewResponses(nil, "not the driver of this share ride")
+	}
+
+	if shareRide.DriverStatus != 1 {
+		return httpResponse.Conflict("FINISHED_SHARE_RIDE").NewResponses(nil, "share ride already finished")
+	}
+
+	update := LocationUpdate{
+		ShareRideID: shareRide.ID,
+		DriverID:    requester.ID,
+		Coordinate: entity.Coordinate{
+			Latitude:  payload.Latitude,
+			Longitude: payload.Longitude,
+		},
+		RecordedAt: time.Now().UTC(),
+	}
+
+	if err := u.UserRepository.UpdateCoordinate(ctx, nil, requester.ID, &update.Coordinate); err != nil {
+		u.Logger.WithContext(ctx).WithField("update", update).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if err := u.recordTrace(ctx, &update); err != nil {
+		u.Logger.WithContext(ctx).WithField("update", update).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	message, err := json.Marshal(update)
+	if err != nil {
+		u.Logger.WithContext(ctx).WithField("update", update).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if err := u.Broker.Publish(ctx, locationTopic(shareRide.ID), message); err != nil {
+		u.Logger.WithContext(ctx).WithField("update", update).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Ok("").NewResponses(update, "location published")
+}
+
+// SubscribeLocation lets the driver and the active passengers of a share ride follow the driver's location.
+// A waiting passenger can only follow once the driver accepted the offer.
+// The caller owns the returned subscription and must close it.
+func (u *UsecaseImpl) SubscribeLocation(ctx context.Context, shareRideId int64) (subscription pubsub.Subscription, resp responses.Responses) {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return nil, httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	shareRide, err := u.Repository.FindOne(ctx, "id", shareRideId)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithField("shareRideId", shareRideId).Error(err.Error())
+		return nil, httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if shareRide == nil {
+		return nil, httpResponse.NotFound("").NewResponses(nil, "share ride not found")
+	}
+
+	if shareRide.DriverStatus != 1 {
+		return nil, httpResponse.Conflict("FINISHED_SHARE_RIDE").NewResponses(nil, "share ride already finished")
+	}
+
+	if shareRide.DriverId != requester.ID {
+		passenger, err := u.PassengerRepository.FindActivePassenger(ctx, shareRide.ID, requester.ID)
+		if err != nil && err != exception.ErrNotFound {
+			u.Logger.WithFields(logrus.Fields{"shareRideId": shareRideId, "requester": requester}).Error(err.Error())
+			return nil, httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+		}
+
+		if passenger == nil {
+			return nil, httpResponse.Forbidden("NOT_ELIGIBLE").NewResponses(nil, "not a passenger of this share ride")
+		}
+
+		if passenger.Status < 2 {
+			offer, err := u.OfferRepository.FindLatestByPassenger(ctx, passenger.ID)
+			if err != nil && err != exception.ErrNotFound {
+				u.Logger.WithFields(logrus.Fields{"shareRideId": shareRideId, "requester": requester}).Error(err.Error())
+				return nil, httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+			}
+
+			if offer == nil || offer.Status != offerAccepted {
+				return nil, httpResponse.Forbidden("NOT_ELIGIBLE").NewResponses(nil, "driver has not accepted the booking yet")
+			}
+		}
+	}
+
+	if subscription, err = u.Broker.Subscribe(ctx, locationTopic(shareRide.ID)); err != nil {
+		u.Logger.WithContext(ctx).WithField("shareRideId", shareRideId).Error(err)
+		return nil, httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return subscription, nil
+}
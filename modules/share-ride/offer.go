@@ -0,0 +1,330 @@
+package shareride
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/helpers/date"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/responses"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	offerPending  = "pending"
+	offerAccepted = "accepted"
+	offerDeclined = "declined"
+	offerExpired  = "expired"
+	offerCanceled = "cancelled"
+
+	defaultOfferTimeout = 30 * time.Second
+	expiredOfferBatch   = 50
+)
+
+func offerTimeout() time.Duration {
+	seconds, err := strconv.ParseInt(os.Getenv("SHARE_RIDE_OFFER_TIMEOUT_SECONDS"), 10, 64)
+	if err != nil || seconds <= 0 {
+		return defaultOfferTimeout
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func driverSearchRadius() float64 {
+	searchRadius, err := strconv.ParseFloat(os.Getenv("DRIVER_SEARCH_RADIUS_KM"), 64)
+	if err != nil || searchRadius <= 0 {
+		return defaultDriverSearchRadius
+	}
+	return searchRadius
+}
+
+// LowRating marks drivers with at least MinimumCount ratings averaging below Stars, they are offered after every other nearby driver.
+type LowRating struct {
+	Stars        float64
+	MinimumCount int64
+}
+
+func lowRating() LowRating {
+	low := LowRating{
+		Stars:        defaultLowRatingStars,
+		MinimumCount: defaultLowRatingMinimumCount,
+	}
+
+	if stars, err := strconv.ParseFloat(os.Getenv("DRIVER_LOW_RATING_STARS"), 64); err == nil && stars >= 0 {
+		low.Stars = stars
+	}
+
+	if count, err := strconv.ParseInt(os.Getenv("DRIVER_LOW_RATING_MINIMUM_COUNT"), 10, 64); err == nil && count >= 0 {
+		low.MinimumCount = count
+	}
+
+	return low
+}
+
+func (u *UsecaseImpl) GetPendingOffers(ctx context.Context) responses.Responses {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if !requester.IsDriver {
+		return httpResponse.Forbidden("INVALID_ROLE").NewResponses(nil, "")
+	}
+
+	offers, err := u.OfferRepository.FindPendingByDriver(ctx, requester.ID)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if offers == nil {
+		return httpResponse.NotFound("").NewResponses(nil, "no pending offer")
+	}
+
+	return httpResponse.Ok("").NewResponses(offers, "")
+}
+
+func (u *UsecaseImpl) AcceptOffer(ctx context.Context, offerId int64) responses.Responses {
+
+	offer, resp := u.findRespondableOffer(ctx, offerId)
+	if resp != nil {
+		return resp
+	}
+
+	if err := u.OfferRepository.UpdateStatus(ctx, nil, offer.ID, offerPending, offerAccepted); err != nil {
+		if err == exception.ErrConflict {
+			return httpResponse.Conflict("OFFER_NOT_PENDING").NewResponses(nil, "offer is no longer pending")
+		}
+		u.Logger.WithContext(ctx).WithField("offer", offer).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Ok("").NewResponses(nil, "offer accepted")
+}
+
+func (u *UsecaseImpl) DeclineOffer(ctx context.Context, offerId int64) responses.Responses {
+
+	offer, resp := u.findRespondableOffer(ctx, offerId)
+	if resp != nil {
+		return resp
+	}
+
+	tx, err := u.Repository.BeginTx(ctx)
+	if err != nil {
+		u.Logger.WithContext(ctx).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if _, err := u.reoffer(ctx, tx, offer, offerDeclined); err != nil {
+		if err == exception.ErrConflict {
+			return httpResponse.Conflict("OFFER_NOT_PENDING").NewResponses(nil, "offer is no longer pending")
+		}
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Ok("").NewResponses(nil, "offer declined")
+}
+
+// ExpireOffers re-offers every booking whose pending offer was not answered in time to the next nearest driver.
+func (u *UsecaseImpl) ExpireOffers(ctx context.Context) (err error) {
+
+	offers, err := u.OfferRepository.FindExpiredPending(ctx, time.Now().UTC(), expiredOfferBatch)
+	if err != nil {
+		if err == exception.ErrNotFound {
+			return nil
+		}
+		return
+	}
+
+	for i := range offers {
+		offer := &offers[i]
+
+		tx, err := u.Repository.BeginTx(ctx)
+		if err != nil {
+			u.Logger.WithContext(ctx).Error(err)
+			return err
+		}
+
+		if _, err := u.reoffer(ctx, tx, offer, offerExpired); err != nil && err != exception.ErrConflict {
+			u.Logger.WithContext(ctx).WithField("offer", offer).Error(err)
+		}
+	}
+
+	return
+}
+
+func (u *UsecaseImpl) findRespondableOffer(ctx context.Context, offerId int64) (offer *entity.ShareRideOffer, resp responses.Responses) {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return nil, httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	offer, err = u.OfferRepository.FindOne(ctx, offerId)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithFields(logrus.Fields{"offerId": offerId, "requester": requester}).Error(err.Error())
+		return nil, httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if offer == nil {
+		return nil, httpResponse.NotFound("").NewResponses(nil, "offer not found")
+	}
+
+	if offer.DriverId != requester.ID {
+		return nil, httpResponse.Forbidden("NOT_ELIGIBLE").NewResponses(nil, "offer belongs to another driver")
+	}
+
+	if offer.Status != offerPending {
+		return nil, httpResponse.Conflict("OFFER_NOT_PENDING").NewResponses(nil, "offer is no longer pending")
+	}
+
+	if time.Now().UTC().After(offer.ExpiresAt) {
+		return nil, httpResponse.Conflict("OFFER_EXPIRED").NewResponses(nil, "offer has expired")
+	}
+
+	return offer, nil
+}
+
+// reoffer closes the offer with offerStatus, marks its passenger as skipped (-2) and moves the booking with its payment
+// to the nearest driver that has not been offered this booking yet. Only drivers of the vehicle type the payment was priced for
+// are offered, so its fare, commission and reserved coins stay valid. When nobody is left the payment is voided
+// and its reserved coins and promo are released.
+// It commits or rolls back tx.
+func (u *UsecaseImpl) reoffer(ctx context.Context, tx *sql.Tx, offer *entity.ShareRideOffer, offerStatus string) (next *entity.ShareRideOffer, err error) {
+
+	fields := logrus.Fields{"offer": offer, "offerStatus": offerStatus}
+
+	if err = u.OfferRepository.UpdateStatus(ctx, tx, offer.ID, offer.Status, offerStatus); err != nil {
+		u.Repository.RollbackTx(ctx, tx)
+		return
+	}
+
+	passenger, err := u.PassengerRepository.FindOnePassengerOnShareRide(ctx, offer.ShareRideId, offer.PassengerId)
+	if err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return
+	}
+
+	if err = u.PassengerRepository.UpdateOne(ctx, tx, passenger.ID, map[string]any{"status": -2}); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return
+	}
+
+	if err = u.Repository.RefreshIsFull(ctx, tx, offer.ShareRideId); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return
+	}
+
+	offeredDriverIds, err := u.OfferRepository.FindOfferedDriverIds(ctx, offer.BookingId)
+	if err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return
+	}
+
+	nearbyDrivers, err := u.Repository.FindNearestActiveDrivers(ctx, 1, passenger.UserId, offeredDriverIds, offer.VehicleType, passenger.PickupCoordinate, driverSearchRadius(), lowRating(), driverCandidateLimit)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return
+	}
+
+	nextDriver, _, err := u.reserveRouteCompatible(ctx, tx, nearbyDrivers, passenger.PickupCoordinate, passenger.DestinationCoordinate)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return
+	}
+
+	if nextDriver == nil {
+		if err = u.PaymentRepository.UpdateByPassengerId(ctx, tx, passenger.ID, map[string]any{"status": "void"}); err != nil {
+			u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+			u.Repository.RollbackTx(ctx, tx)
+			return
+		}
+
+		if err = u.releasePayment(ctx, tx, passenger, 0, 0); err != nil {
+			u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+			u.Repository.RollbackTx(ctx, tx)
+			return
+		}
+
+		if err = u.Repository.CommitTx(ctx, tx); err != nil {
+			u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+			u.Repository.RollbackTx(ctx, tx)
+		}
+		return
+	}
+
+	nextPassenger := &entity.Passengers{
+		UserId:                passenger.UserId,
+		ShareRideId:           nextDriver.ID,
+		Status:                1,
+		PickupCoordinate:      passenger.PickupCoordinate,
+		PickupNote:            passenger.PickupNote,
+		DestinationCoordinate: passenger.DestinationCoordinate,
+		Distance:              passenger.Distance,
+		CreatedAt:             *date.CurrentUTCTime(),
+		DroppedAt:             nil,
+	}
+
+	nextPassengerId, err := u.PassengerRepository.Insert(ctx, tx, nextPassenger)
+	if err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return
+	}
+
+	movePayment := map[string]any{
+		"passenger_id": nextPassengerId,
+		"recipient_id": nextDriver.DriverId,
+	}
+
+	if err = u.PaymentRepository.UpdateByPassengerId(ctx, tx, passenger.ID, movePayment); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return
+	}
+
+	if err = u.Repository.RefreshIsFull(ctx, tx, nextDriver.ID); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return
+	}
+
+	next = &entity.ShareRideOffer{
+		BookingId:   offer.BookingId,
+		PassengerId: nextPassengerId,
+		ShareRideId: nextDriver.ID,
+		DriverId:    nextDriver.DriverId,
+		UserId:      passenger.UserId,
+		VehicleType: offer.VehicleType,
+		Status:      offerPending,
+		ExpiresAt:   date.CurrentUTCTime().Add(offerTimeout()),
+		CreatedAt:   *date.CurrentUTCTime(),
+	}
+
+	if next.ID, err = u.OfferRepository.Insert(ctx, tx, next); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return
+	}
+
+	if err = u.Repository.CommitTx(ctx, tx); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return
+	}
+
+	return
+}
@@ -0,0 +1,374 @@
+package shareride
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/sirupsen/logrus"
+)
+
+type OfferRepository interface {
+	Insert(ctx context.Context, tx *sql.Tx, offer *entity.ShareRideOffer) (id int64, err error)
+	UpdateStatus(ctx context.Context, tx *sql.Tx, id int64, fromStatus string, toStatus string) (err error)
+	FindOne(ctx context.Context, id int64) (offer *entity.ShareRideOffer, err error)
+	FindLatestByPassenger(ctx context.Context, passengerId int64) (offer *entity.ShareRideOffer, err error)
+	FindPendingByDriver(ctx context.Context, driverId int64) (offers []entity.ShareRideOffer, err error)
+	FindExpiredPending(ctx context.Context, now time.Time, limit int) (offers []entity.ShareRideOffer, err error)
+	FindOfferedDriverIds(ctx context.Context, bookingId int64) (driverIds []int64, err error)
+}
+
+type OfferRepositoryImpl struct {
+	DB        *sql.DB
+	Logger    *logrus.Logger
+	TableName string
+}
+
+func NewOfferRepositoryImpl(db *sql.DB, logger *logrus.Logger) OfferRepository {
+	return &OfferRepositoryImpl{
+		DB:        db,
+		Logger:    logger,
+		TableName: "share_ride_offers",
+	}
+}
+
+func (repo *OfferRepositoryImpl) Insert(ctx context.Context, tx *sql.Tx, offer *entity.ShareRideOffer) (id int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`
+	INSERT INTO %s
+	SET
+		id = ?,
+		booking_id = ?,
+		passenger_id = ?,
+		share_ride_id = ?,
+		driver_id = ?,
+		user_id = ?,
+		vehicle_type = ?,
+		status = ?,
+		expires_at = ?,
+		created_at = ?,
+		responded_at = ?
+	`, repo.TableName)
+
+	result, err := Exec(ctx, cmd, command, offer.ID, offer.BookingId, offer.PassengerId, offer.ShareRideId, offer.DriverId, offer.UserId, offer.VehicleType, offer.Status, offer.ExpiresAt, offer.CreatedAt, offer.RespondedAt)
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return
+	}
+
+	if id, err = result.LastInsertId(); err != nil {
+		return
+	}
+	return
+}
+
+// UpdateStatus moves an offer from one status to another. It returns exception.ErrConflict when the offer is no longer in fromStatus,
+// so an accept racing with an expiry only succeeds once.
+func (repo *OfferRepositoryImpl) UpdateStatus(ctx context.Context, tx *sql.Tx, id int64, fromStatus string, toStatus string) (err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`
+	UPDATE
+		%s
+	SET
+		status = ?,
+		responded_at = ?
+	WHERE
+		id = ? AND status = ?
+	`, repo.TableName)
+
+	result, err := Exec(ctx, cmd, command, toStatus, time.Now().UTC(), id, fromStatus)
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	if affected < 1 {
+		return exception.ErrConflict
+	}
+
+	return
+}
+
+func (repo *OfferRepositoryImpl) FindOne(ctx context.Context, id int64) (offer *entity.ShareRideOffer, err error) {
+	var cmd SqlCommand = repo.DB
+
+	query := fmt.Sprintf(`
+	SELECT
+		o.id,
+		o.booking_id,
+		o.passenger_id,
+		o.share_ride_id,
+		o.driver_id,
+		o.user_id,
+		o.vehicle_type,
+		o.status,
+		o.expires_at,
+		o.created_at,
+		o.responded_at
+	FROM
+		%s o
+	WHERE
+		o.id = ?
+	`, repo.TableName)
+
+	offers, err := repo.Query(ctx, cmd, query, id)
+	if err != nil {
+		return
+	}
+
+	offer = &offers[len(offers)-1]
+
+	return
+}
+
+func (repo *OfferRepositoryImpl) FindLatestByPassenger(ctx context.Context, passengerId int64) (offer *entity.ShareRideOffer, err error) {
+	var cmd SqlCommand = repo.DB
+
+	query := fmt.Sprintf(`
+	SELECT
+		o.id,
+		o.booking_id,
+		o.passenger_id,
+		o.share_ride_id,
+		o.driver_id,
+		o.user_id,
+		o.vehicle_type,
+		o.status,
+		o.expires_at,
+		o.created_at,
+		o.responded_at
+	FROM
+		%s o
+	WHERE
+		o.passenger_id = ?
+	ORDER BY
+		o.id DESC
+	LIMIT 1
+	`, repo.TableName)
+
+	offers, err := repo.Query(ctx, cmd, query, passengerId)
+	if err != nil {
+		return
+	}
+
+	offer = &offers[len(offers)-1]
+
+	return
+}
+
+func (repo *OfferRepositoryImpl) FindPendingByDriver(ctx context.Context, driverId int64) (offers []entity.ShareRideOffer, err error) {
+	var cmd SqlCommand = repo.DB
+
+	query := fmt.Sprintf(`
+	SELECT
+		o.id,
+		o.booking_id,
+		o.passenger_id,
+		o.share_ride_id,
+		o.driver_id,
+		o.user_id,
+		o.vehicle_type,
+		o.status,
+		o.expires_at,
+		o.created_at,
+		o.responded_at,
+		p.status,
+		ST_X(p.pickup_coordinate),
+		ST_Y(p.pickup_coordinate),
+		p.pickup_note,
+		ST_X(p.destination_coordinate),
+		ST_Y(p.destination_coordinate),
+		p.distance,
+		u.id,
+		u.name,
+		u.email,
+		u.phone_number
+	FROM
+		%s o
+		join passengers p on p.id = o.passenger_id
+		join users u on u.id = o.user_id
+	WHERE
+		o.driver_id = ? AND o.status = 'pending' AND o.expires_at > ?
+	ORDER BY
+		o.created_at ASC
+	`, repo.TableName)
+
+	offers, err = repo.QueryWithPassenger(ctx, cmd, query, driverId, time.Now().UTC())
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (repo *OfferRepositoryImpl) FindExpiredPending(ctx context.Context, now time.Time, limit int) (offers []entity.ShareRideOffer, err error) {
+	var cmd SqlCommand = repo.DB
+
+	query := fmt.Sprintf(`
+	SELECT
+		o.id,
+		o.booking_id,
+		o.passenger_id,
+		o.share_ride_id,
+		o.driver_id,
+		o.user_id,
+		o.vehicle_type,
+		o.status,
+		o.expires_at,
+		o.created_at,
+		o.responded_at
+	FROM
+		%s o
+	WHERE
+		o.status = 'pending' AND o.expires_at <= ?
+	ORDER BY
+		o.expires_at ASC
+	LIMIT %d
+	`, repo.TableName, limit)
+
+	offers, err = repo.Query(ctx, cmd, query, now)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (repo *OfferRepositoryImpl) FindOfferedDriverIds(ctx context.Context, bookingId int64) (driverIds []int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	query := fmt.Sprintf(`
+	SELECT DISTINCT
+		o.driver_id
+	FROM
+		%s o
+	WHERE
+		o.booking_id = ?
+	`, repo.TableName)
+
+	var rows *sql.Rows
+	if rows, err = cmd.QueryContext(ctx, query, bookingId); err != nil {
+		repo.Logger.Error(err.Error())
+		return
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+	}()
+
+	for rows.Next() {
+		var driverId int64
+		if err = rows.Scan(&driverId); err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+		driverIds = append(driverIds, driverId)
+	}
+
+	return
+}
+
+// ==================================================================================================================== //
+
+func (repo *OfferRepositoryImpl) Query(ctx context.Context, cmd SqlCommand, query string, args ...interface{}) (offers []entity.ShareRideOffer, err error) {
+
+	var rows *sql.Rows
+	if rows, err = cmd.QueryContext(ctx, query, args...); err != nil {
+		repo.Logger.Error(err.Error())
+		return
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+	}()
+
+	for rows.Next() {
+		var offer entity.ShareRideOffer
+
+		err = rows.Scan(&offer.ID, &offer.BookingId, &offer.PassengerId, &offer.ShareRideId, &offer.DriverId, &offer.UserId, &offer.VehicleType, &offer.Status, &offer.ExpiresAt, &offer.CreatedAt, &offer.RespondedAt)
+		if err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+
+		offers = append(offers, offer)
+	}
+
+	if offers == nil {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
+
+func (repo *OfferRepositoryImpl) QueryWithPassenger(ctx context.Context, cmd SqlCommand, query string, args ...interface{}) (offers []entity.ShareRideOffer, err error) {
+
+	var rows *sql.Rows
+	if rows, err = cmd.QueryContext(ctx, query, args...); err != nil {
+		repo.Logger.Error(err.Error())
+		return
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+	}()
+
+	for rows.Next() {
+		var offer entity.ShareRideOffer
+		var passenger entity.Passengers
+		var user entity.UserInVehicle
+
+		err = rows.Scan(&offer.ID, &offer.BookingId, &offer.PassengerId, &offer.ShareRideId, &offer.DriverId, &offer.UserId, &offer.VehicleType, &offer.Status, &offer.ExpiresAt, &offer.CreatedAt, &offer.RespondedAt, &passenger.Status, &passenger.PickupCoordinate.Latitude, &passenger.PickupCoordinate.Longitude, &passenger.PickupNote, &passenger.DestinationCoordinate.Latitude, &passenger.DestinationCoordinate.Longitude, &passenger.Distance, &user.ID, &user.Name, &user.Email, &user.PhoneNumber)
+		if err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+
+		passenger.ID = offer.PassengerId
+		passenger.User = &user
+		offer.Passenger = &passenger
+
+		offers = append(offers, offer)
+	}
+
+	if offers == nil {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,58 @@
+package shareride
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// OfferExpiryWorker periodically re-offers bookings whose driver did not answer in time.
+type OfferExpiryWorker struct {
+	usecase  Usecase
+	logger   *logrus.Logger
+	interval time.Duration
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewOfferExpiryWorker is a constructor.
+func NewOfferExpiryWorker(usecase Usecase, logger *logrus.Logger, interval time.Duration) *OfferExpiryWorker {
+	return &OfferExpiryWorker{
+		usecase:  usecase,
+		logger:   logger,
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start runs the worker in the background.
+func (w *OfferExpiryWorker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	go func() {
+		defer close(w.done)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := w.usecase.ExpireOffers(ctx); err != nil {
+					w.logger.Error(err.Error())
+				}
+			}
+		}
+	}()
+}
+
+// Close stops the worker and waits for the running round to finish.
+func (w *OfferExpiryWorker) Close() {
+	w.cancel()
+	<-w.done
+	w.logger.Info("Offer expiry worker is gracefully shutdown.")
+}
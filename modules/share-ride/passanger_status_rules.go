@@ -8,6 +8,7 @@ import (
 	"github.com/Difaal21/nebeng-dong/exception"
 	"github.com/Difaal21/nebeng-dong/helpers/date"
 	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/modules/wallet"
 	"github.com/Difaal21/nebeng-dong/responses"
 	"github.com/sirupsen/logrus"
 )
@@ -28,29 +29,70 @@ var invalidRule = "INVALID_PASSENGER_STATUS_RULES"
 // CurrentStatus: 1 (Menunggu)
 func (h *WaitingStatusHandler) HandleUpdateStatus(ctx context.Context, u *UsecaseImpl, payload *model.UpdatePassengerStatus, tx *sql.Tx, shareRide *entity.ShareRide, passenger *entity.Passengers) responses.Responses {
 
-	if payload.Code != 2 || payload.Code == -2 {
-		return httpResponse.BadRequest(invalidRule).NewResponses(nil, "after waiting should be picked up")
-	}
-
-	updatedFieldOnPassenger := map[string]any{
-		"status": payload.Code,
+	if payload.Code != 2 && payload.Code != -2 {
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.BadRequest(invalidRule).NewResponses(nil, "after waiting should be picked up or skipped")
 	}
 
-	var updatedFieldOnShareRide = make(map[string]any)
-
-	if payload.Code == 2 {
-		updatedFieldOnShareRide["is_full"] = true
+	offer, err := u.OfferRepository.FindLatestByPassenger(ctx, passenger.ID)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithContext(ctx).WithFields(logrus.Fields{"payload": payload, "shareRide": shareRide, "passenger": passenger}).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
 
 	if payload.Code == -2 {
-		updatedFieldOnShareRide["driver_status"] = 2
-		updatedFieldOnShareRide["finisihed_at"] = date.CurrentUTCTime()
+		if offer != nil && (offer.Status == offerPending || offer.Status == offerAccepted) {
+			if _, err := u.reoffer(ctx, tx, offer, offerDeclined); err != nil {
+				if err == exception.ErrConflict {
+					return httpResponse.Conflict("").NewResponses(nil, "offer already responded")
+				}
+				return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+			}
+			return httpResponse.Ok("").NewResponses(nil, "status updated")
+		}
+
+		if err := u.PassengerRepository.UpdateOne(ctx, tx, payload.ID, map[string]any{"status": payload.Code}); err != nil {
+			u.Logger.WithContext(ctx).WithFields(logrus.Fields{"payload": payload, "shareRide": shareRide, "passenger": passenger}).Error(err)
+			u.Repository.RollbackTx(ctx, tx)
+			return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+		}
+
+		if err := u.PaymentRepository.UpdateByPassengerId(ctx, tx, passenger.ID, map[string]any{"status": "void"}); err != nil {
+			u.Logger.WithContext(ctx).WithFields(logrus.Fields{"payload": payload, "shareRide": shareRide, "passenger": passenger}).Error(err)
+			u.Repository.RollbackTx(ctx, tx)
+			return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+		}
+
+		if err := u.releasePayment(ctx, tx, passenger, 0, 0); err != nil {
+			u.Logger.WithContext(ctx).WithFields(logrus.Fields{"payload": payload, "shareRide": shareRide, "passenger": passenger}).Error(err)
+			u.Repository.RollbackTx(ctx, tx)
+			return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+		}
+
+		if err := u.Repository.RefreshIsFull(ctx, tx, shareRide.ID); err != nil {
+			u.Logger.WithContext(ctx).WithFields(logrus.Fields{"payload": payload, "shareRide": shareRide, "passenger": passenger}).Error(err)
+			u.Repository.RollbackTx(ctx, tx)
+			return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+		}
+
+		if err := u.Repository.CommitTx(ctx, tx); err != nil {
+			u.Logger.WithContext(ctx).WithFields(logrus.Fields{"payload": payload, "shareRide": shareRide, "passenger": passenger}).Error(err)
+			u.Repository.RollbackTx(ctx, tx)
+			return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+		}
+
+		return httpResponse.Ok("").NewResponses(nil, "status updated")
 	}
 
-	if err := u.Repository.UpdateOne(ctx, tx, shareRide.ID, updatedFieldOnShareRide); err != nil {
-		u.Logger.WithContext(ctx).WithFields(logrus.Fields{"payload": payload, "shareRide": shareRide, "passenger": passenger}).Error(err)
+	// bookings made before offers existed have no offer row and are allowed through
+	if offer != nil && offer.Status != offerAccepted {
 		u.Repository.RollbackTx(ctx, tx)
-		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+		return httpResponse.BadRequest(invalidRule).NewResponses(nil, "the offer must be accepted before picking up the passenger")
+	}
+
+	updatedFieldOnPassenger := map[string]any{
+		"status": payload.Code,
 	}
 
 	if err := u.PassengerRepository.UpdateOne(ctx, tx, payload.ID, updatedFieldOnPassenger); err != nil {
@@ -72,6 +114,7 @@ func (h *WaitingStatusHandler) HandleUpdateStatus(ctx context.Context, u *Usecas
 func (h *PickedUpStatusHandler) HandleUpdateStatus(ctx context.Context, u *UsecaseImpl, payload *model.UpdatePassengerStatus, tx *sql.Tx, shareRide *entity.ShareRide, passenger *entity.Passengers) responses.Responses {
 
 	if payload.Code != 3 {
+		u.Repository.RollbackTx(ctx, tx)
 		return httpResponse.BadRequest(invalidRule).NewResponses(nil, "after being picked up the driver should have arrived")
 	}
 
@@ -98,11 +141,13 @@ func (h *PickedUpStatusHandler) HandleUpdateStatus(ctx context.Context, u *Useca
 func (h *ArrivedStatusHandler) HandleUpdateStatus(ctx context.Context, u *UsecaseImpl, payload *model.UpdatePassengerStatus, tx *sql.Tx, shareRide *entity.ShareRide, passenger *entity.Passengers) responses.Responses {
 
 	if payload.Code != 4 {
+		u.Repository.RollbackTx(ctx, tx)
 		return httpResponse.BadRequest(invalidRule).NewResponses(nil, "once the driver arrives, next is on the way")
 	}
 
 	updatedFieldOnPassenger := map[string]any{
-		"status": payload.Code,
+		"status":       payload.Code,
+		"picked_up_at": date.CurrentUTCTime(),
 	}
 
 	if err := u.PassengerRepository.UpdateOne(ctx, tx, payload.ID, updatedFieldOnPassenger); err != nil {
@@ -123,23 +168,23 @@ func (h *ArrivedStatusHandler) HandleUpdateStatus(ctx context.Context, u *Usecas
 func (h *OnTheWayStatusHandler) HandleUpdateStatus(ctx context.Context, u *UsecaseImpl, payload *model.UpdatePassengerStatus, tx *sql.Tx, shareRide *entity.ShareRide, passenger *entity.Passengers) responses.Responses {
 
 	if payload.Code != 5 {
+		u.Repository.RollbackTx(ctx, tx)
 		return httpResponse.BadRequest(invalidRule).NewResponses(nil, "after on the way it should arrive or done")
 	}
 
-	updatedFieldOnShareRide := map[string]any{
-		"finished_at":   date.CurrentUTCTime(),
-		"driver_status": 2,
-	}
+	droppedAt := date.CurrentUTCTime()
 
-	if err := u.Repository.UpdateOne(ctx, tx, shareRide.ID, updatedFieldOnShareRide); err != nil {
+	travelledDistance, err := u.passengerTravelledDistance(ctx, passenger, droppedAt)
+	if err != nil {
 		u.Logger.WithContext(ctx).WithFields(logrus.Fields{"payload": payload, "shareRide": shareRide, "passenger": passenger}).Error(err)
 		u.Repository.RollbackTx(ctx, tx)
 		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
 
 	updatedFieldOnPassenger := map[string]any{
-		"status":     payload.Code,
-		"dropped_at": date.CurrentUTCTime(),
+		"status":             payload.Code,
+		"dropped_at":         droppedAt,
+		"travelled_distance": travelledDistance,
 	}
 
 	if err := u.PassengerRepository.UpdateOne(ctx, tx, payload.ID, updatedFieldOnPassenger); err != nil {
@@ -148,44 +193,82 @@ func (h *OnTheWayStatusHandler) HandleUpdateStatus(ctx context.Context, u *Useca
 		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
 
-	if err := u.PaymentRepository.UpdatePaidStatusByPassengerId(ctx, tx, passenger.ID); err != nil {
+	activePassengers, err := u.Repository.CountActivePassengers(ctx, tx, shareRide.ID)
+	if err != nil {
 		u.Logger.WithContext(ctx).WithFields(logrus.Fields{"payload": payload, "shareRide": shareRide, "passenger": passenger}).Error(err)
 		u.Repository.RollbackTx(ctx, tx)
 		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
 
-	driver, err := u.UserRepository.FindOneById(ctx, shareRide.DriverId)
-	if err != nil && err != exception.ErrNotFound {
-		u.Logger.WithContext(ctx).WithFields(logrus.Fields{"payload": payload, "shareRide": shareRide, "passenger": passenger, "driver": driver}).Error(err)
+	// the share ride only finishes once the last pooled passenger has been dropped off
+	if activePassengers == 0 {
+		updatedFieldOnShareRide := map[string]any{
+			"finished_at":   date.CurrentUTCTime(),
+			"driver_status": 2,
+		}
+
+		if err := u.Repository.UpdateOne(ctx, tx, shareRide.ID, updatedFieldOnShareRide); err != nil {
+			u.Logger.WithContext(ctx).WithFields(logrus.Fields{"payload": payload, "shareRide": shareRide, "passenger": passenger}).Error(err)
+			u.Repository.RollbackTx(ctx, tx)
+			return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+		}
+	} else if err := u.Repository.RefreshIsFull(ctx, tx, shareRide.ID); err != nil {
+		u.Logger.WithContext(ctx).WithFields(logrus.Fields{"payload": payload, "shareRide": shareRide, "passenger": passenger}).Error(err)
 		u.Repository.RollbackTx(ctx, tx)
 		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
 
-	totalCoin := driver.Coin - passenger.Payment[0].TotalAmount
-	reduceBalance := map[string]any{
-		"coin": totalCoin,
+	if err := u.PaymentRepository.UpdatePaidStatusByPassengerId(ctx, tx, passenger.ID); err != nil {
+		u.Logger.WithContext(ctx).WithFields(logrus.Fields{"payload": payload, "shareRide": shareRide, "passenger": passenger}).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
 
-	if err := u.UserRepository.Update(ctx, tx, shareRide.DriverId, reduceBalance); err != nil {
+	if err := u.capturePayment(ctx, tx, shareRide.DriverId, passenger.Payment[0]); err != nil {
 		u.Logger.WithContext(ctx).WithFields(logrus.Fields{"payload": payload, "shareRide": shareRide, "passenger": passenger}).Error(err)
 		u.Repository.RollbackTx(ctx, tx)
 		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
 
+	// the driver keeps the cash and receives the coins and promo subsidy, the platform commission is taken from their balance
+	if commission := passenger.Payment[0].Commission; commission > 0 {
+		platformCommission := &wallet.Entry{
+			UserId:        shareRide.DriverId,
+			Amount:        commission,
+			ReferenceType: wallet.ReferencePayment,
+			ReferenceId:   &passenger.Payment[0].ID,
+			Description:   "share ride commission",
+			AllowNegative: true,
+		}
+
+		if _, err := u.Ledger.Debit(ctx, tx, platformCommission); err != nil {
+			u.Logger.WithContext(ctx).WithFields(logrus.Fields{"payload": payload, "shareRide": shareRide, "passenger": passenger}).Error(err)
+			u.Repository.RollbackTx(ctx, tx)
+			return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+		}
+	}
+
 	if err := u.Repository.CommitTx(ctx, tx); err != nil {
 		u.Logger.WithContext(ctx).WithFields(logrus.Fields{"payload": payload, "shareRide": shareRide, "passenger": passenger}).Error(err)
 		u.Repository.RollbackTx(ctx, tx)
 		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
+
+	if receiptEmailOnFinish() {
+		go u.mailReceiptAfterTrip(shareRide.ID, passenger.ID)
+	}
+
 	return httpResponse.Ok("").NewResponses(nil, "status updated")
 }
 
 // CurrentStatus: -2 (Ditolak)
 func (h *SkippedStatusHandler) HandleUpdateStatus(ctx context.Context, u *UsecaseImpl, payload *model.UpdatePassengerStatus, tx *sql.Tx, shareRide *entity.ShareRide, passenger *entity.Passengers) responses.Responses {
+	u.Repository.RollbackTx(ctx, tx)
 	return httpResponse.BadRequest(invalidRule).NewResponses(nil, "cannot skip if the passenger is not waiting")
 }
 
 // CurrentStatus: 5 (Selesai)
 func (h *DoneStatusHandler) HandleUpdateStatus(ctx context.Context, u *UsecaseImpl, payload *model.UpdatePassengerStatus, tx *sql.Tx, shareRide *entity.ShareRide, passenger *entity.Passengers) responses.Responses {
+	u.Repository.RollbackTx(ctx, tx)
 	return httpResponse.BadRequest(invalidRule).NewResponses(nil, "cannot change what has been done")
 }
@@ -0,0 +1,189 @@
+package shareride
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/modules/promotions"
+	"github.com/Difaal21/nebeng-dong/modules/wallet"
+	"github.com/Difaal21/nebeng-dong/responses"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	paymentCash  = "cash"
+	paymentCoin  = "coin"
+	paymentSplit = "split"
+	// the discount of a promo code is recorded as its own payment_detail row
+	paymentPromo = "promo"
+)
+
+// paymentDetailsFor splits what the passenger pays into the payment_detail rows of the chosen method. A split payment
+// takes coinAmount from the wallet and leaves the rest to be paid in cash. Nothing is left to pay when a promo covers the fare.
+func paymentDetailsFor(method string, coinAmount, totalAmount int64) (details []*entity.PaymentDetails, err error) {
+	if totalAmount < 1 {
+		return nil, nil
+	}
+
+	switch method {
+	case "", paymentCash:
+		return []*entity.PaymentDetails{{PaymentMethod: paymentCash, Amount: totalAmount}}, nil
+	case paymentCoin:
+		return []*entity.PaymentDetails{{PaymentMethod: paymentCoin, Amount: totalAmount}}, nil
+	case paymentSplit:
+		if coinAmount < 1 || coinAmount >= totalAmount {
+			return nil, fmt.Errorf("coin amount of a split payment must be between 1 and %d", totalAmount-1)
+		}
+		return []*entity.PaymentDetails{
+			{PaymentMethod: paymentCoin, Amount: coinAmount},
+			{PaymentMethod: paymentCash, Amount: totalAmount - coinAmount},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown payment method %s", method)
+}
+
+// amountOf sums the payment_detail rows of one method.
+func amountOf(payment *entity.Payment, method string) (amount int64) {
+	for _, detail := range payment.PaymentDetails {
+		if detail.PaymentMethod == method {
+			amount += detail.Amount
+		}
+	}
+	return
+}
+
+// reserveCoins takes the coin part of a booking from the passenger's wallet until the trip is captured or released.
+func (u *UsecaseImpl) reserveCoins(ctx context.Context, tx *sql.Tx, userId, paymentId, amount int64) (err error) {
+	if amount < 1 {
+		return
+	}
+
+	_, err = u.Ledger.Debit(ctx, tx, &wallet.Entry{
+		UserId:        userId,
+		Amount:        amount,
+		ReferenceType: wallet.ReferencePayment,
+		ReferenceId:   &paymentId,
+		Description:   "share ride coin reservation",
+	})
+	return
+}
+
+// capturePayment credits the driver of a finished trip with the reserved coins and the promo discount the platform pays for.
+func (u *UsecaseImpl) capturePayment(ctx context.Context, tx *sql.Tx, driverId int64, payment *entity.Payment) (err error) {
+	parts := []struct {
+		method      string
+		description string
+	}{
+		{paymentCoin, "share ride coin payment"},
+		{paymentPromo, "share ride promo subsidy"},
+	}
+
+	for _, part := range parts {
+		amount := amountOf(payment, part.method)
+		if amount < 1 {
+			continue
+		}
+
+		if _, err = u.Ledger.Credit(ctx, tx, &wallet.Entry{
+			UserId:        driverId,
+			Amount:        amount,
+			ReferenceType: wallet.ReferencePayment,
+			ReferenceId:   &payment.ID,
+			Description:   part.description,
+		}); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// releasePayment gives the promo use and the reserved coins back to the passenger. A cancellation fee is paid to
+// the driver, first out of the coin reservation and the rest, e.g. of a cash payment, debited from the passenger's
+// wallet even when that takes the balance below zero.
+func (u *UsecaseImpl) releasePayment(ctx context.Context, tx *sql.Tx, passenger *entity.Passengers, driverId, cancellationFee int64) (err error) {
+	if len(passenger.Payment) < 1 {
+		return
+	}
+
+	payment := passenger.Payment[0]
+
+	if amountOf(payment, paymentPromo) > 0 {
+		if err = u.Promotions.Release(ctx, tx, payment.ID); err != nil {
+			return
+		}
+	}
+
+	amount := amountOf(payment, paymentCoin)
+
+	retained := cancellationFee
+	if retained > amount {
+		retained = amount
+	}
+
+	if cancellationFee-retained > 0 {
+		if _, err = u.Ledger.Debit(ctx, tx, &wallet.Entry{
+			UserId:        passenger.UserId,
+			Amount:        cancellationFee - retained,
+			ReferenceType: wallet.ReferencePayment,
+			ReferenceId:   &payment.ID,
+			Description:   "share ride cancellation fee",
+			AllowNegative: true,
+		}); err != nil {
+			return
+		}
+	}
+
+	if cancellationFee > 0 {
+		if _, err = u.Ledger.Credit(ctx, tx, &wallet.Entry{
+			UserId:        driverId,
+			Amount:        cancellationFee,
+			ReferenceType: wallet.ReferencePayment,
+			ReferenceId:   &payment.ID,
+			Description:   "share ride cancellation fee",
+		}); err != nil {
+			return
+		}
+	}
+
+	if amount-retained < 1 {
+		return
+	}
+
+	_, err = u.Ledger.Credit(ctx, tx, &wallet.Entry{
+		UserId:        passenger.UserId,
+		Amount:        amount - retained,
+		ReferenceType: wallet.ReferencePayment,
+		ReferenceId:   &payment.ID,
+		Description:   "share ride coin reservation released",
+	})
+	return
+}
+
+// quotePromo returns the discount of a promo code on the fare, nil without a code.
+func (u *UsecaseImpl) quotePromo(ctx context.Context, code string, userId, fareAmount int64) (discount *promotions.Discount, resp responses.Responses) {
+	if code == "" {
+		return nil, nil
+	}
+
+	discount, err := u.Promotions.Quote(ctx, code, userId, fareAmount)
+	if err != nil {
+		if ineligible, ok := err.(*promotions.Ineligible); ok {
+			return nil, httpResponse.BadRequest("PROMO_NOT_APPLICABLE").NewResponses(nil, ineligible.Reason)
+		}
+		u.Logger.WithContext(ctx).WithFields(logrus.Fields{"code": code, "userId": userId}).Error(err)
+		return nil, httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return discount, nil
+}
+
+func discountAmount(discount *promotions.Discount) int64 {
+	if discount == nil {
+		return 0
+	}
+	return discount.Amount
+}
@@ -0,0 +1,188 @@
+package shareride
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/helpers/pdf"
+	"github.com/Difaal21/nebeng-dong/mailer"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/responses"
+	"github.com/sirupsen/logrus"
+)
+
+const receiptTimeLayout = "02 Jan 2006 15:04 MST"
+
+func receiptEmailOnFinish() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("RECEIPT_EMAIL_ON_FINISH"))
+	return enabled
+}
+
+func (u *UsecaseImpl) GetReceipt(ctx context.Context, shareRideId int64, passengerId int64) responses.Responses {
+
+	receipt, resp := u.findReceipt(ctx, shareRideId, passengerId)
+	if resp != nil {
+		return resp
+	}
+
+	return httpResponse.Ok("").NewResponses(receipt, "get receipt success")
+}
+
+func (u *UsecaseImpl) ReceiptPDF(ctx context.Context, shareRideId int64, passengerId int64) (document []byte, filename string, resp responses.Responses) {
+
+	receipt, resp := u.findReceipt(ctx, shareRideId, passengerId)
+	if resp != nil {
+		return nil, "", resp
+	}
+
+	return renderReceipt(receipt), receipt.Number + ".pdf", nil
+}
+
+// EmailReceipt sends the PDF receipt to the email of the passenger.
+func (u *UsecaseImpl) EmailReceipt(ctx context.Context, shareRideId int64, passengerId int64) responses.Responses {
+
+	receipt, resp := u.findReceipt(ctx, shareRideId, passengerId)
+	if resp != nil {
+		return resp
+	}
+
+	if err := u.mailReceipt(ctx, receipt); err != nil {
+		u.Logger.WithContext(ctx).WithField("receipt", receipt.Number).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, "receipt could not be sent, please retry")
+	}
+
+	return httpResponse.Ok("").NewResponses(nil, fmt.Sprintf("receipt sent to %s", receipt.PassengerEmail))
+}
+
+// mailReceiptAfterTrip is started once a passenger is dropped off, failures are only logged since the trip itself is done.
+func (u *UsecaseImpl) mailReceiptAfterTrip(shareRideId int64, passengerId int64) {
+	ctx := context.Background()
+
+	receipt, err := u.Repository.FindReceipt(ctx, shareRideId, passengerId)
+	if err != nil {
+		u.Logger.WithFields(logrus.Fields{"shareRideId": shareRideId, "passengerId": passengerId}).Error(err)
+		return
+	}
+
+	receipt.Number = receiptNumber(receipt)
+
+	if err := u.mailReceipt(ctx, receipt); err != nil {
+		u.Logger.WithField("receipt", receipt.Number).Error(err)
+	}
+}
+
+// findReceipt returns the receipt of a finished trip to its passenger or driver.
+func (u *UsecaseImpl) findReceipt(ctx context.Context, shareRideId int64, passengerId int64) (receipt *entity.Receipt, resp responses.Responses) {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return nil, httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	receipt, err = u.Repository.FindReceipt(ctx, shareRideId, passengerId)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithContext(ctx).WithFields(logrus.Fields{"shareRideId": shareRideId, "passengerId": passengerId}).Error(err)
+		return nil, httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if receipt == nil {
+		return nil, httpResponse.NotFound("").NewResponses(nil, "receipt not found, the trip may not be finished yet")
+	}
+
+	if requester.ID != receipt.PassengerUserId && requester.ID != receipt.DriverId {
+		return nil, httpResponse.Forbidden("").NewResponses(nil, "not eligible to see this receipt")
+	}
+
+	receipt.Number = receiptNumber(receipt)
+
+	return receipt, nil
+}
+
+func (u *UsecaseImpl) mailReceipt(ctx context.Context, receipt *entity.Receipt) (err error) {
+	message := &mailer.Message{
+		To:      []string{receipt.PassengerEmail},
+		Subject: fmt.Sprintf("Your Nebeng Dong receipt %s", receipt.Number),
+		Text:    fmt.Sprintf("Hi %s,\n\nThank you for riding with %s. Your receipt is attached.\n\nTotal: %s\n", receipt.PassengerName, receipt.DriverName, rupiah(receipt.TotalAmount)),
+		Attachments: []mailer.Attachment{
+			{Filename: receipt.Number + ".pdf", ContentType: "application/pdf", Data: renderReceipt(receipt)},
+		},
+	}
+
+	return u.Mailer.Send(ctx, message)
+}
+
+func receiptNumber(receipt *entity.Receipt) string {
+	date := receipt.BookedAt
+	if receipt.DroppedAt != nil {
+		date = *receipt.DroppedAt
+	}
+	return fmt.Sprintf("NBD-%s-%06d", date.Format("20060102"), receipt.PassengerId)
+}
+
+func renderReceipt(receipt *entity.Receipt) []byte {
+	document := pdf.New()
+
+	document.Bold("Nebeng Dong", 20)
+	document.Text("Trip receipt "+receipt.Number, 12)
+	document.Space(12)
+
+	document.Row("Passenger", receipt.PassengerName, 11)
+	document.Row("Driver", receipt.DriverName, 11)
+	document.Row("Vehicle", strings.TrimSpace(receipt.VehicleModel), 11)
+	document.Row("License plate", receipt.LicensePlate, 11)
+	document.Space(12)
+
+	document.Row("Booked at", receipt.BookedAt.Format(receiptTimeLayout), 11)
+	if receipt.PickedUpAt != nil {
+		document.Row("Picked up at", receipt.PickedUpAt.Format(receiptTimeLayout), 11)
+	}
+	if receipt.DroppedAt != nil {
+		document.Row("Dropped off at", receipt.DroppedAt.Format(receiptTimeLayout), 11)
+	}
+
+	distance := receipt.Distance
+	if receipt.TravelledDistance != nil {
+		distance = *receipt.TravelledDistance
+	}
+	document.Row("Distance", fmt.Sprintf("%.2f km", distance), 11)
+	document.Space(12)
+
+	document.Bold("Fare", 13)
+	document.Row("Base fare", rupiah(receipt.BaseFare), 11)
+	document.Row("Distance fare", rupiah(receipt.DistanceFare), 11)
+	document.Row("Total", rupiah(receipt.TotalAmount), 11)
+	document.Space(12)
+
+	document.Bold("Payment", 13)
+	for _, detail := range receipt.PaymentDetails {
+		amount := rupiah(detail.Amount)
+		if detail.PaymentMethod == paymentPromo {
+			amount = "-" + amount
+		}
+		document.Row(strings.ToUpper(detail.PaymentMethod[:1])+detail.PaymentMethod[1:], amount, 11)
+	}
+	document.Row("Status", receipt.PaymentStatus, 11)
+
+	return document.Bytes()
+}
+
+// rupiah formats an amount the Indonesian way, e.g. Rp 12.500.
+func rupiah(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	for i := len(digits) - 3; i > 0; i -= 3 {
+		digits = digits[:i] + "." + digits[i:]
+	}
+
+	return sign + "Rp " + digits
+}
@@ -20,10 +20,15 @@ type Repository interface {
 	Insert(ctx context.Context, tx *sql.Tx, shareRide *entity.ShareRide) (id int64, err error)
 	UpdateOne(ctx context.Context, tx *sql.Tx, id int64, updateFields map[string]any) (err error)
 	CheckActiveDriver(ctx context.Context, driverId int64, driverStatus int8) (shareRide *entity.ShareRide, err error)
-	FindActiveDriver(ctx context.Context, driverStatus int8) (shareRide *entity.ShareRide, err error)
+	FindNearestActiveDrivers(ctx context.Context, driverStatus int8, requesterId int64, excludedDriverIds []int64, vehicleType string, coordinate entity.Coordinate, radius float64, lowRating LowRating, limit int) (shareRides []entity.ShareRide, err error)
 	FindOne(ctx context.Context, coloumn string, value any) (shareRide *entity.ShareRide, err error)
 	FindActiveShareRideByDriver(ctx context.Context, driverId int64) (shareRide *entity.ShareRide, err error)
 	FindActiveShareRideByPassenger(ctx context.Context, passengerId int64) (shareRide *entity.ShareRide, err error)
+	CountActivePassengers(ctx context.Context, tx *sql.Tx, shareRideId int64) (count int, err error)
+	FindRoutePassengers(ctx context.Context, shareRideIds []int64) (passengers []entity.Passengers, err error)
+	RefreshIsFull(ctx context.Context, tx *sql.Tx, shareRideId int64) (err error)
+	ReserveSeat(ctx context.Context, tx *sql.Tx, shareRideId int64) (err error)
+	FindReceipt(ctx context.Context, shareRideId int64, passengerId int64) (receipt *entity.Receipt, err error)
 }
 
 type RepositoryImpl struct {
@@ -94,6 +99,9 @@ func (repo *RepositoryImpl) CheckActiveDriver(ctx context.Context, driverId int6
 		sr.finished_at,
 		p.id,
 		p.status,
+		ST_X(p.pickup_coordinate),
+		ST_Y(p.pickup_coordinate),
+		p.pickup_note,
 		ST_X(p.destination_coordinate),
 		ST_Y(p.destination_coordinate),
 		p.distance,
@@ -141,9 +149,30 @@ func (repo *RepositoryImpl) CheckActiveDriver(ctx context.Context, driverId int6
 	return
 }
 
-func (repo *RepositoryImpl) FindActiveDriver(ctx context.Context, status int8) (shareRide *entity.ShareRide, err error) {
+// FindNearestActiveDrivers returns open share rides with a free seat whose driver is within radius (in km) of the given coordinate, nearest first.
+// Drivers with a low rating come after all the others. A non-empty vehicleType only keeps drivers of that type.
+// Coordinates are stored as POINT(latitude, longitude), so ST_X is the latitude and ST_Y is the longitude.
+func (repo *RepositoryImpl) FindNearestActiveDrivers(ctx context.Context, status int8, requesterId int64, excludedDriverIds []int64, vehicleType string, coordinate entity.Coordinate, radius float64, lowRating LowRating, limit int) (shareRides []entity.ShareRide, err error) {
 	var cmd SqlCommand = repo.DB
 
+	args := []interface{}{coordinate.Latitude, coordinate.Latitude, coordinate.Longitude, status, requesterId}
+
+	var excludedDrivers string
+	if len(excludedDriverIds) > 0 {
+		excludedDrivers = fmt.Sprintf("AND sr.driver_id NOT IN (%s)", placeholders(len(excludedDriverIds)))
+		for _, driverId := range excludedDriverIds {
+			args = append(args, driverId)
+		}
+	}
+
+	var vehicleTypeFilter string
+	if vehicleType != "" {
+		vehicleTypeFilter = "AND COALESCE(v.type, ?) = ?"
+		args = append(args, defaultVehicleType, vehicleType)
+	}
+
+	args = append(args, radius, lowRating.MinimumCount, lowRating.Stars)
+
 	query := fmt.Sprintf(`
 	SELECT
 		sr.id,
@@ -152,53 +181,45 @@ func (repo *RepositoryImpl) FindActiveDriver(ctx context.Context, status int8) (
 		sr.driver_status,
 		sr.created_at,
 		sr.finished_at,
-		p.id,
-		p.status,
-		ST_X(p.destination_coordinate),
-		ST_Y(p.destination_coordinate),
-		p.distance,
-		p.created_at,
-		p.dropped_at,
-		pymt.id,
-		pymt.status,
-		pymt.total_amount,
-		pymt.created_at,
-		pd.id,
-		pd.payment_method,
-		pd.amount,
 		d.id,
 		d.name,
 		d.email,
 		d.phone_number,
-		u.id,
-		u.name,
-		u.email,
-		u.phone_number
+		d.rating_average,
+		d.rating_count,
+		ST_X(d.coordinate),
+		ST_Y(d.coordinate),
+		v.id,
+		v.type,
+		v.manufacture,
+		v.model,
+		v.license_plate,
+		v.in_use,
+		v.capacity,
+		(SELECT COUNT(*) FROM passengers p WHERE p.share_ride_id = sr.id AND p.status IN (1, 2, 3, 4)) AS seats_taken,
+		6371 * 2 * ASIN(SQRT(
+			POWER(SIN(RADIANS(ST_X(d.coordinate) - ?) / 2), 2) +
+			COS(RADIANS(?)) * COS(RADIANS(ST_X(d.coordinate))) * POWER(SIN(RADIANS(ST_Y(d.coordinate) - ?) / 2), 2)
+		)) AS driver_distance
 	FROM
 		%s sr
-		left join passengers p on p.share_ride_id = sr.id
-		left join payment pymt on pymt.passenger_id = p.id
-		left join payment_detail pd on pd.payment_id = pymt.id
-		left join users d on d.id = sr.driver_id
-		left join users u on u.id = p.user_id
+		join users d on d.id = sr.driver_id
+		left join vehicles v on v.user_id = d.id and v.in_use = 1
 	WHERE
-		sr.driver_status = ? AND p.id is null
-	LIMIT 1
-	`, repo.TableName)
-
-	shareRides, err := repo.Query(ctx, cmd, query, status)
+		sr.driver_status = ? AND d.coordinate is not null AND sr.driver_id <> ? %s %s
+	HAVING
+		driver_distance <= ? AND seats_taken < COALESCE(v.capacity, 1)
+	ORDER BY
+		(d.rating_count >= ? AND d.rating_average < ?) ASC,
+		driver_distance ASC
+	LIMIT %d
+	`, repo.TableName, excludedDrivers, vehicleTypeFilter, limit)
+
+	shareRides, err = repo.QueryNearby(ctx, cmd, query, args...)
 	if err != nil {
 		return
 	}
 
-	lengthOfUsers := len(shareRides)
-	if lengthOfUsers < 1 {
-		err = exception.ErrNotFound
-		return
-	}
-
-	shareRide = &shareRides[lengthOfUsers-1]
-
 	return
 }
 
@@ -253,6 +274,9 @@ func (repo *RepositoryImpl) FindOne(ctx context.Context, coloumn string, value a
 		sr.finished_at,
 		p.id,
 		p.status,
+		ST_X(p.pickup_coordinate),
+		ST_Y(p.pickup_coordinate),
+		p.pickup_note,
 		ST_X(p.destination_coordinate),
 		ST_Y(p.destination_coordinate),
 		p.distance,
@@ -312,6 +336,9 @@ func (repo *RepositoryImpl) FindActiveShareRideByDriver(ctx context.Context, dri
 		sr.finished_at,
 		p.id,
 		p.status,
+		ST_X(p.pickup_coordinate),
+		ST_Y(p.pickup_coordinate),
+		p.pickup_note,
 		ST_X(p.destination_coordinate),
 		ST_Y(p.destination_coordinate),
 		p.distance,
@@ -371,6 +398,9 @@ func (repo *RepositoryImpl) FindActiveShareRideByPassenger(ctx context.Context,
 		sr.finished_at,
 		p.id,
 		p.status,
+		ST_X(p.pickup_coordinate),
+		ST_Y(p.pickup_coordinate),
+		p.pickup_note,
 		ST_X(p.destination_coordinate),
 		ST_Y(p.destination_coordinate),
 		p.distance,
@@ -387,11 +417,14 @@ func (repo *RepositoryImpl) FindActiveShareRideByPassenger(ctx context.Context,
 		d.name,
 		d.email,
 		d.phone_number,
+		d.rating_average,
+		d.rating_count,
 		u.id,
 		u.name,
 		u.email,
 		u.phone_number,
 		v.id,
+		v.type,
 		v.manufacture,
 		v.model,
 		v.license_plate,
@@ -424,6 +457,156 @@ func (repo *RepositoryImpl) FindActiveShareRideByPassenger(ctx context.Context,
 	return
 }
 
+// CountActivePassengers counts the passengers that still hold a seat (waiting up to on the way) on the share ride.
+func (repo *RepositoryImpl) CountActivePassengers(ctx context.Context, tx *sql.Tx, shareRideId int64) (count int, err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `SELECT COUNT(*) FROM passengers WHERE share_ride_id = ? AND status IN (1, 2, 3, 4)`
+
+	if err = cmd.QueryRowContext(ctx, query, shareRideId).Scan(&count); err != nil {
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return
+	}
+
+	return
+}
+
+// FindRoutePassengers returns the passengers that still hold a seat on the given share rides with their pickup and destination.
+func (repo *RepositoryImpl) FindRoutePassengers(ctx context.Context, shareRideIds []int64) (passengers []entity.Passengers, err error) {
+	var cmd SqlCommand = repo.DB
+
+	if len(shareRideIds) < 1 {
+		err = exception.ErrNotFound
+		return
+	}
+
+	args := make([]interface{}, 0, len(shareRideIds))
+	for _, shareRideId := range shareRideIds {
+		args = append(args, shareRideId)
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+		p.id,
+		p.share_ride_id,
+		p.status,
+		ST_X(p.pickup_coordinate),
+		ST_Y(p.pickup_coordinate),
+		ST_X(p.destination_coordinate),
+		ST_Y(p.destination_coordinate)
+	FROM
+		passengers p
+	WHERE
+		p.share_ride_id IN (%s) AND p.status IN (1, 2, 3, 4)
+	ORDER BY
+		p.id ASC
+	`, placeholders(len(shareRideIds)))
+
+	var rows *sql.Rows
+	if rows, err = cmd.QueryContext(ctx, query, args...); err != nil {
+		repo.Logger.Error(err.Error())
+		return
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+	}()
+
+	for rows.Next() {
+		var (
+			passenger                       entity.Passengers
+			pickupLatitude, pickupLongitude sql.NullFloat64
+		)
+
+		err = rows.Scan(&passenger.ID, &passenger.ShareRideId, &passenger.Status, &pickupLatitude, &pickupLongitude, &passenger.DestinationCoordinate.Latitude, &passenger.DestinationCoordinate.Longitude)
+		if err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+
+		passenger.PickupCoordinate = entity.Coordinate{Latitude: pickupLatitude.Float64, Longitude: pickupLongitude.Float64}
+		passengers = append(passengers, passenger)
+	}
+
+	if passengers == nil {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
+
+// RefreshIsFull derives is_full from the seats taken and the capacity of the driver's in-use vehicle.
+func (repo *RepositoryImpl) RefreshIsFull(ctx context.Context, tx *sql.Tx, shareRideId int64) (err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`
+	UPDATE
+		%s sr
+		left join vehicles v on v.user_id = sr.driver_id and v.in_use = 1
+	SET
+		sr.is_full = (SELECT COUNT(*) FROM passengers p WHERE p.share_ride_id = sr.id AND p.status IN (1, 2, 3, 4)) >= COALESCE(v.capacity, 1)
+	WHERE
+		sr.id = ?
+	`, repo.TableName)
+
+	if _, err = Exec(ctx, cmd, command, shareRideId); err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return
+	}
+
+	return
+}
+
+// ReserveSeat locks the share ride row until tx ends, so bookings on the same ride are serialised, and returns
+// exception.ErrConflict when the ride stopped looking for passengers or has no free seat left.
+// It must run in the tx that inserts the passenger.
+func (repo *RepositoryImpl) ReserveSeat(ctx context.Context, tx *sql.Tx, shareRideId int64) (err error) {
+	query := fmt.Sprintf(`
+	SELECT
+		sr.driver_status,
+		(SELECT COUNT(*) FROM passengers p WHERE p.share_ride_id = sr.id AND p.status IN (1, 2, 3, 4)) AS seats_taken,
+		COALESCE(v.capacity, 1)
+	FROM
+		%s sr
+		left join vehicles v on v.user_id = sr.driver_id and v.in_use = 1
+	WHERE
+		sr.id = ?
+	FOR UPDATE
+	`, repo.TableName)
+
+	var (
+		driverStatus int8
+		seatsTaken   int
+		capacity     int
+	)
+
+	if err = tx.QueryRowContext(ctx, query, shareRideId).Scan(&driverStatus, &seatsTaken, &capacity); err != nil {
+		if err == sql.ErrNoRows {
+			return exception.ErrNotFound
+		}
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return
+	}
+
+	if driverStatus != 1 || seatsTaken >= capacity {
+		return exception.ErrConflict
+	}
+
+	return
+}
+
 // ==================================================================================================================== //
 type SqlCommand interface {
 	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
@@ -469,9 +652,9 @@ func (repo *RepositoryImpl) QueryActiveOrder(ctx context.Context, cmd SqlCommand
 	}()
 
 	var shareRide entity.ShareRide
-	var passenger entity.Passengers
-	var payment entity.Payment
-	var paymentDetails entity.PaymentDetails
+
+	passengersById := make(map[int64]*entity.Passengers)
+	paymentsById := make(map[int64]*entity.Payment)
 
 	for rows.Next() {
 
@@ -482,6 +665,9 @@ func (repo *RepositoryImpl) QueryActiveOrder(ctx context.Context, cmd SqlCommand
 		var (
 			passengerId                             sql.NullInt64
 			passengerStatus                         sql.NullInt16
+			passengerPickupCoordinateLatitude       sql.NullFloat64
+			passengerPickupCoordinateLongitude      sql.NullFloat64
+			passengerPickupNote                     sql.NullString
 			passengerDestinationCoordinateLatitue   sql.NullFloat64
 			passengerDestinationCoordinateLongitude sql.NullFloat64
 			passengerDistance                       sql.NullFloat64
@@ -510,21 +696,24 @@ func (repo *RepositoryImpl) QueryActiveOrder(ctx context.Context, cmd SqlCommand
 		)
 
 		var (
-			driverId          sql.NullInt64
-			driverName        sql.NullString
-			driverEmail       sql.NullString
-			driverPhoneNumber sql.NullString
+			driverId            sql.NullInt64
+			driverName          sql.NullString
+			driverEmail         sql.NullString
+			driverPhoneNumber   sql.NullString
+			driverRatingAverage sql.NullFloat64
+			driverRatingCount   sql.NullInt64
 		)
 
 		var (
 			vehicleId           sql.NullInt64
+			vehicleType         sql.NullString
 			vehicleManufacture  sql.NullString
 			vehicleModel        sql.NullString
 			vehicleLicensePlate sql.NullString
 			vehicleInUse        sql.NullBool
 		)
 
-		err = rows.Scan(&shareRide.ID, &shareRide.DriverId, &shareRide.IsFull, &shareRideDriverStatus, &shareRide.CreatedAt, &shareRide.FinishedAt, &passengerId, &passengerStatus, &passengerDestinationCoordinateLatitue, &passengerDestinationCoordinateLongitude, &passengerDistance, &passengerCreatedAt, &passengerDroppedAt, &paymentId, &paymentStatus, &paymentTotalAmount, &paymentCreatedAt, &paymentDetailId, &paymentDetailPaymentMethod, &paymentDetailAmount, &driverId, &driverName, &driverEmail, &driverPhoneNumber, &userId, &userName, &userEmail, &userPhoneNumber, &vehicleId, &vehicleManufacture, &vehicleModel, &vehicleLicensePlate, &vehicleInUse)
+		err = rows.Scan(&shareRide.ID, &shareRide.DriverId, &shareRide.IsFull, &shareRideDriverStatus, &shareRide.CreatedAt, &shareRide.FinishedAt, &passengerId, &passengerStatus, &passengerPickupCoordinateLatitude, &passengerPickupCoordinateLongitude, &passengerPickupNote, &passengerDestinationCoordinateLatitue, &passengerDestinationCoordinateLongitude, &passengerDistance, &passengerCreatedAt, &passengerDroppedAt, &paymentId, &paymentStatus, &paymentTotalAmount, &paymentCreatedAt, &paymentDetailId, &paymentDetailPaymentMethod, &paymentDetailAmount, &driverId, &driverName, &driverEmail, &driverPhoneNumber, &driverRatingAverage, &driverRatingCount, &userId, &userName, &userEmail, &userPhoneNumber, &vehicleId, &vehicleType, &vehicleManufacture, &vehicleModel, &vehicleLicensePlate, &vehicleInUse)
 		if err != nil {
 			repo.Logger.Error(err.Error())
 			return
@@ -534,10 +723,19 @@ func (repo *RepositoryImpl) QueryActiveOrder(ctx context.Context, cmd SqlCommand
 			shareRide.DriverStatus = shareRideDriverStatus.Int16
 		}
 
+		var passenger *entity.Passengers
 		if passengerId.Valid {
-			passenger = entity.Passengers{
+			passenger = passengersById[passengerId.Int64]
+		}
+
+		if passengerId.Valid && passenger == nil {
+			passenger = &entity.Passengers{
 				ID:     passengerId.Int64,
 				Status: passengerStatus.Int16,
+				PickupCoordinate: entity.Coordinate{
+					Latitude:  passengerPickupCoordinateLatitude.Float64,
+					Longitude: passengerPickupCoordinateLongitude.Float64,
+				},
 				DestinationCoordinate: entity.Coordinate{
 					Latitude:  passengerDestinationCoordinateLatitue.Float64,
 					Longitude: passengerDestinationCoordinateLongitude.Float64,
@@ -551,22 +749,34 @@ func (repo *RepositoryImpl) QueryActiveOrder(ctx context.Context, cmd SqlCommand
 					PhoneNumber: userPhoneNumber.String,
 				},
 			}
-			shareRide.Passengers = append(shareRide.Passengers, &passenger)
+
+			if passengerPickupNote.Valid {
+				passenger.PickupNote = &passengerPickupNote.String
+			}
+
+			passengersById[passengerId.Int64] = passenger
+			shareRide.Passengers = append(shareRide.Passengers, passenger)
 		}
 
+		var payment *entity.Payment
 		if paymentId.Valid {
-			payment = entity.Payment{
+			payment = paymentsById[paymentId.Int64]
+		}
+
+		if paymentId.Valid && payment == nil && passenger != nil {
+			payment = &entity.Payment{
 				ID:          paymentId.Int64,
 				Status:      paymentStatus.String,
 				TotalAmount: paymentTotalAmount.Int64,
 				CreatedAt:   paymentCreatedAt.Time,
 			}
 
-			passenger.Payment = append(passenger.Payment, &payment)
+			paymentsById[paymentId.Int64] = payment
+			passenger.Payment = append(passenger.Payment, payment)
 		}
 
-		if paymentDetailId.Valid {
-			paymentDetails = entity.PaymentDetails{
+		if paymentDetailId.Valid && payment != nil {
+			paymentDetails := entity.PaymentDetails{
 				ID:            paymentDetailId.Int64,
 				PaymentMethod: paymentDetailPaymentMethod.String,
 				Amount:        paymentDetailAmount.Int64,
@@ -576,16 +786,19 @@ func (repo *RepositoryImpl) QueryActiveOrder(ctx context.Context, cmd SqlCommand
 
 		if driverId.Valid {
 			shareRide.Driver = &entity.DriverInShareRide{
-				ID:          driverId.Int64,
-				Name:        driverName.String,
-				Email:       driverEmail.String,
-				PhoneNumber: driverPhoneNumber.String,
+				ID:            driverId.Int64,
+				Name:          driverName.String,
+				Email:         driverEmail.String,
+				PhoneNumber:   driverPhoneNumber.String,
+				RatingAverage: driverRatingAverage.Float64,
+				RatingCount:   driverRatingCount.Int64,
 			}
 		}
 
 		if vehicleId.Valid {
 			shareRide.Driver.Vehicle = &entity.DriverVehicleInShareRide{
 				ID:           vehicleId.Int64,
+				Type:         vehicleType.String,
 				Manufacture:  vehicleManufacture.String,
 				Model:        vehicleModel.String,
 				LicensePlate: vehicleLicensePlate.String,
@@ -620,9 +833,9 @@ func (repo *RepositoryImpl) Query(ctx context.Context, cmd SqlCommand, query str
 	}()
 
 	var shareRide entity.ShareRide
-	var passenger entity.Passengers
-	var payment entity.Payment
-	var paymentDetails entity.PaymentDetails
+
+	passengersById := make(map[int64]*entity.Passengers)
+	paymentsById := make(map[int64]*entity.Payment)
 
 	for rows.Next() {
 
@@ -633,6 +846,9 @@ func (repo *RepositoryImpl) Query(ctx context.Context, cmd SqlCommand, query str
 		var (
 			passengerId                             sql.NullInt64
 			passengerStatus                         sql.NullInt16
+			passengerPickupCoordinateLatitude       sql.NullFloat64
+			passengerPickupCoordinateLongitude      sql.NullFloat64
+			passengerPickupNote                     sql.NullString
 			passengerDestinationCoordinateLatitue   sql.NullFloat64
 			passengerDestinationCoordinateLongitude sql.NullFloat64
 			passengerDistance                       sql.NullFloat64
@@ -667,7 +883,7 @@ func (repo *RepositoryImpl) Query(ctx context.Context, cmd SqlCommand, query str
 			driverPhoneNumber sql.NullString
 		)
 
-		err = rows.Scan(&shareRide.ID, &shareRide.DriverId, &shareRide.IsFull, &shareRideDriverStatus, &shareRide.CreatedAt, &shareRide.FinishedAt, &passengerId, &passengerStatus, &passengerDestinationCoordinateLatitue, &passengerDestinationCoordinateLongitude, &passengerDistance, &passengerCreatedAt, &passengerDroppedAt, &paymentId, &paymentStatus, &paymentTotalAmount, &paymentCreatedAt, &paymentDetailId, &paymentDetailPaymentMethod, &paymentDetailAmount, &driverId, &driverName, &driverEmail, &driverPhoneNumber, &userId, &userName, &userEmail, &userPhoneNumber)
+		err = rows.Scan(&shareRide.ID, &shareRide.DriverId, &shareRide.IsFull, &shareRideDriverStatus, &shareRide.CreatedAt, &shareRide.FinishedAt, &passengerId, &passengerStatus, &passengerPickupCoordinateLatitude, &passengerPickupCoordinateLongitude, &passengerPickupNote, &passengerDestinationCoordinateLatitue, &passengerDestinationCoordinateLongitude, &passengerDistance, &passengerCreatedAt, &passengerDroppedAt, &paymentId, &paymentStatus, &paymentTotalAmount, &paymentCreatedAt, &paymentDetailId, &paymentDetailPaymentMethod, &paymentDetailAmount, &driverId, &driverName, &driverEmail, &driverPhoneNumber, &userId, &userName, &userEmail, &userPhoneNumber)
 		if err != nil {
 			repo.Logger.Error(err.Error())
 			return
@@ -677,10 +893,19 @@ func (repo *RepositoryImpl) Query(ctx context.Context, cmd SqlCommand, query str
 			shareRide.DriverStatus = shareRideDriverStatus.Int16
 		}
 
+		var passenger *entity.Passengers
 		if passengerId.Valid {
-			passenger = entity.Passengers{
+			passenger = passengersById[passengerId.Int64]
+		}
+
+		if passengerId.Valid && passenger == nil {
+			passenger = &entity.Passengers{
 				ID:     passengerId.Int64,
 				Status: passengerStatus.Int16,
+				PickupCoordinate: entity.Coordinate{
+					Latitude:  passengerPickupCoordinateLatitude.Float64,
+					Longitude: passengerPickupCoordinateLongitude.Float64,
+				},
 				DestinationCoordinate: entity.Coordinate{
 					Latitude:  passengerDestinationCoordinateLatitue.Float64,
 					Longitude: passengerDestinationCoordinateLongitude.Float64,
@@ -694,22 +919,34 @@ func (repo *RepositoryImpl) Query(ctx context.Context, cmd SqlCommand, query str
 					PhoneNumber: userPhoneNumber.String,
 				},
 			}
-			shareRide.Passengers = append(shareRide.Passengers, &passenger)
+
+			if passengerPickupNote.Valid {
+				passenger.PickupNote = &passengerPickupNote.String
+			}
+
+			passengersById[passengerId.Int64] = passenger
+			shareRide.Passengers = append(shareRide.Passengers, passenger)
 		}
 
+		var payment *entity.Payment
 		if paymentId.Valid {
-			payment = entity.Payment{
+			payment = paymentsById[paymentId.Int64]
+		}
+
+		if paymentId.Valid && payment == nil && passenger != nil {
+			payment = &entity.Payment{
 				ID:          paymentId.Int64,
 				Status:      paymentStatus.String,
 				TotalAmount: paymentTotalAmount.Int64,
 				CreatedAt:   paymentCreatedAt.Time,
 			}
 
-			passenger.Payment = append(passenger.Payment, &payment)
+			paymentsById[paymentId.Int64] = payment
+			passenger.Payment = append(passenger.Payment, payment)
 		}
 
-		if paymentDetailId.Valid {
-			paymentDetails = entity.PaymentDetails{
+		if paymentDetailId.Valid && payment != nil {
+			paymentDetails := entity.PaymentDetails{
 				ID:            paymentDetailId.Int64,
 				PaymentMethod: paymentDetailPaymentMethod.String,
 				Amount:        paymentDetailAmount.Int64,
@@ -736,3 +973,156 @@ func (repo *RepositoryImpl) Query(ctx context.Context, cmd SqlCommand, query str
 
 	return
 }
+
+func (repo *RepositoryImpl) QueryNearby(ctx context.Context, cmd SqlCommand, query string, args ...interface{}) (shareRides []entity.ShareRide, err error) {
+
+	var rows *sql.Rows
+	if rows, err = cmd.QueryContext(ctx, query, args...); err != nil {
+		repo.Logger.Error(err.Error())
+		return
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+	}()
+
+	for rows.Next() {
+		var shareRide entity.ShareRide
+		var driver entity.DriverInShareRide
+
+		var (
+			vehicleId           sql.NullInt64
+			vehicleType         sql.NullString
+			vehicleManufacture  sql.NullString
+			vehicleModel        sql.NullString
+			vehicleLicensePlate sql.NullString
+			vehicleInUse        sql.NullBool
+			vehicleCapacity     sql.NullInt64
+			seatsTaken          int
+		)
+
+		var driverLatitude, driverLongitude float64
+
+		err = rows.Scan(&shareRide.ID, &shareRide.DriverId, &shareRide.IsFull, &shareRide.DriverStatus, &shareRide.CreatedAt, &shareRide.FinishedAt, &driver.ID, &driver.Name, &driver.Email, &driver.PhoneNumber, &driver.RatingAverage, &driver.RatingCount, &driverLatitude, &driverLongitude, &vehicleId, &vehicleType, &vehicleManufacture, &vehicleModel, &vehicleLicensePlate, &vehicleInUse, &vehicleCapacity, &seatsTaken, &shareRide.DriverDistance)
+		if err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+
+		if vehicleId.Valid {
+			driver.Vehicle = &entity.DriverVehicleInShareRide{
+				ID:           vehicleId.Int64,
+				Type:         vehicleType.String,
+				Manufacture:  vehicleManufacture.String,
+				Model:        vehicleModel.String,
+				LicensePlate: vehicleLicensePlate.String,
+				InUse:        vehicleInUse.Bool,
+				Capacity:     int(vehicleCapacity.Int64),
+			}
+		}
+
+		capacity := 1
+		if vehicleCapacity.Valid && vehicleCapacity.Int64 > 0 {
+			capacity = int(vehicleCapacity.Int64)
+		}
+		shareRide.AvailableSeats = capacity - seatsTaken
+
+		driver.Coordinate = &entity.Coordinate{Latitude: driverLatitude, Longitude: driverLongitude}
+		shareRide.Driver = &driver
+		shareRides = append(shareRides, shareRide)
+	}
+
+	if shareRides == nil {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
+
+// FindReceipt collects what the receipt of a dropped off passenger shows, the vehicle is the one the driver has in use.
+func (repo *RepositoryImpl) FindReceipt(ctx context.Context, shareRideId int64, passengerId int64) (receipt *entity.Receipt, err error) {
+	var cmd SqlCommand = repo.DB
+
+	query := fmt.Sprintf(`
+	SELECT
+		p.id,
+		p.share_ride_id,
+		p.created_at,
+		p.picked_up_at,
+		p.dropped_at,
+		p.distance,
+		p.travelled_distance,
+		u.id,
+		u.name,
+		u.email,
+		d.id,
+		d.name,
+		COALESCE(v.type, ''),
+		COALESCE(CONCAT(v.manufacture, ' ', v.model), ''),
+		COALESCE(v.license_plate, ''),
+		pymt.base_fare,
+		pymt.distance_fare,
+		pymt.total_amount,
+		pymt.status,
+		pd.payment_method,
+		pd.amount
+	FROM
+		passengers p
+		JOIN %s sr ON sr.id = p.share_ride_id
+		JOIN users u ON u.id = p.user_id
+		JOIN users d ON d.id = sr.driver_id
+		JOIN payment pymt ON pymt.passenger_id = p.id
+		LEFT JOIN payment_detail pd ON pd.payment_id = pymt.id
+		LEFT JOIN vehicles v ON v.user_id = d.id AND v.in_use = 1
+	WHERE
+		p.share_ride_id = ? AND p.id = ? AND p.status = 5
+	ORDER BY
+		pd.id ASC
+	`, repo.TableName)
+
+	var rows *sql.Rows
+	if rows, err = cmd.QueryContext(ctx, query, shareRideId, passengerId); err != nil {
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+	}()
+
+	for rows.Next() {
+		var (
+			row           entity.Receipt
+			paymentMethod sql.NullString
+			amount        sql.NullInt64
+		)
+
+		err = rows.Scan(&row.PassengerId, &row.ShareRideId, &row.BookedAt, &row.PickedUpAt, &row.DroppedAt, &row.Distance, &row.TravelledDistance, &row.PassengerUserId, &row.PassengerName, &row.PassengerEmail, &row.DriverId, &row.DriverName, &row.VehicleType, &row.VehicleModel, &row.LicensePlate, &row.BaseFare, &row.DistanceFare, &row.TotalAmount, &row.PaymentStatus, &paymentMethod, &amount)
+		if err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+
+		if receipt == nil {
+			receipt = &row
+		}
+
+		if paymentMethod.Valid {
+			receipt.PaymentDetails = append(receipt.PaymentDetails, entity.PaymentDetails{PaymentMethod: paymentMethod.String, Amount: amount.Int64})
+		}
+	}
+
+	if receipt == nil {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
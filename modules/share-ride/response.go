@@ -0,0 +1,42 @@
+package shareride
+
+import (
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/fare"
+	"github.com/Difaal21/nebeng-dong/modules/promotions"
+)
+
+type DriverMatch struct {
+	ShareRideID    int64                     `json:"shareRideId"`
+	PassengerID    int64                     `json:"passengerId"`
+	OfferID        int64                     `json:"offerId"`
+	OfferExpiresAt time.Time                 `json:"offerExpiresAt"`
+	Driver         *entity.DriverInShareRide `json:"driver"`
+	DriverDistance float64                   `json:"driverDistance"`
+	Fare           *fare.Fare                `json:"fare"`
+	Discount       *promotions.Discount      `json:"discount,omitempty"`
+	PayableAmount  int64                     `json:"payableAmount"`
+	Detour         *Detour                   `json:"detour"`
+}
+
+type Cancellation struct {
+	PassengerID     int64  `json:"passengerId"`
+	PaymentStatus   string `json:"paymentStatus"`
+	CancellationFee int64  `json:"cancellationFee"`
+}
+
+type FareQuote struct {
+	Distance      float64              `json:"distance"`
+	Duration      int64                `json:"duration"`
+	Fare          *fare.Fare           `json:"fare"`
+	Discount      *promotions.Discount `json:"discount,omitempty"`
+	PayableAmount int64                `json:"payableAmount"`
+	Token         QuoteToken           `json:"token"`
+}
+
+type QuoteToken struct {
+	Value     string `json:"value"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
@@ -0,0 +1,137 @@
+package shareride
+
+import (
+	"context"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/helpers/geo"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/responses"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultTraceSampleInterval = 10 * time.Second
+
+func traceSampleInterval() time.Duration {
+	seconds, err := strconv.ParseInt(os.Getenv("TRACE_SAMPLE_SECONDS"), 10, 64)
+	if err != nil || seconds < 0 {
+		return defaultTraceSampleInterval
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+type Trace struct {
+	ShareRideID int64                   `json:"shareRideId"`
+	Distance    float64                 `json:"distance"` // km
+	Points      []entity.ShareRideTrace `json:"points"`
+}
+
+func NewTrace(shareRideId int64, points []entity.ShareRideTrace) *Trace {
+	if points == nil {
+		points = []entity.ShareRideTrace{}
+	}
+
+	return &Trace{
+		ShareRideID: shareRideId,
+		Distance:    TravelledDistance(points),
+		Points:      points,
+	}
+}
+
+// TravelledDistance sums the distance in km between consecutive trace points.
+func TravelledDistance(points []entity.ShareRideTrace) float64 {
+	var distance float64
+	for i := 1; i < len(points); i++ {
+		distance += geo.Distance(points[i-1].Coordinate, points[i].Coordinate)
+	}
+	return math.Round(distance*100) / 100
+}
+
+// recordTrace keeps a location update in the trip trace, at most once per sample interval.
+func (u *UsecaseImpl) recordTrace(ctx context.Context, update *LocationUpdate) (err error) {
+	latest, err := u.TraceRepository.FindLatest(ctx, update.ShareRideID)
+	if err != nil && err != exception.ErrNotFound {
+		return
+	}
+
+	if latest != nil && update.RecordedAt.Sub(latest.RecordedAt) < traceSampleInterval() {
+		return nil
+	}
+
+	trace := &entity.ShareRideTrace{
+		ShareRideId: update.ShareRideID,
+		DriverId:    update.DriverID,
+		Coordinate:  update.Coordinate,
+		RecordedAt:  update.RecordedAt,
+	}
+
+	_, err = u.TraceRepository.Insert(ctx, nil, trace)
+	return
+}
+
+// passengerTravelledDistance measures the trace between the passenger's pickup (or booking when it was never recorded) and now.
+func (u *UsecaseImpl) passengerTravelledDistance(ctx context.Context, passenger *entity.Passengers, droppedAt *time.Time) (distance float64, err error) {
+	from := &passenger.CreatedAt
+	if passenger.PickedUpAt != nil {
+		from = passenger.PickedUpAt
+	}
+
+	points, err := u.TraceRepository.FindByShareRide(ctx, passenger.ShareRideId, from, droppedAt)
+	if err != nil && err != exception.ErrNotFound {
+		return
+	}
+
+	return TravelledDistance(points), nil
+}
+
+// GetTrace is available to the driver of the share ride and to its passengers, who only see the part they rode.
+func (u *UsecaseImpl) GetTrace(ctx context.Context, shareRideId int64) responses.Responses {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	shareRide, err := u.Repository.FindOne(ctx, "id", shareRideId)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithField("shareRideId", shareRideId).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if shareRide == nil {
+		return httpResponse.NotFound("").NewResponses(nil, "share ride not found")
+	}
+
+	var from, to *time.Time
+
+	if shareRide.DriverId != requester.ID {
+		passenger, err := u.PassengerRepository.FindLatestByShareRideAndUser(ctx, shareRide.ID, requester.ID)
+		if err != nil && err != exception.ErrNotFound {
+			u.Logger.WithFields(logrus.Fields{"shareRideId": shareRideId, "requester": requester}).Error(err.Error())
+			return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+		}
+
+		if passenger == nil {
+			return httpResponse.Forbidden("NOT_ELIGIBLE").NewResponses(nil, "not a passenger of this share ride")
+		}
+
+		from, to = &passenger.CreatedAt, passenger.DroppedAt
+		if passenger.PickedUpAt != nil {
+			from = passenger.PickedUpAt
+		}
+	}
+
+	points, err := u.TraceRepository.FindByShareRide(ctx, shareRide.ID, from, to)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithField("shareRideId", shareRideId).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Ok("").NewResponses(NewTrace(shareRide.ID, points), "")
+}
@@ -0,0 +1,162 @@
+package shareride
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/sirupsen/logrus"
+)
+
+type TraceRepository interface {
+	Insert(ctx context.Context, tx *sql.Tx, trace *entity.ShareRideTrace) (id int64, err error)
+	FindLatest(ctx context.Context, shareRideId int64) (trace *entity.ShareRideTrace, err error)
+	FindByShareRide(ctx context.Context, shareRideId int64, from, to *time.Time) (traces []entity.ShareRideTrace, err error)
+}
+
+type TraceRepositoryImpl struct {
+	DB        *sql.DB
+	Logger    *logrus.Logger
+	TableName string
+}
+
+func NewTraceRepositoryImpl(db *sql.DB, logger *logrus.Logger) TraceRepository {
+	return &TraceRepositoryImpl{
+		DB:        db,
+		Logger:    logger,
+		TableName: "share_ride_traces",
+	}
+}
+
+func (repo *TraceRepositoryImpl) Insert(ctx context.Context, tx *sql.Tx, trace *entity.ShareRideTrace) (id int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`
+	INSERT INTO %s
+	SET
+		id = ?,
+		share_ride_id = ?,
+		driver_id = ?,
+		coordinate = POINT(?, ?),
+		recorded_at = ?
+	`, repo.TableName)
+
+	result, err := Exec(ctx, cmd, command, trace.ID, trace.ShareRideId, trace.DriverId, trace.Coordinate.Latitude, trace.Coordinate.Longitude, trace.RecordedAt)
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return
+	}
+
+	if id, err = result.LastInsertId(); err != nil {
+		return
+	}
+	return
+}
+
+func (repo *TraceRepositoryImpl) FindLatest(ctx context.Context, shareRideId int64) (trace *entity.ShareRideTrace, err error) {
+	var cmd SqlCommand = repo.DB
+
+	query := fmt.Sprintf(`
+	SELECT
+		t.id,
+		t.share_ride_id,
+		t.driver_id,
+		ST_X(t.coordinate),
+		ST_Y(t.coordinate),
+		t.recorded_at
+	FROM
+		%s t
+	WHERE
+		t.share_ride_id = ?
+	ORDER BY
+		t.recorded_at DESC
+	LIMIT 1
+	`, repo.TableName)
+
+	traces, err := repo.Query(ctx, cmd, query, shareRideId)
+	if err != nil {
+		return
+	}
+
+	trace = &traces[0]
+
+	return
+}
+
+// FindByShareRide returns the trace of a share ride in recording order, optionally limited to the [from, to] window.
+func (repo *TraceRepositoryImpl) FindByShareRide(ctx context.Context, shareRideId int64, from, to *time.Time) (traces []entity.ShareRideTrace, err error) {
+	var cmd SqlCommand = repo.DB
+
+	args := []interface{}{shareRideId}
+
+	var window string
+	if from != nil {
+		window += " AND t.recorded_at >= ?"
+		args = append(args, from)
+	}
+
+	if to != nil {
+		window += " AND t.recorded_at <= ?"
+		args = append(args, to)
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+		t.id,
+		t.share_ride_id,
+		t.driver_id,
+		ST_X(t.coordinate),
+		ST_Y(t.coordinate),
+		t.recorded_at
+	FROM
+		%s t
+	WHERE
+		t.share_ride_id = ?%s
+	ORDER BY
+		t.recorded_at ASC, t.id ASC
+	`, repo.TableName, window)
+
+	return repo.Query(ctx, cmd, query, args...)
+}
+
+func (repo *TraceRepositoryImpl) Query(ctx context.Context, cmd SqlCommand, query string, args ...interface{}) (traces []entity.ShareRideTrace, err error) {
+
+	var rows *sql.Rows
+	if rows, err = cmd.QueryContext(ctx, query, args...); err != nil {
+		repo.Logger.Error(err.Error())
+		return
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+	}()
+
+	for rows.Next() {
+		var trace entity.ShareRideTrace
+
+		err = rows.Scan(&trace.ID, &trace.ShareRideId, &trace.DriverId, &trace.Coordinate.Latitude, &trace.Coordinate.Longitude, &trace.RecordedAt)
+		if err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+
+		traces = append(traces, trace)
+	}
+
+	if traces == nil {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
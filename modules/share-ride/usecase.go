@@ -3,50 +3,97 @@ package shareride
 import (
 	"context"
 	"database/sql"
-	"math"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/Difaal21/nebeng-dong/entity"
 	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/fare"
 	"github.com/Difaal21/nebeng-dong/helpers/date"
 	"github.com/Difaal21/nebeng-dong/jwt"
+	"github.com/Difaal21/nebeng-dong/mailer"
 	"github.com/Difaal21/nebeng-dong/model"
 	"github.com/Difaal21/nebeng-dong/modules/passengers"
 	"github.com/Difaal21/nebeng-dong/modules/payment"
+	"github.com/Difaal21/nebeng-dong/modules/promotions"
 	"github.com/Difaal21/nebeng-dong/modules/users"
+	"github.com/Difaal21/nebeng-dong/modules/wallet"
+	"github.com/Difaal21/nebeng-dong/pubsub"
 	"github.com/Difaal21/nebeng-dong/responses"
+	jwtv5 "github.com/golang-jwt/jwt/v5"
 	"github.com/sirupsen/logrus"
 )
 
 type Usecase interface {
 	FindPassenger(ctx context.Context) responses.Responses
 	FinishFindPassenger(ctx context.Context, shareRideId int64) responses.Responses
+	QuoteFare(ctx context.Context, payload *model.FareQuote) responses.Responses
 	FindDriver(ctx context.Context, payload *model.FindDriver) responses.Responses
 	UpdatePassengerStatusOnShareRide(ctx context.Context, payload *model.UpdatePassengerStatus) responses.Responses
 	GetShareRideByDriver(ctx context.Context) responses.Responses
 	GetShareRideByPassanger(ctx context.Context) responses.Responses
+	CancelBooking(ctx context.Context, payload *model.CancelBooking) responses.Responses
+
+	GetPendingOffers(ctx context.Context) responses.Responses
+	AcceptOffer(ctx context.Context, offerId int64) responses.Responses
+	DeclineOffer(ctx context.Context, offerId int64) responses.Responses
+	ExpireOffers(ctx context.Context) (err error)
+
+	PublishLocation(ctx context.Context, shareRideId int64, payload *model.Coordinate) responses.Responses
+	SubscribeLocation(ctx context.Context, shareRideId int64) (subscription pubsub.Subscription, resp responses.Responses)
+	GetTrace(ctx context.Context, shareRideId int64) responses.Responses
+
+	GetReceipt(ctx context.Context, shareRideId int64, passengerId int64) responses.Responses
+	ReceiptPDF(ctx context.Context, shareRideId int64, passengerId int64) (document []byte, filename string, resp responses.Responses)
+	EmailReceipt(ctx context.Context, shareRideId int64, passengerId int64) responses.Responses
 }
 
+const (
+	defaultDriverSearchRadius    = 5.0 // km
+	driverCandidateLimit         = 10
+	defaultLowRatingStars        = 3.5
+	defaultLowRatingMinimumCount = 5
+	defaultVehicleType           = "motorcycle"
+	defaultQuoteTTL              = 5 * time.Minute
+	quoteAudience                = "share-ride-quote"
+)
+
 type UsecaseImpl struct {
 	Repository              Repository
+	OfferRepository         OfferRepository
+	TraceRepository         TraceRepository
 	Logger                  *logrus.Logger
 	JSONWebToken            jwt.JSONWebToken
 	PassengerRepository     passengers.Repository
 	PaymentRepository       payment.Repository
 	PaymentDetailRepository payment.PaymentDetailRepository
 	UserRepository          users.Repository
+	FareEngine              fare.Engine
+	RouteEstimator          fare.RouteEstimator
+	Broker                  pubsub.Broker
+	Ledger                  wallet.Ledger
+	Promotions              promotions.Service
+	Mailer                  mailer.Mailer
 }
 
-func NewUsecaseImpl(repo Repository, logger *logrus.Logger, jwt jwt.JSONWebToken, passengerRepository passengers.Repository, paymentRepository payment.Repository, paymentDetailRepo payment.PaymentDetailRepository, userRepository users.Repository) Usecase {
+func NewUsecaseImpl(repo Repository, offerRepository OfferRepository, traceRepository TraceRepository, logger *logrus.Logger, jwt jwt.JSONWebToken, passengerRepository passengers.Repository, paymentRepository payment.Repository, paymentDetailRepo payment.PaymentDetailRepository, userRepository users.Repository, fareEngine fare.Engine, routeEstimator fare.RouteEstimator, broker pubsub.Broker, ledger wallet.Ledger, promotionService promotions.Service, mailer mailer.Mailer) Usecase {
 	return &UsecaseImpl{
 		Repository:              repo,
+		OfferRepository:         offerRepository,
+		TraceRepository:         traceRepository,
 		Logger:                  logger,
 		JSONWebToken:            jwt,
 		PassengerRepository:     passengerRepository,
 		PaymentRepository:       paymentRepository,
 		PaymentDetailRepository: paymentDetailRepo,
 		UserRepository:          userRepository,
+		FareEngine:              fareEngine,
+		RouteEstimator:          routeEstimator,
+		Broker:                  broker,
+		Ledger:                  ledger,
+		Promotions:              promotionService,
+		Mailer:                  mailer,
 	}
 }
 
@@ -63,6 +110,10 @@ func (u *UsecaseImpl) FindPassenger(ctx context.Context) responses.Responses {
 		return httpResponse.Forbidden("NOT_ELIGIBLE").NewResponses(nil, "invalid role")
 	}
 
+	if resp := u.requireVerifiedEmail(ctx, requester); resp != nil {
+		return resp
+	}
+
 	minimumBalance, _ := strconv.ParseInt(os.Getenv("MINIMUM_BALANCE"), 10, 64)
 	driver, err := u.UserRepository.FindOneById(ctx, requester.ID)
 	if err != nil && err != exception.ErrNotFound {
@@ -152,7 +203,7 @@ func (u *UsecaseImpl) FinishFindPassenger(ctx context.Context, shareRideId int64
 	return httpResponse.Ok("").NewResponses(nil, "")
 }
 
-func (u *UsecaseImpl) FindDriver(ctx context.Context, payload *model.FindDriver) responses.Responses {
+func (u *UsecaseImpl) QuoteFare(ctx context.Context, payload *model.FareQuote) responses.Responses {
 
 	requester, err := model.GetRequester(ctx)
 	if err != nil {
@@ -160,30 +211,177 @@ func (u *UsecaseImpl) FindDriver(ctx context.Context, payload *model.FindDriver)
 		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
 
-	activeDriver, err := u.Repository.FindActiveDriver(ctx, 1)
+	vehicleType := payload.VehicleType
+	if vehicleType == "" {
+		vehicleType = defaultVehicleType
+	}
+
+	pickupCoordinate := entity.Coordinate{
+		Latitude:  payload.PickupCoordinate.Latitude,
+		Longitude: payload.PickupCoordinate.Longitude,
+	}
+
+	destinationCoordinate := entity.Coordinate{
+		Latitude:  payload.DestinationCoordinate.Latitude,
+		Longitude: payload.DestinationCoordinate.Longitude,
+	}
+
+	tripFare, err := u.FareEngine.Calculate(ctx, vehicleType, pickupCoordinate, destinationCoordinate)
+	if err != nil {
+		u.Logger.WithContext(ctx).WithFields(logrus.Fields{"requester": requester, "payload": payload}).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	quoteTTL := defaultQuoteTTL
+	if seconds, err := strconv.ParseInt(os.Getenv("SHARE_RIDE_QUOTE_TTL_SECONDS"), 10, 64); err == nil && seconds > 0 {
+		quoteTTL = time.Duration(seconds) * time.Second
+	}
+
+	expiresAt := time.Now().Add(quoteTTL)
+	claims := &model.QuoteBearer{}
+	claims.UserId = requester.ID
+	claims.PickupCoordinate = payload.PickupCoordinate
+	claims.DestinationCoordinate = payload.DestinationCoordinate
+	claims.Fare = *tripFare
+	claims.Audience = jwtv5.ClaimStrings{quoteAudience}
+	claims.IssuedAt = jwtv5.NewNumericDate(time.Now())
+	claims.ExpiresAt = jwtv5.NewNumericDate(expiresAt)
+
+	tokenString, err := u.JSONWebToken.CreateToken(ctx, claims)
+	if err != nil {
+		u.Logger.WithContext(ctx).WithFields(logrus.Fields{"requester": requester, "payload": payload}).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	discount, resp := u.quotePromo(ctx, payload.PromoCode, requester.ID, tripFare.TotalAmount)
+	if resp != nil {
+		return resp
+	}
+
+	quote := FareQuote{
+		Distance:      tripFare.Distance,
+		Duration:      tripFare.Duration,
+		Fare:          tripFare,
+		Discount:      discount,
+		PayableAmount: tripFare.TotalAmount - discountAmount(discount),
+		Token: QuoteToken{
+			Value:     tokenString,
+			ExpiresAt: expiresAt.Unix(),
+		},
+	}
+
+	return httpResponse.Ok("").NewResponses(quote, "fare quoted")
+}
+
+// verifyQuote returns the fare of a quote token issued to the requester for the same pickup and destination.
+func (u *UsecaseImpl) verifyQuote(ctx context.Context, requester *model.UserBearer, payload *model.FindDriver) (quotedFare *fare.Fare, resp responses.Responses) {
+
+	claims := &model.QuoteBearer{}
+	if err := u.JSONWebToken.VerifyToken(ctx, payload.QuoteToken, claims); err != nil {
+		return nil, httpResponse.BadRequest("INVALID_QUOTE").NewResponses(nil, "quote is invalid or expired")
+	}
+
+	isQuote := false
+	for _, audience := range claims.Audience {
+		if audience == quoteAudience {
+			isQuote = true
+		}
+	}
+
+	if !isQuote {
+		return nil, httpResponse.BadRequest("INVALID_QUOTE").NewResponses(nil, "quote is invalid or expired")
+	}
+
+	if claims.UserId != requester.ID {
+		return nil, httpResponse.Forbidden("INVALID_QUOTE").NewResponses(nil, "quote belongs to another user")
+	}
+
+	if claims.PickupCoordinate != payload.PickupCoordinate || claims.DestinationCoordinate != payload.DestinationCoordinate {
+		return nil, httpResponse.BadRequest("QUOTE_MISMATCH").NewResponses(nil, "pickup or destination differs from the quote")
+	}
+
+	return &claims.Fare, nil
+}
+
+// requireVerifiedEmail blocks unverified accounts from matching when REQUIRE_EMAIL_VERIFICATION is enabled.
+func (u *UsecaseImpl) requireVerifiedEmail(ctx context.Context, requester *model.UserBearer) (resp responses.Responses) {
+	if required, _ := strconv.ParseBool(os.Getenv("REQUIRE_EMAIL_VERIFICATION")); !required {
+		return nil
+	}
+
+	user, err := u.UserRepository.FindOneById(ctx, requester.ID)
 	if err != nil && err != exception.ErrNotFound {
-		u.Logger.WithFields(logrus.Fields{"activeDriver": activeDriver, "requester": requester}).Error(err.Error())
+		u.Logger.WithContext(ctx).WithField("requester", requester).Error(err)
 		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
 
-	if activeDriver == nil {
-		return httpResponse.NotFound("").NewResponses(nil, "driver not found")
+	if user == nil {
+		return httpResponse.NotFound("").NewResponses(nil, "user not found")
 	}
 
-	if activeDriver.DriverId == requester.ID {
-		return httpResponse.Forbidden("").NewResponses(nil, "youre not allowed to ride with youre self")
+	if !user.IsEmailVerified {
+		return httpResponse.Forbidden("EMAIL_NOT_VERIFIED").NewResponses(nil, "verify your email before booking or offering a ride")
 	}
 
-	activePassenger, err := u.PassengerRepository.FindActivePassenger(ctx, activeDriver.ID, requester.ID)
+	return nil
+}
+
+func (u *UsecaseImpl) FindDriver(ctx context.Context, payload *model.FindDriver) responses.Responses {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if resp := u.requireVerifiedEmail(ctx, requester); resp != nil {
+		return resp
+	}
+
+	var quotedFare *fare.Fare
+	if payload.QuoteToken != "" {
+		var resp responses.Responses
+		if quotedFare, resp = u.verifyQuote(ctx, requester, payload); resp != nil {
+			return resp
+		}
+	}
+
+	activeShareRide, err := u.Repository.FindActiveShareRideByPassenger(ctx, requester.ID)
 	if err != nil && err != exception.ErrNotFound {
-		u.Logger.WithFields(logrus.Fields{"activePassenger": activePassenger, "requester": requester}).Error(err.Error())
+		u.Logger.WithFields(logrus.Fields{"activeShareRide": activeShareRide, "requester": requester}).Error(err.Error())
 		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
 
-	if activePassenger != nil {
+	if activeShareRide != nil {
 		return httpResponse.Conflict("").NewResponses(nil, "Youre share ride still active")
 	}
 
+	pickupCoordinate := entity.Coordinate{
+		Latitude:  payload.PickupCoordinate.Latitude,
+		Longitude: payload.PickupCoordinate.Longitude,
+	}
+
+	// a quote is only honoured for the vehicle type it was priced for
+	var quotedVehicleType string
+	if quotedFare != nil {
+		quotedVehicleType = quotedFare.VehicleType
+	}
+
+	nearbyDrivers, err := u.Repository.FindNearestActiveDrivers(ctx, 1, requester.ID, nil, quotedVehicleType, pickupCoordinate, driverSearchRadius(), lowRating(), driverCandidateLimit)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithFields(logrus.Fields{"nearbyDrivers": nearbyDrivers, "requester": requester}).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if len(nearbyDrivers) < 1 {
+		return httpResponse.NotFound("").NewResponses(nil, "driver not found")
+	}
+
+	destinationCoordinate := entity.Coordinate{
+		Latitude:  payload.DestinationCoordinate.Latitude,
+		Longitude: payload.DestinationCoordinate.Longitude,
+	}
+
 	var tx *sql.Tx
 
 	if tx, err = u.Repository.BeginTx(ctx); err != nil {
@@ -191,17 +389,61 @@ func (u *UsecaseImpl) FindDriver(ctx context.Context, payload *model.FindDriver)
 		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
 
+	activeDriver, detour, err := u.reserveRouteCompatible(ctx, tx, nearbyDrivers, pickupCoordinate, destinationCoordinate)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithContext(ctx).WithFields(logrus.Fields{"nearbyDrivers": nearbyDrivers, "requester": requester}).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if activeDriver == nil {
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.NotFound("").NewResponses(nil, "no driver on a compatible route")
+	}
+
+	vehicleType := defaultVehicleType
+	if activeDriver.Driver.Vehicle != nil && activeDriver.Driver.Vehicle.Type != "" {
+		vehicleType = activeDriver.Driver.Vehicle.Type
+	}
+
+	if quotedFare != nil && quotedFare.VehicleType != vehicleType {
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.Conflict("QUOTE_MISMATCH").NewResponses(nil, "no driver with the quoted vehicle type, please request a new quote")
+	}
+
+	tripFare := quotedFare
+	if tripFare == nil {
+		if tripFare, err = u.FareEngine.Calculate(ctx, vehicleType, pickupCoordinate, destinationCoordinate); err != nil {
+			u.Logger.WithContext(ctx).WithFields(logrus.Fields{"requester": requester, "payload": payload}).Error(err)
+			u.Repository.RollbackTx(ctx, tx)
+			return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+		}
+	}
+
+	discount, resp := u.quotePromo(ctx, payload.PromoCode, requester.ID, tripFare.TotalAmount)
+	if resp != nil {
+		u.Repository.RollbackTx(ctx, tx)
+		return resp
+	}
+
+	payableAmount := tripFare.TotalAmount - discountAmount(discount)
+
+	paymentDetails, err := paymentDetailsFor(payload.PaymentMethod, payload.CoinAmount, payableAmount)
+	if err != nil {
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.BadRequest("").NewResponses(nil, err.Error())
+	}
+
 	passenger := &entity.Passengers{
-		UserId:      requester.ID,
-		ShareRideId: activeDriver.ID,
-		Status:      1,
-		DestinationCoordinate: entity.Coordinate{
-			Latitude:  payload.DestinationCoordinate.Latitude,
-			Longitude: payload.DestinationCoordinate.Longitude,
-		},
-		Distance:  payload.Distance,
-		CreatedAt: *date.CurrentUTCTime(),
-		DroppedAt: nil,
+		UserId:                requester.ID,
+		ShareRideId:           activeDriver.ID,
+		Status:                1,
+		PickupCoordinate:      pickupCoordinate,
+		PickupNote:            payload.PickupNote,
+		DestinationCoordinate: destinationCoordinate,
+		Distance:              tripFare.Distance,
+		CreatedAt:             *date.CurrentUTCTime(),
+		DroppedAt:             nil,
 	}
 
 	passengerId, err := u.PassengerRepository.Insert(ctx, tx, passenger)
@@ -211,16 +453,16 @@ func (u *UsecaseImpl) FindDriver(ctx context.Context, payload *model.FindDriver)
 		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
 
-	rawTotalAmount := float64(payload.CostPerKM) * payload.Distance
-	roundedTotalAmount := int64(math.Round(rawTotalAmount))
-
 	payment := &entity.Payment{
-		PassengerId: passengerId,
-		RecipientId: activeDriver.DriverId,
-		UserId:      requester.ID,
-		Status:      "unpaid",
-		TotalAmount: roundedTotalAmount,
-		CreatedAt:   *date.CurrentUTCTime(),
+		PassengerId:  passengerId,
+		RecipientId:  activeDriver.DriverId,
+		UserId:       requester.ID,
+		Status:       "unpaid",
+		BaseFare:     tripFare.BaseFare,
+		DistanceFare: tripFare.DistanceFare,
+		TotalAmount:  tripFare.TotalAmount,
+		Commission:   u.FareEngine.Commission(tripFare.VehicleType, tripFare.TotalAmount),
+		CreatedAt:    *date.CurrentUTCTime(),
 	}
 
 	paymentId, err := u.PaymentRepository.Insert(ctx, tx, payment)
@@ -230,14 +472,66 @@ func (u *UsecaseImpl) FindDriver(ctx context.Context, payload *model.FindDriver)
 		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
 
-	paymentDetails := &entity.PaymentDetails{
-		PaymentId:     paymentId,
-		PaymentMethod: "cash",
-		Amount:        roundedTotalAmount,
+	if discount != nil {
+		redeemed, err := u.Promotions.Redeem(ctx, tx, discount.Code, requester.ID, paymentId, tripFare.TotalAmount)
+		if err != nil {
+			u.Repository.RollbackTx(ctx, tx)
+			if ineligible, ok := err.(*promotions.Ineligible); ok {
+				return httpResponse.BadRequest("PROMO_NOT_APPLICABLE").NewResponses(nil, ineligible.Reason)
+			}
+			u.Logger.WithContext(ctx).WithFields(logrus.Fields{"requester": requester, "discount": discount}).Error(err)
+			return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+		}
+
+		if redeemed.Amount != discount.Amount {
+			u.Repository.RollbackTx(ctx, tx)
+			return httpResponse.Conflict("").NewResponses(nil, "promo changed while booking, please retry")
+		}
+
+		paymentDetails = append(paymentDetails, &entity.PaymentDetails{PaymentMethod: paymentPromo, Amount: discount.Amount})
+	}
+
+	for _, paymentDetail := range paymentDetails {
+		paymentDetail.PaymentId = paymentId
+
+		if _, err = u.PaymentDetailRepository.InsertDetailPayment(ctx, tx, paymentDetail); err != nil {
+			u.Logger.WithContext(ctx).WithFields(logrus.Fields{"payload.paymentDetails": paymentDetail, "payload.payment": payment}).Error(err)
+			u.Repository.RollbackTx(ctx, tx)
+			return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+		}
+
+		payment.PaymentDetails = append(payment.PaymentDetails, *paymentDetail)
 	}
 
-	if _, err = u.PaymentDetailRepository.InsertDetailPayment(ctx, tx, paymentDetails); err != nil {
-		u.Logger.WithContext(ctx).WithFields(logrus.Fields{"payload.paymentDetails": paymentDetails, "payload.payment": payment}).Error(err)
+	if err = u.reserveCoins(ctx, tx, requester.ID, paymentId, amountOf(payment, paymentCoin)); err != nil {
+		u.Repository.RollbackTx(ctx, tx)
+		if err == exception.ErrInsufficientBalance {
+			return httpResponse.BadRequest("INSUFFICIENT_BALANCE").NewResponses(nil, "coin balance does not cover the coin payment")
+		}
+		u.Logger.WithContext(ctx).WithFields(logrus.Fields{"requester": requester, "payload.payment": payment}).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	offer := &entity.ShareRideOffer{
+		BookingId:   passengerId,
+		PassengerId: passengerId,
+		ShareRideId: activeDriver.ID,
+		DriverId:    activeDriver.DriverId,
+		UserId:      requester.ID,
+		VehicleType: tripFare.VehicleType,
+		Status:      offerPending,
+		ExpiresAt:   date.CurrentUTCTime().Add(offerTimeout()),
+		CreatedAt:   *date.CurrentUTCTime(),
+	}
+
+	if offer.ID, err = u.OfferRepository.Insert(ctx, tx, offer); err != nil {
+		u.Logger.WithContext(ctx).WithFields(logrus.Fields{"payload.offer": offer, "payload.payment": payment}).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if err := u.Repository.RefreshIsFull(ctx, tx, activeDriver.ID); err != nil {
+		u.Logger.WithContext(ctx).WithField("shareRide", activeDriver).Error(err)
 		u.Repository.RollbackTx(ctx, tx)
 		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
@@ -248,7 +542,20 @@ func (u *UsecaseImpl) FindDriver(ctx context.Context, payload *model.FindDriver)
 		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
 
-	return httpResponse.Ok("").NewResponses(nil, "get driver")
+	match := DriverMatch{
+		ShareRideID:    activeDriver.ID,
+		PassengerID:    passengerId,
+		OfferID:        offer.ID,
+		OfferExpiresAt: offer.ExpiresAt,
+		Detour:         detour,
+		Driver:         activeDriver.Driver,
+		DriverDistance: activeDriver.DriverDistance,
+		Fare:           tripFare,
+		Discount:       discount,
+		PayableAmount:  payableAmount,
+	}
+
+	return httpResponse.Ok("").NewResponses(match, "waiting for driver to accept")
 }
 
 func (u *UsecaseImpl) UpdatePassengerStatusOnShareRide(ctx context.Context, payload *model.UpdatePassengerStatus) responses.Responses {
@@ -290,6 +597,19 @@ func (u *UsecaseImpl) UpdatePassengerStatusOnShareRide(ctx context.Context, payl
 		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
 
+	// a cancellation committed since the read above must not be overwritten
+	lockedStatus, err := u.PassengerRepository.LockStatus(ctx, tx, passenger.ID)
+	if err != nil {
+		u.Logger.WithContext(ctx).WithField("payload", payload).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if lockedStatus != passenger.Status {
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.Conflict("").NewResponses(nil, "passenger status changed meanwhile, please retry")
+	}
+
 	var handler PassengerStatusState
 
 	switch passenger.Status {
@@ -306,6 +626,7 @@ func (u *UsecaseImpl) UpdatePassengerStatusOnShareRide(ctx context.Context, payl
 	case -2:
 		handler = &SkippedStatusHandler{}
 	default:
+		u.Repository.RollbackTx(ctx, tx)
 		return httpResponse.BadRequest("INVALID_PASSENGER_STATUS").NewResponses(nil, "invalid passenger status")
 	}
 
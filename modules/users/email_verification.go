@@ -0,0 +1,176 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/helpers/date"
+	"github.com/Difaal21/nebeng-dong/mailer"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/responses"
+	jwtv5 "github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	verificationAudience      = "email-verification"
+	defaultVerificationTTL    = 24 * time.Hour
+	defaultVerificationResend = time.Minute
+	defaultVerificationURL    = "http://localhost:5000/nebengdong-service/v1/users/verify-email"
+	verificationEmailSubject  = "Verify your Nebeng Dong email"
+	verificationEmailText     = "Hi %s,\n\nPlease verify your email by opening the link below, it is valid for %s.\n\n%s\n\nIgnore this email if you did not register on Nebeng Dong.\n"
+)
+
+func verificationTTL() time.Duration {
+	if hours, err := strconv.ParseInt(os.Getenv("EMAIL_VERIFICATION_TTL_HOURS"), 10, 64); err == nil && hours > 0 {
+		return time.Duration(hours) * time.Hour
+	}
+	return defaultVerificationTTL
+}
+
+func verificationResendInterval() time.Duration {
+	if seconds, err := strconv.ParseInt(os.Getenv("EMAIL_VERIFICATION_RESEND_SECONDS"), 10, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultVerificationResend
+}
+
+func verificationURL() string {
+	if link := os.Getenv("EMAIL_VERIFICATION_URL"); link != "" {
+		return link
+	}
+	return defaultVerificationURL
+}
+
+// VerifyEmail marks the email of the token as verified. Opening the link again is harmless.
+func (u *UsecaseImpl) VerifyEmail(ctx context.Context, payload *model.VerifyEmail) responses.Responses {
+
+	claims := &model.VerificationBearer{}
+	if err := u.JSONWebToken.VerifyToken(ctx, payload.Token, claims); err != nil {
+		return httpResponse.BadRequest("INVALID_VERIFICATION").NewResponses(nil, "verification link is invalid or expired")
+	}
+
+	isVerification := false
+	for _, audience := range claims.Audience {
+		if audience == verificationAudience {
+			isVerification = true
+		}
+	}
+
+	if !isVerification {
+		return httpResponse.BadRequest("INVALID_VERIFICATION").NewResponses(nil, "verification link is invalid or expired")
+	}
+
+	user, err := u.Repository.FindOneById(ctx, claims.UserId)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithContext(ctx).WithField("claims", claims).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	// a link sent to a previous email of the user must not verify the current one
+	if user == nil || user.Email != claims.Email {
+		return httpResponse.BadRequest("INVALID_VERIFICATION").NewResponses(nil, "verification link is invalid or expired")
+	}
+
+	if user.IsEmailVerified {
+		return httpResponse.Ok("").NewResponses(nil, "email already verified")
+	}
+
+	updateFields := map[string]any{
+		"is_email_verified": true,
+		"email_verified_at": date.CurrentUTCTime(),
+	}
+
+	if err := u.Repository.Update(ctx, nil, user.ID, updateFields); err != nil {
+		u.Logger.WithContext(ctx).WithField("claims", claims).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Ok("").NewResponses(nil, "email verified")
+}
+
+func (u *UsecaseImpl) ResendVerification(ctx context.Context) responses.Responses {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	user, err := u.Repository.FindOneById(ctx, requester.ID)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithContext(ctx).WithField("requester", requester).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if user == nil {
+		return httpResponse.NotFound("").NewResponses(nil, "User not found")
+	}
+
+	if user.IsEmailVerified {
+		return httpResponse.BadRequest("").NewResponses(nil, "email already verified")
+	}
+
+	if err := u.sendVerification(ctx, user); err != nil {
+		if err == exception.ErrConflict {
+			return httpResponse.TooManyRequests("").NewResponses(nil, fmt.Sprintf("a verification email was sent recently, retry in %s", verificationResendInterval()))
+		}
+		u.Logger.WithContext(ctx).WithField("requester", requester).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, "verification email could not be sent, please retry")
+	}
+
+	return httpResponse.Ok("").NewResponses(nil, fmt.Sprintf("verification email sent to %s", user.Email))
+}
+
+// sendVerification mails a verification link to the user, at most once per resend interval.
+// It returns exception.ErrConflict when the previous email is too recent.
+func (u *UsecaseImpl) sendVerification(ctx context.Context, user *entity.Users) (err error) {
+	now := date.CurrentUTCTime()
+	ttl := verificationTTL()
+
+	if err = u.Repository.MarkVerificationSent(ctx, user.ID, *now, now.Add(-verificationResendInterval())); err != nil {
+		return
+	}
+
+	claims := &model.VerificationBearer{}
+	claims.UserId = user.ID
+	claims.Email = user.Email
+	claims.Audience = jwtv5.ClaimStrings{verificationAudience}
+	claims.IssuedAt = jwtv5.NewNumericDate(*now)
+	claims.ExpiresAt = jwtv5.NewNumericDate(now.Add(ttl))
+
+	token, err := u.JSONWebToken.CreateToken(ctx, claims)
+	if err != nil {
+		return
+	}
+
+	link, err := url.Parse(verificationURL())
+	if err != nil {
+		return
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	message := &mailer.Message{
+		To:      []string{user.Email},
+		Subject: verificationEmailSubject,
+		Text:    fmt.Sprintf(verificationEmailText, user.Name, ttl, link.String()),
+	}
+
+	return u.Mailer.Send(ctx, message)
+}
+
+// sendVerificationAfterRegistration does not fail the registration, the user can ask for another email.
+func (u *UsecaseImpl) sendVerificationAfterRegistration(ctx context.Context, user *entity.Users) {
+	if err := u.sendVerification(ctx, user); err != nil {
+		u.Logger.WithContext(ctx).WithFields(logrus.Fields{"userId": user.ID, "email": user.Email}).Error(err)
+	}
+}
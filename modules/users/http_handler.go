@@ -34,6 +34,16 @@ func NewHTTPHandler(router *gin.Engine, basicAuth *middleware.BasicAuth, session
 	router.PUT("/nebengdong-service/v1/users/phone-number", session.Verify, handler.ChangePhoneNumber)
 	router.PUT("/nebengdong-service/v1/users/password", session.Verify, handler.ChangePassword)
 	router.POST("/nebengdong-service/v1/users/join-driver", session.Verify, handler.JoinAsDriver)
+
+	router.GET("/nebengdong-service/v1/users/verify-email", handler.VerifyEmail)
+	router.POST("/nebengdong-service/v1/users/verify-email/resend", session.Verify, handler.ResendVerification)
+
+	router.POST("/nebengdong-service/v1/users/password/forgot", basicAuth.Verify, handler.ForgotPassword)
+	router.POST("/nebengdong-service/v1/users/password/reset", basicAuth.Verify, handler.ResetPassword)
+
+	router.POST("/nebengdong-service/v1/users/token/refresh", basicAuth.Verify, handler.RefreshSession)
+	router.POST("/nebengdong-service/v1/users/logout", session.Verify, handler.Logout)
+	router.POST("/nebengdong-service/v1/users/logout-all", session.Verify, handler.LogoutAll)
 }
 
 func (handler *HTTPHandler) Registration(c *gin.Context) {
@@ -53,6 +63,7 @@ func (handler *HTTPHandler) Registration(c *gin.Context) {
 	}
 
 	payload.Email = strings.ToLower(payload.Email)
+	payload.PhoneNumber = strings.ReplaceAll(payload.PhoneNumber, " ", "")
 	payload.VehicleLicensePlate = strings.ReplaceAll(payload.VehicleLicensePlate, " ", "")
 	resp := handler.Usecase.UserRegistration(context, payload)
 	responses.REST(c, resp)
@@ -80,6 +91,7 @@ func (handler *HTTPHandler) Login(c *gin.Context) {
 	}
 
 	payload.Email = strings.ToLower(payload.Email)
+	payload.Device = model.Device{UserAgent: c.Request.UserAgent(), IPAddress: c.ClientIP()}
 	resp := handler.Usecase.UserLogin(context, payload)
 	responses.REST(c, resp)
 }
@@ -164,6 +176,7 @@ func (handler *HTTPHandler) ChangePhoneNumber(c *gin.Context) {
 		return
 	}
 
+	payload.New = strings.ReplaceAll(payload.New, " ", "")
 	resp := handler.Usecase.ChangePhoneNumber(context, payload)
 	responses.REST(c, resp)
 }
@@ -213,3 +226,104 @@ func (handler *HTTPHandler) JoinAsDriver(c *gin.Context) {
 	resp := handler.Usecase.JoinAsDriver(context, payload)
 	responses.REST(c, resp)
 }
+
+func (handler *HTTPHandler) VerifyEmail(c *gin.Context) {
+	context := c.Request.Context()
+
+	payload := model.VerifyEmail{
+		Token: c.Request.URL.Query().Get("token"),
+	}
+
+	if err := c.ShouldBind(&payload); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, payload)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.VerifyEmail(context, &payload)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) ResendVerification(c *gin.Context) {
+	context := c.Request.Context()
+
+	resp := handler.Usecase.ResendVerification(context)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) ForgotPassword(c *gin.Context) {
+	context := c.Request.Context()
+
+	var payload model.ForgotPassword
+
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, payload)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	payload.Email = strings.ToLower(payload.Email)
+	resp := handler.Usecase.ForgotPassword(context, &payload)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) ResetPassword(c *gin.Context) {
+	context := c.Request.Context()
+
+	var payload model.ResetPassword
+
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, payload)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.ResetPassword(context, &payload)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) RefreshSession(c *gin.Context) {
+	context := c.Request.Context()
+
+	var payload model.RefreshSession
+
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, payload)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	payload.Device = model.Device{UserAgent: c.Request.UserAgent(), IPAddress: c.ClientIP()}
+	resp := handler.Usecase.RefreshSession(context, &payload)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) Logout(c *gin.Context) {
+	context := c.Request.Context()
+
+	resp := handler.Usecase.Logout(context)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) LogoutAll(c *gin.Context) {
+	context := c.Request.Context()
+
+	resp := handler.Usecase.LogoutAll(context)
+	responses.REST(c, resp)
+}
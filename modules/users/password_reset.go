@@ -0,0 +1,191 @@
+package users
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/helpers/cryptography"
+	"github.com/Difaal21/nebeng-dong/helpers/date"
+	"github.com/Difaal21/nebeng-dong/mailer"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/responses"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultPasswordResetTTL   = 30 * time.Minute
+	defaultPasswordResetLimit = 3 // per hour and email
+	defaultPasswordResetURL   = "http://localhost:3000/reset-password"
+	passwordResetEmailSubject = "Reset your Nebeng Dong password"
+	passwordResetEmailText    = "Hi %s,\n\nUse the link below to choose a new password, it is valid for %s and works once.\n\n%s\n\nIgnore this email if you did not ask for it, your password stays the same.\n"
+	forgotPasswordMessage     = "if the email is registered, a reset link has been sent"
+)
+
+func passwordResetTTL() time.Duration {
+	if minutes, err := strconv.ParseInt(os.Getenv("PASSWORD_RESET_TTL_MINUTES"), 10, 64); err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return defaultPasswordResetTTL
+}
+
+func passwordResetLimit() int64 {
+	if limit, err := strconv.ParseInt(os.Getenv("PASSWORD_RESET_LIMIT_PER_HOUR"), 10, 64); err == nil && limit > 0 {
+		return limit
+	}
+	return defaultPasswordResetLimit
+}
+
+func passwordResetURL() string {
+	if link := os.Getenv("PASSWORD_RESET_URL"); link != "" {
+		return link
+	}
+	return defaultPasswordResetURL
+}
+
+// hashToken is how single use tokens are stored, only the mailed or returned value can match it.
+func hashToken(token string) string {
+	digest := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(digest[:])
+}
+
+// ForgotPassword mails a reset link. The response is the same whether the email is registered or not.
+func (u *UsecaseImpl) ForgotPassword(ctx context.Context, payload *model.ForgotPassword) responses.Responses {
+
+	user, err := u.Repository.FindOneByEmail(ctx, payload.Email)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithContext(ctx).WithField("payload", payload).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if user == nil {
+		return httpResponse.Ok("").NewResponses(nil, forgotPasswordMessage)
+	}
+
+	now := date.CurrentUTCTime()
+
+	sent, err := u.PasswordResetRepository.CountSince(ctx, user.ID, now.Add(-time.Hour))
+	if err != nil {
+		u.Logger.WithContext(ctx).WithField("payload", payload).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	// throttled and failed requests answer like the others, so the response never tells whether the email is registered
+	if sent >= passwordResetLimit() {
+		u.Logger.WithContext(ctx).WithField("userId", user.ID).Warn("password reset requests throttled")
+		return httpResponse.Ok("").NewResponses(nil, forgotPasswordMessage)
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		u.Logger.WithContext(ctx).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, "unexpected error")
+	}
+
+	ttl := passwordResetTTL()
+	reset := &entity.PasswordReset{
+		UserId:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: *now,
+	}
+
+	if reset.ID, err = u.PasswordResetRepository.Insert(ctx, nil, reset); err != nil {
+		u.Logger.WithContext(ctx).WithField("userId", user.ID).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	link, err := url.Parse(passwordResetURL())
+	if err != nil {
+		u.Logger.WithContext(ctx).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, "unexpected error")
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	message := &mailer.Message{
+		To:      []string{user.Email},
+		Subject: passwordResetEmailSubject,
+		Text:    fmt.Sprintf(passwordResetEmailText, user.Name, ttl, link.String()),
+	}
+
+	if err := u.Mailer.Send(ctx, message); err != nil {
+		u.Logger.WithContext(ctx).WithField("userId", user.ID).Error(err)
+		return httpResponse.Ok("").NewResponses(nil, forgotPasswordMessage)
+	}
+
+	return httpResponse.Ok("").NewResponses(nil, forgotPasswordMessage)
+}
+
+// ResetPassword sets a new password with a mailed token. The token and every other outstanding one of the user
+// stop working, and all sessions issued before the reset are rejected.
+func (u *UsecaseImpl) ResetPassword(ctx context.Context, payload *model.ResetPassword) responses.Responses {
+
+	hashPassword, err := cryptography.Hash([]byte(payload.New))
+	if err != nil {
+		u.Logger.Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, "unexpected error")
+	}
+
+	tx, err := u.Repository.BeginTx(ctx)
+	if err != nil {
+		u.Logger.WithContext(ctx).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	reset, err := u.PasswordResetRepository.FindByTokenHash(ctx, tx, hashToken(payload.Token))
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithContext(ctx).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	now := date.CurrentUTCTime()
+
+	if reset == nil || reset.UsedAt != nil || now.After(reset.ExpiresAt) {
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.BadRequest("INVALID_RESET_TOKEN").NewResponses(nil, "reset link is invalid, used or expired")
+	}
+
+	fields := logrus.Fields{"userId": reset.UserId, "resetId": reset.ID}
+
+	updatedField := map[string]any{
+		"password":                hashPassword,
+		"sessions_invalidated_at": now.Truncate(time.Second),
+	}
+
+	if err := u.Repository.Update(ctx, tx, reset.UserId, updatedField); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if err := u.PasswordResetRepository.UseAllByUser(ctx, tx, reset.UserId, *now); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if err := u.RefreshTokenRepository.RevokeAllByUser(ctx, tx, reset.UserId, *now); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if err := u.Repository.CommitTx(ctx, tx); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Ok("").NewResponses(nil, "password has been reset, please login again")
+}
@@ -0,0 +1,132 @@
+package users
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/sirupsen/logrus"
+)
+
+type PasswordResetRepository interface {
+	Insert(ctx context.Context, tx *sql.Tx, reset *entity.PasswordReset) (id int64, err error)
+	// FindByTokenHash locks the reset row when tx is given.
+	FindByTokenHash(ctx context.Context, tx *sql.Tx, tokenHash string) (reset *entity.PasswordReset, err error)
+	CountSince(ctx context.Context, userId int64, since time.Time) (total int64, err error)
+	// UseAllByUser marks every unused reset of the user as used, so no other mailed token keeps working.
+	UseAllByUser(ctx context.Context, tx *sql.Tx, userId int64, usedAt time.Time) (err error)
+}
+
+type PasswordResetRepositoryImpl struct {
+	DB        *sql.DB
+	Logger    *logrus.Logger
+	TableName string
+}
+
+func NewPasswordResetRepositoryImpl(db *sql.DB, logger *logrus.Logger) PasswordResetRepository {
+	return &PasswordResetRepositoryImpl{
+		DB:        db,
+		Logger:    logger,
+		TableName: "password_resets",
+	}
+}
+
+func (repo *PasswordResetRepositoryImpl) Insert(ctx context.Context, tx *sql.Tx, reset *entity.PasswordReset) (id int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`
+	INSERT INTO %s
+	SET
+		id = ?,
+		user_id = ?,
+		token_hash = ?,
+		expires_at = ?,
+		created_at = ?
+	`, repo.TableName)
+
+	result, err := Exec(ctx, cmd, command, reset.ID, reset.UserId, reset.TokenHash, reset.ExpiresAt, reset.CreatedAt)
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return
+	}
+
+	if id, err = result.LastInsertId(); err != nil {
+		return
+	}
+	return
+}
+
+func (repo *PasswordResetRepositoryImpl) FindByTokenHash(ctx context.Context, tx *sql.Tx, tokenHash string) (reset *entity.PasswordReset, err error) {
+	var cmd SqlCommand = repo.DB
+
+	lock := ""
+	if tx != nil {
+		cmd = tx
+		lock = "FOR UPDATE"
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+		pr.id,
+		pr.user_id,
+		pr.token_hash,
+		pr.expires_at,
+		pr.used_at,
+		pr.created_at
+	FROM
+		%s pr
+	WHERE
+		pr.token_hash = ?
+	%s
+	`, repo.TableName, lock)
+
+	reset = &entity.PasswordReset{}
+
+	err = cmd.QueryRowContext(ctx, query, tokenHash).Scan(&reset.ID, &reset.UserId, &reset.TokenHash, &reset.ExpiresAt, &reset.UsedAt, &reset.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, exception.ErrNotFound
+	}
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return nil, err
+	}
+
+	return
+}
+
+func (repo *PasswordResetRepositoryImpl) CountSince(ctx context.Context, userId int64, since time.Time) (total int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	query := fmt.Sprintf(`SELECT COUNT(pr.id) FROM %s pr WHERE pr.user_id = ? AND pr.created_at >= ?`, repo.TableName)
+
+	if err = cmd.QueryRowContext(ctx, query, userId, since).Scan(&total); err != nil {
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return
+	}
+
+	return
+}
+
+func (repo *PasswordResetRepositoryImpl) UseAllByUser(ctx context.Context, tx *sql.Tx, userId int64, usedAt time.Time) (err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`UPDATE %s SET used_at = ? WHERE user_id = ? AND used_at IS NULL`, repo.TableName)
+
+	if _, err = Exec(ctx, cmd, command, usedAt, userId); err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	return
+}
@@ -37,6 +37,7 @@ func (q *query) BaseQuerySelectAllUser() *query {
 		ST_Y(u.coordinate),
 		u.is_email_verified,
 		u.email_verified_at,
+		u.is_phone_verified,
 		u.is_driver,
 		u.created_at,
 		u.updated_at
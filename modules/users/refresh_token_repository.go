@@ -0,0 +1,174 @@
+package users
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/sirupsen/logrus"
+)
+
+type RefreshTokenRepository interface {
+	Insert(ctx context.Context, tx *sql.Tx, token *entity.RefreshToken) (id int64, err error)
+	// FindByTokenHash locks the token row when tx is given.
+	FindByTokenHash(ctx context.Context, tx *sql.Tx, tokenHash string) (token *entity.RefreshToken, err error)
+	// MarkUsed returns exception.ErrConflict when the token was already used.
+	MarkUsed(ctx context.Context, tx *sql.Tx, id int64, usedAt time.Time) (err error)
+	RevokeFamily(ctx context.Context, tx *sql.Tx, familyId string, revokedAt time.Time) (err error)
+	RevokeAllByUser(ctx context.Context, tx *sql.Tx, userId int64, revokedAt time.Time) (err error)
+}
+
+type RefreshTokenRepositoryImpl struct {
+	DB        *sql.DB
+	Logger    *logrus.Logger
+	TableName string
+}
+
+func NewRefreshTokenRepositoryImpl(db *sql.DB, logger *logrus.Logger) RefreshTokenRepository {
+	return &RefreshTokenRepositoryImpl{
+		DB:        db,
+		Logger:    logger,
+		TableName: "refresh_tokens",
+	}
+}
+
+func (repo *RefreshTokenRepositoryImpl) Insert(ctx context.Context, tx *sql.Tx, token *entity.RefreshToken) (id int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`
+	INSERT INTO %s
+	SET
+		id = ?,
+		user_id = ?,
+		family_id = ?,
+		token_hash = ?,
+		device_name = ?,
+		user_agent = ?,
+		ip_address = ?,
+		expires_at = ?,
+		created_at = ?
+	`, repo.TableName)
+
+	result, err := Exec(ctx, cmd, command, token.ID, token.UserId, token.FamilyId, token.TokenHash, token.DeviceName, token.UserAgent, token.IPAddress, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return
+	}
+
+	if id, err = result.LastInsertId(); err != nil {
+		return
+	}
+	return
+}
+
+func (repo *RefreshTokenRepositoryImpl) FindByTokenHash(ctx context.Context, tx *sql.Tx, tokenHash string) (token *entity.RefreshToken, err error) {
+	var cmd SqlCommand = repo.DB
+
+	lock := ""
+	if tx != nil {
+		cmd = tx
+		lock = "FOR UPDATE"
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+		rt.id,
+		rt.user_id,
+		rt.family_id,
+		rt.token_hash,
+		rt.device_name,
+		rt.user_agent,
+		rt.ip_address,
+		rt.expires_at,
+		rt.used_at,
+		rt.revoked_at,
+		rt.created_at
+	FROM
+		%s rt
+	WHERE
+		rt.token_hash = ?
+	%s
+	`, repo.TableName, lock)
+
+	token = &entity.RefreshToken{}
+
+	err = cmd.QueryRowContext(ctx, query, tokenHash).Scan(&token.ID, &token.UserId, &token.FamilyId, &token.TokenHash, &token.DeviceName, &token.UserAgent, &token.IPAddress, &token.ExpiresAt, &token.UsedAt, &token.RevokedAt, &token.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, exception.ErrNotFound
+	}
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return nil, err
+	}
+
+	return
+}
+
+func (repo *RefreshTokenRepositoryImpl) MarkUsed(ctx context.Context, tx *sql.Tx, id int64, usedAt time.Time) (err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`UPDATE %s SET used_at = ? WHERE id = ? AND used_at IS NULL`, repo.TableName)
+
+	result, err := Exec(ctx, cmd, command, usedAt, id)
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	if affected < 1 {
+		return exception.ErrConflict
+	}
+
+	return
+}
+
+func (repo *RefreshTokenRepositoryImpl) RevokeFamily(ctx context.Context, tx *sql.Tx, familyId string, revokedAt time.Time) (err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`UPDATE %s SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL`, repo.TableName)
+
+	if _, err = Exec(ctx, cmd, command, revokedAt, familyId); err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	return
+}
+
+func (repo *RefreshTokenRepositoryImpl) RevokeAllByUser(ctx context.Context, tx *sql.Tx, userId int64, revokedAt time.Time) (err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`UPDATE %s SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL`, repo.TableName)
+
+	if _, err = Exec(ctx, cmd, command, revokedAt, userId); err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	return
+}
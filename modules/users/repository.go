@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/Difaal21/nebeng-dong/entity"
 	"github.com/Difaal21/nebeng-dong/exception"
@@ -26,6 +27,15 @@ type Repository interface {
 	UpdateCoordinate(ctx context.Context, tx *sql.Tx, id int64, coordinate *entity.Coordinate) (err error)
 	Insert(ctx context.Context, tx *sql.Tx, users *entity.Users) (id int64, err error)
 	Update(ctx context.Context, tx *sql.Tx, id int64, updateFields map[string]any) (err error)
+	IncrementCoin(ctx context.Context, tx *sql.Tx, id int64, amount int64) (balance int64, err error)
+	DecrementCoin(ctx context.Context, tx *sql.Tx, id int64, amount int64, allowNegative bool) (balance int64, err error)
+	// RefreshRating recomputes the rating average and count of the user from the ratings they received.
+	RefreshRating(ctx context.Context, tx *sql.Tx, id int64) (err error)
+	// MarkVerificationSent records a verification email sent at sentAt unless one was already sent after notBefore,
+	// in which case it returns exception.ErrConflict.
+	MarkVerificationSent(ctx context.Context, id int64, sentAt time.Time, notBefore time.Time) (err error)
+	// FindSessionsInvalidatedAt returns when the sessions of the user were last invalidated, nil if never.
+	FindSessionsInvalidatedAt(ctx context.Context, id int64) (invalidatedAt *time.Time, err error)
 }
 
 type RepositoryImpl struct {
@@ -68,12 +78,13 @@ func (repo *RepositoryImpl) Insert(ctx context.Context, tx *sql.Tx, user *entity
 		password = ?,
 		is_email_verified = ?,
 		email_verified_at = ?,
+		is_phone_verified = ?,
 		is_driver = ?,
 		created_at = ?,
 		updated_at = ?
 	`, repo.TableName)
 
-	result, err := Exec(ctx, cmd, command, user.ID, user.Name, user.Email, user.PhoneNumber, user.Coin, user.Coordinate, user.Password, user.IsEmailVerified, user.EmailVerifiedAt, user.IsDriver, user.CreatedAt, user.UpdatedAt)
+	result, err := Exec(ctx, cmd, command, user.ID, user.Name, user.Email, user.PhoneNumber, user.Coin, user.Coordinate, user.Password, user.IsEmailVerified, user.EmailVerifiedAt, user.IsPhoneVerified, user.IsDriver, user.CreatedAt, user.UpdatedAt)
 	if err != nil {
 		repo.Logger.WithContext(ctx).Error(command, err.Error())
 		return
@@ -158,7 +169,10 @@ func (repo *RepositoryImpl) FindOne(ctx context.Context, coloumn string, value a
 		u.password,
 		u.is_email_verified,
 		u.email_verified_at,
+		u.is_phone_verified,
 		u.is_driver,
+		u.rating_average,
+		u.rating_count,
 		u.created_at,
 		u.updated_at,
 		v.id,
@@ -205,7 +219,10 @@ func (repo *RepositoryImpl) FindOneByEmail(ctx context.Context, email string) (u
 		u.password,
 		u.is_email_verified,
 		u.email_verified_at,
+		u.is_phone_verified,
 		u.is_driver,
+		u.rating_average,
+		u.rating_count,
 		u.created_at,
 		u.updated_at,
 		v.id,
@@ -252,7 +269,10 @@ func (repo *RepositoryImpl) FindOneById(ctx context.Context, id int64) (user *en
 		u.password,
 		u.is_email_verified,
 		u.email_verified_at,
+		u.is_phone_verified,
 		u.is_driver,
+		u.rating_average,
+		u.rating_count,
 		u.created_at,
 		u.updated_at,
 		v.id,
@@ -310,6 +330,135 @@ func (repo *RepositoryImpl) UpdateCoordinate(ctx context.Context, tx *sql.Tx, id
 	return
 }
 
+// IncrementCoin adds amount to the user's coin and returns the new balance.
+// It must run inside the caller's transaction so the row lock is held until commit.
+func (repo *RepositoryImpl) IncrementCoin(ctx context.Context, tx *sql.Tx, id int64, amount int64) (balance int64, err error) {
+	return repo.changeCoin(ctx, tx, id, amount, true)
+}
+
+// DecrementCoin takes amount from the user's coin and returns the new balance. Unless allowNegative is set it fails
+// with exception.ErrInsufficientBalance when the coin does not cover the amount.
+// It must run inside the caller's transaction so the row lock is held until commit.
+func (repo *RepositoryImpl) DecrementCoin(ctx context.Context, tx *sql.Tx, id int64, amount int64, allowNegative bool) (balance int64, err error) {
+	return repo.changeCoin(ctx, tx, id, -amount, allowNegative)
+}
+
+func (repo *RepositoryImpl) changeCoin(ctx context.Context, tx *sql.Tx, id int64, delta int64, allowNegative bool) (balance int64, err error) {
+	if tx == nil {
+		repo.Logger.WithContext(ctx).Error("coin change requires a transaction")
+		return 0, exception.ErrInternalServer
+	}
+
+	query := fmt.Sprintf(`SELECT coin FROM %s WHERE id = ? FOR UPDATE`, repo.TableName)
+
+	var coin int64
+	if err = tx.QueryRowContext(ctx, query, id).Scan(&coin); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, exception.ErrNotFound
+		}
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return 0, exception.ErrInternalServer
+	}
+
+	balance = coin + delta
+	if balance < 0 && !allowNegative {
+		return coin, exception.ErrInsufficientBalance
+	}
+
+	command := fmt.Sprintf(`UPDATE %s SET coin = ? WHERE id = ?`, repo.TableName)
+
+	if _, err = Exec(ctx, tx, command, balance, id); err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return 0, exception.ErrInternalServer
+	}
+
+	return
+}
+
+func (repo *RepositoryImpl) RefreshRating(ctx context.Context, tx *sql.Tx, id int64) (err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	// derived from the ratings on every change, a running average kept in DECIMAL(3,2) would drift with rounding
+	command := fmt.Sprintf(`
+	UPDATE
+		%s
+	SET
+		rating_average = (SELECT COALESCE(AVG(r.stars), 0) FROM ratings r WHERE r.ratee_id = ?),
+		rating_count = (SELECT COUNT(r.id) FROM ratings r WHERE r.ratee_id = ?)
+	WHERE
+		id = ?
+	`, repo.TableName)
+
+	result, err := Exec(ctx, cmd, command, id, id, id)
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	if affected < 1 {
+		return exception.ErrNotFound
+	}
+
+	return
+}
+
+func (repo *RepositoryImpl) MarkVerificationSent(ctx context.Context, id int64, sentAt time.Time, notBefore time.Time) (err error) {
+	var cmd SqlCommand = repo.DB
+
+	command := fmt.Sprintf(`
+	UPDATE
+		%s
+	SET
+		email_verification_sent_at = ?
+	WHERE
+		id = ? AND (email_verification_sent_at IS NULL OR email_verification_sent_at <= ?)
+	`, repo.TableName)
+
+	result, err := Exec(ctx, cmd, command, sentAt, id, notBefore)
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	if affected < 1 {
+		return exception.ErrConflict
+	}
+
+	return
+}
+
+func (repo *RepositoryImpl) FindSessionsInvalidatedAt(ctx context.Context, id int64) (invalidatedAt *time.Time, err error) {
+	var cmd SqlCommand = repo.DB
+
+	query := fmt.Sprintf(`SELECT sessions_invalidated_at FROM %s WHERE id = ?`, repo.TableName)
+
+	if err = cmd.QueryRowContext(ctx, query, id).Scan(&invalidatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, exception.ErrNotFound
+		}
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return nil, exception.ErrInternalServer
+	}
+
+	return
+}
+
 func (repo *RepositoryImpl) Update(ctx context.Context, tx *sql.Tx, id int64, updateFields map[string]any) (err error) {
 	var cmd SqlCommand = repo.DB
 
@@ -403,7 +552,7 @@ func (repo *RepositoryImpl) Query(ctx context.Context, cmd SqlCommand, query str
 			vehicleCreatedAt    sql.NullTime
 		)
 
-		err = rows.Scan(&user.ID, &user.Name, &user.Email, &user.PhoneNumber, &user.Coin, &coordinateLatitue, &coordinateLongitude, &user.Password, &user.IsEmailVerified, &user.EmailVerifiedAt, &user.IsDriver, &user.CreatedAt, &user.UpdatedAt, &vehicleId, &vehicleType, &vehicleManufacture, &vehicleModel, &vehicleLicensePlate, &vehicleCreatedAt)
+		err = rows.Scan(&user.ID, &user.Name, &user.Email, &user.PhoneNumber, &user.Coin, &coordinateLatitue, &coordinateLongitude, &user.Password, &user.IsEmailVerified, &user.EmailVerifiedAt, &user.IsPhoneVerified, &user.IsDriver, &user.RatingAverage, &user.RatingCount, &user.CreatedAt, &user.UpdatedAt, &vehicleId, &vehicleType, &vehicleManufacture, &vehicleModel, &vehicleLicensePlate, &vehicleCreatedAt)
 
 		if err != nil {
 			repo.Logger.Error(err.Error())
@@ -497,7 +646,7 @@ func (repo *RepositoryImpl) QuerySelectAllUser(ctx context.Context, cmd SqlComma
 			coordinateLongitude sql.NullFloat64
 		)
 
-		err = rows.Scan(&user.ID, &user.Name, &user.Email, &user.PhoneNumber, &user.Coin, &coordinateLatitue, &coordinateLongitude, &user.IsEmailVerified, &user.EmailVerifiedAt, &user.IsDriver, &user.CreatedAt, &user.UpdatedAt)
+		err = rows.Scan(&user.ID, &user.Name, &user.Email, &user.PhoneNumber, &user.Coin, &coordinateLatitue, &coordinateLongitude, &user.IsEmailVerified, &user.EmailVerifiedAt, &user.IsPhoneVerified, &user.IsDriver, &user.CreatedAt, &user.UpdatedAt)
 
 		if err != nil {
 			repo.Logger.Error(err.Error())
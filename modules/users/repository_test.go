@@ -0,0 +1,205 @@
+package users
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/databases/mariadb"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/sirupsen/logrus"
+)
+
+// The coin tests need a real MariaDB, e.g.
+//
+//	docker run -d --rm -p 3306:3306 -e MARIADB_ROOT_PASSWORD=secret -e MARIADB_DATABASE=nebeng_dong_test mariadb:10.11
+//	MARIADB_TEST_DSN='root:secret@tcp(127.0.0.1:3306)/nebeng_dong_test' go test ./modules/users/
+//
+// They run on a scratch table that is dropped afterwards and are skipped when MARIADB_TEST_DSN is not set.
+func newCoinRepository(t *testing.T) *RepositoryImpl {
+	t.Helper()
+
+	dsn := os.Getenv("MARIADB_TEST_DSN")
+	if dsn == "" {
+		t.Skip("MARIADB_TEST_DSN is not set")
+	}
+
+	db, err := mariadb.NewClientImpl("mysql", dsn).Connect(20, 20)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	tableName := fmt.Sprintf("users_coin_test_%d", time.Now().UnixNano())
+
+	if _, err := db.Exec(fmt.Sprintf("CREATE TABLE %s (id BIGINT PRIMARY KEY AUTO_INCREMENT, coin BIGINT NOT NULL DEFAULT 0) ENGINE=InnoDB", tableName)); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	t.Cleanup(func() {
+		db.Exec(fmt.Sprintf("DROP TABLE %s", tableName))
+		db.Close()
+	})
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	return &RepositoryImpl{DB: db, Logger: logger, TableName: tableName}
+}
+
+func insertCoinUser(t *testing.T, repo *RepositoryImpl, coin int64) int64 {
+	t.Helper()
+
+	result, err := repo.DB.Exec(fmt.Sprintf("INSERT INTO %s SET coin = ?", repo.TableName), coin)
+	if err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+
+	return id
+}
+
+func coinOf(t *testing.T, repo *RepositoryImpl, id int64) (coin int64) {
+	t.Helper()
+
+	if err := repo.DB.QueryRow(fmt.Sprintf("SELECT coin FROM %s WHERE id = ?", repo.TableName), id).Scan(&coin); err != nil {
+		t.Fatalf("read coin: %v", err)
+	}
+
+	return
+}
+
+// changeCoinInTx runs one coin change in its own transaction, the way the wallet ledger does.
+func changeCoinInTx(ctx context.Context, repo *RepositoryImpl, change func(tx *sql.Tx) (int64, error)) (err error) {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+
+	if _, err = change(tx); err != nil {
+		tx.Rollback()
+		return
+	}
+
+	return tx.Commit()
+}
+
+func TestRepositoryImpl_ConcurrentCoinChanges(t *testing.T) {
+	repo := newCoinRepository(t)
+	ctx := context.Background()
+
+	const (
+		initialCoin  = 1000
+		credits      = 60
+		creditAmount = 15
+		debits       = 40
+		debitAmount  = 25
+	)
+
+	id := insertCoinUser(t, repo, initialCoin)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, credits+debits)
+
+	for i := 0; i < credits; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- changeCoinInTx(ctx, repo, func(tx *sql.Tx) (int64, error) {
+				return repo.IncrementCoin(ctx, tx, id, creditAmount)
+			})
+		}()
+	}
+
+	for i := 0; i < debits; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- changeCoinInTx(ctx, repo, func(tx *sql.Tx) (int64, error) {
+				return repo.DecrementCoin(ctx, tx, id, debitAmount, false)
+			})
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("coin change failed: %v", err)
+		}
+	}
+
+	want := int64(initialCoin + credits*creditAmount - debits*debitAmount)
+	if got := coinOf(t, repo, id); got != want {
+		t.Errorf("coin = %d, want %d", got, want)
+	}
+}
+
+func TestRepositoryImpl_ConcurrentDebitsKeepBalanceGuard(t *testing.T) {
+	repo := newCoinRepository(t)
+	ctx := context.Background()
+
+	const (
+		initialCoin = 100
+		debits      = 50
+		debitAmount = 10
+	)
+
+	id := insertCoinUser(t, repo, initialCoin)
+
+	var (
+		wg                  sync.WaitGroup
+		mu                  sync.Mutex
+		succeeded, declined int
+		unexpected          []error
+	)
+
+	for i := 0; i < debits; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := changeCoinInTx(ctx, repo, func(tx *sql.Tx) (int64, error) {
+				return repo.DecrementCoin(ctx, tx, id, debitAmount, false)
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			switch err {
+			case nil:
+				succeeded++
+			case exception.ErrInsufficientBalance:
+				declined++
+			default:
+				unexpected = append(unexpected, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for _, err := range unexpected {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if want := initialCoin / debitAmount; succeeded != want {
+		t.Errorf("succeeded debits = %d, want %d", succeeded, want)
+	}
+
+	if want := debits - initialCoin/debitAmount; declined != want {
+		t.Errorf("declined debits = %d, want %d", declined, want)
+	}
+
+	if got := coinOf(t, repo, id); got != 0 {
+		t.Errorf("coin = %d, want 0", got)
+	}
+}
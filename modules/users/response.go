@@ -1,10 +1,11 @@
 package users
 
 type UserLogin struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	IsDriver bool   `json:"isDriver"`
-	Token    Token  `json:"token"`
+	Name         string `json:"name"`
+	Email        string `json:"email"`
+	IsDriver     bool   `json:"isDriver"`
+	Token        Token  `json:"token"`
+	RefreshToken *Token `json:"refreshToken"`
 }
 
 type Token struct {
@@ -0,0 +1,77 @@
+package users
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/sirupsen/logrus"
+)
+
+// RevokedTokenRepository is the deny-list of access tokens, by jti, that were revoked before they expired.
+type RevokedTokenRepository interface {
+	// Insert keeps the jti until expiresAt, inserting it again is a no-op.
+	Insert(ctx context.Context, tx *sql.Tx, jti string, userId int64, expiresAt time.Time) (err error)
+	Exists(ctx context.Context, jti string) (exists bool, err error)
+	// DeleteExpired drops entries of tokens that expired anyway.
+	DeleteExpired(ctx context.Context, now time.Time) (err error)
+}
+
+type RevokedTokenRepositoryImpl struct {
+	DB        *sql.DB
+	Logger    *logrus.Logger
+	TableName string
+}
+
+func NewRevokedTokenRepositoryImpl(db *sql.DB, logger *logrus.Logger) RevokedTokenRepository {
+	return &RevokedTokenRepositoryImpl{
+		DB:        db,
+		Logger:    logger,
+		TableName: "revoked_tokens",
+	}
+}
+
+func (repo *RevokedTokenRepositoryImpl) Insert(ctx context.Context, tx *sql.Tx, jti string, userId int64, expiresAt time.Time) (err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`INSERT IGNORE INTO %s SET jti = ?, user_id = ?, expires_at = ?`, repo.TableName)
+
+	if _, err = Exec(ctx, cmd, command, jti, userId, expiresAt); err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	return
+}
+
+func (repo *RevokedTokenRepositoryImpl) Exists(ctx context.Context, jti string) (exists bool, err error) {
+	var cmd SqlCommand = repo.DB
+
+	query := fmt.Sprintf(`SELECT EXISTS(SELECT 1 FROM %s WHERE jti = ?)`, repo.TableName)
+
+	if err = cmd.QueryRowContext(ctx, query, jti).Scan(&exists); err != nil {
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return false, exception.ErrInternalServer
+	}
+
+	return
+}
+
+func (repo *RevokedTokenRepositoryImpl) DeleteExpired(ctx context.Context, now time.Time) (err error) {
+	var cmd SqlCommand = repo.DB
+
+	command := fmt.Sprintf(`DELETE FROM %s WHERE expires_at < ?`, repo.TableName)
+
+	if _, err = Exec(ctx, cmd, command, now); err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	return
+}
@@ -0,0 +1,302 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/helpers/date"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/responses"
+	jwtv5 "github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultAccessTokenTTL  = time.Hour
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+func accessTokenTTL() time.Duration {
+	if minutes, err := strconv.ParseInt(os.Getenv("ACCESS_TOKEN_TTL_MINUTES"), 10, 64); err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return defaultAccessTokenTTL
+}
+
+func refreshTokenTTL() time.Duration {
+	if days, err := strconv.ParseInt(os.Getenv("REFRESH_TOKEN_TTL_DAYS"), 10, 64); err == nil && days > 0 {
+		return time.Duration(days) * 24 * time.Hour
+	}
+	return defaultRefreshTokenTTL
+}
+
+// randomToken returns size random bytes hex encoded.
+func randomToken(size int) (token string, err error) {
+	secret := make([]byte, size)
+	if _, err = rand.Read(secret); err != nil {
+		return
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// issueSession stores a new refresh token of the family and signs an access token that points to it.
+func (u *UsecaseImpl) issueSession(ctx context.Context, tx *sql.Tx, user *entity.Users, familyId string, deviceName *string, device model.Device) (session *UserLogin, err error) {
+	now := date.CurrentUTCTime()
+	accessTTL, refreshTTL := accessTokenTTL(), refreshTokenTTL()
+
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return
+	}
+
+	token := &entity.RefreshToken{
+		UserId:     user.ID,
+		FamilyId:   familyId,
+		TokenHash:  hashToken(refreshToken),
+		DeviceName: deviceName,
+		UserAgent:  device.UserAgent,
+		IPAddress:  device.IPAddress,
+		ExpiresAt:  now.Add(refreshTTL),
+		CreatedAt:  *now,
+	}
+
+	if token.ID, err = u.RefreshTokenRepository.Insert(ctx, tx, token); err != nil {
+		return
+	}
+
+	jti, err := randomToken(16)
+	if err != nil {
+		return
+	}
+
+	claims := &model.UserBearer{}
+	claims.ID = user.ID
+	claims.Email = user.Email
+	claims.Name = user.Name
+	claims.IsDriver = user.IsDriver
+	claims.SessionId = familyId
+	claims.RegisteredClaims.ID = jti
+	claims.IssuedAt = jwtv5.NewNumericDate(*now)
+	claims.ExpiresAt = jwtv5.NewNumericDate(now.Add(accessTTL))
+
+	accessToken, err := u.JSONWebToken.CreateToken(ctx, claims)
+	if err != nil {
+		return
+	}
+
+	session = &UserLogin{
+		Name:     user.Name,
+		Email:    user.Email,
+		IsDriver: user.IsDriver,
+		Token: Token{
+			Value:     &accessToken,
+			ExpiresIn: int64(accessTTL.Seconds()),
+		},
+		RefreshToken: &Token{
+			Value:     &refreshToken,
+			ExpiresIn: int64(refreshTTL.Seconds()),
+		},
+	}
+
+	return
+}
+
+// RefreshSession rotates a refresh token: it can be used once and returns a new refresh and access token.
+// Using a rotated token again means it leaked, so the whole family is revoked.
+func (u *UsecaseImpl) RefreshSession(ctx context.Context, payload *model.RefreshSession) responses.Responses {
+
+	tx, err := u.Repository.BeginTx(ctx)
+	if err != nil {
+		u.Logger.WithContext(ctx).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	token, err := u.RefreshTokenRepository.FindByTokenHash(ctx, tx, hashToken(payload.RefreshToken))
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithContext(ctx).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	now := date.CurrentUTCTime()
+
+	if token == nil || token.RevokedAt != nil || now.After(token.ExpiresAt) {
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.Unathorized("INVALID_REFRESH_TOKEN").NewResponses(nil, "refresh token is invalid or expired, please login again")
+	}
+
+	fields := logrus.Fields{"userId": token.UserId, "familyId": token.FamilyId}
+
+	if token.UsedAt != nil {
+		if err := u.RefreshTokenRepository.RevokeFamily(ctx, tx, token.FamilyId, *now); err != nil {
+			u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+			u.Repository.RollbackTx(ctx, tx)
+			return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+		}
+
+		if err := u.Repository.CommitTx(ctx, tx); err != nil {
+			u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+			u.Repository.RollbackTx(ctx, tx)
+			return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+		}
+
+		u.Logger.WithContext(ctx).WithFields(fields).Warn("refresh token reused, session family revoked")
+		return httpResponse.Unathorized("INVALID_REFRESH_TOKEN").NewResponses(nil, "refresh token is invalid or expired, please login again")
+	}
+
+	if err := u.RefreshTokenRepository.MarkUsed(ctx, tx, token.ID, *now); err != nil {
+		u.Repository.RollbackTx(ctx, tx)
+		if err == exception.ErrConflict {
+			return httpResponse.Unathorized("INVALID_REFRESH_TOKEN").NewResponses(nil, "refresh token is invalid or expired, please login again")
+		}
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	user, err := u.Repository.FindOneById(ctx, token.UserId)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if user == nil {
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.Unathorized("INVALID_REFRESH_TOKEN").NewResponses(nil, "refresh token is invalid or expired, please login again")
+	}
+
+	session, err := u.issueSession(ctx, tx, user, token.FamilyId, token.DeviceName, payload.Device)
+	if err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if err := u.Repository.CommitTx(ctx, tx); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Ok("").NewResponses(session, "session refreshed")
+}
+
+// Logout revokes the refresh token family of the current session and its access token.
+func (u *UsecaseImpl) Logout(ctx context.Context) responses.Responses {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	now := date.CurrentUTCTime()
+
+	tx, err := u.Repository.BeginTx(ctx)
+	if err != nil {
+		u.Logger.WithContext(ctx).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if requester.SessionId != "" {
+		if err := u.RefreshTokenRepository.RevokeFamily(ctx, tx, requester.SessionId, *now); err != nil {
+			u.Logger.WithContext(ctx).WithField("requester", requester).Error(err)
+			u.Repository.RollbackTx(ctx, tx)
+			return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+		}
+	}
+
+	if err := u.denyAccessToken(ctx, tx, requester, now); err != nil {
+		u.Logger.WithContext(ctx).WithField("requester", requester).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if err := u.Repository.CommitTx(ctx, tx); err != nil {
+		u.Logger.WithContext(ctx).WithField("requester", requester).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	u.pruneRevokedTokens(ctx, now)
+
+	return httpResponse.Ok("").NewResponses(nil, "logged out")
+}
+
+// LogoutAll revokes every refresh token of the user and rejects every access token issued until now.
+func (u *UsecaseImpl) LogoutAll(ctx context.Context) responses.Responses {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	now := date.CurrentUTCTime()
+
+	tx, err := u.Repository.BeginTx(ctx)
+	if err != nil {
+		u.Logger.WithContext(ctx).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if err := u.RefreshTokenRepository.RevokeAllByUser(ctx, tx, requester.ID, *now); err != nil {
+		u.Logger.WithContext(ctx).WithField("requester", requester).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	updatedField := map[string]any{
+		"sessions_invalidated_at": now.Truncate(time.Second),
+	}
+
+	if err := u.Repository.Update(ctx, tx, requester.ID, updatedField); err != nil {
+		u.Logger.WithContext(ctx).WithField("requester", requester).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	// tokens issued within the current second pass the check above, the one in use is denied explicitly
+	if err := u.denyAccessToken(ctx, tx, requester, now); err != nil {
+		u.Logger.WithContext(ctx).WithField("requester", requester).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if err := u.Repository.CommitTx(ctx, tx); err != nil {
+		u.Logger.WithContext(ctx).WithField("requester", requester).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	u.pruneRevokedTokens(ctx, now)
+
+	return httpResponse.Ok("").NewResponses(nil, "logged out from all devices")
+}
+
+func (u *UsecaseImpl) denyAccessToken(ctx context.Context, tx *sql.Tx, requester *model.UserBearer, now *time.Time) (err error) {
+	if requester.RegisteredClaims.ID == "" {
+		return
+	}
+
+	expiresAt := now.Add(accessTokenTTL())
+	if requester.ExpiresAt != nil {
+		expiresAt = requester.ExpiresAt.Time
+	}
+
+	return u.RevokedTokenRepository.Insert(ctx, tx, requester.RegisteredClaims.ID, requester.ID, expiresAt)
+}
+
+// pruneRevokedTokens keeps the deny-list small, a failure only leaves stale entries behind.
+func (u *UsecaseImpl) pruneRevokedTokens(ctx context.Context, now *time.Time) {
+	if err := u.RevokedTokenRepository.DeleteExpired(ctx, *now); err != nil {
+		u.Logger.WithContext(ctx).Warn(err)
+	}
+}
@@ -0,0 +1,41 @@
+package users
+
+import (
+	"context"
+
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/middleware"
+	"github.com/Difaal21/nebeng-dong/model"
+)
+
+// SessionRevocation rejects session tokens that were logged out, or issued before the sessions of the user were invalidated.
+type SessionRevocation struct {
+	Repository             Repository
+	RevokedTokenRepository RevokedTokenRepository
+}
+
+func NewSessionRevocation(repo Repository, revokedTokenRepository RevokedTokenRepository) middleware.Revocation {
+	return &SessionRevocation{
+		Repository:             repo,
+		RevokedTokenRepository: revokedTokenRepository,
+	}
+}
+
+func (s *SessionRevocation) Revoked(ctx context.Context, claims *model.UserBearer) (revoked bool, err error) {
+	if claims.RegisteredClaims.ID != "" {
+		if revoked, err = s.RevokedTokenRepository.Exists(ctx, claims.RegisteredClaims.ID); err != nil || revoked {
+			return
+		}
+	}
+
+	invalidatedAt, err := s.Repository.FindSessionsInvalidatedAt(ctx, claims.ID)
+	if err == exception.ErrNotFound {
+		return true, nil
+	}
+	if err != nil || invalidatedAt == nil {
+		return false, err
+	}
+
+	// invalidatedAt is kept in whole seconds like iat, so a login right after a reset is still accepted
+	return claims.IssuedAt == nil || claims.IssuedAt.Time.Before(*invalidatedAt), nil
+}
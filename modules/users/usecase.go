@@ -3,17 +3,17 @@ package users
 import (
 	"context"
 	"database/sql"
-	"time"
 
 	"github.com/Difaal21/nebeng-dong/entity"
 	"github.com/Difaal21/nebeng-dong/exception"
 	"github.com/Difaal21/nebeng-dong/helpers/cryptography"
 	"github.com/Difaal21/nebeng-dong/helpers/date"
 	"github.com/Difaal21/nebeng-dong/jwt"
+	"github.com/Difaal21/nebeng-dong/mailer"
 	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/modules/otp"
 	"github.com/Difaal21/nebeng-dong/modules/vehicles"
 	"github.com/Difaal21/nebeng-dong/responses"
-	jwtv5 "github.com/golang-jwt/jwt/v5"
 	"github.com/sirupsen/logrus"
 )
 
@@ -25,23 +25,40 @@ type Usecase interface {
 	// TopUpCoinBalance(ctx context.Context, payload *model.TopUpCoinBalance) responses.Responses
 	ChangePhoneNumber(ctx context.Context, payload *model.ChangePhoneNumber) responses.Responses
 	ChangePassword(ctx context.Context, payload *model.ChangePassword) responses.Responses
+	VerifyEmail(ctx context.Context, payload *model.VerifyEmail) responses.Responses
+	ResendVerification(ctx context.Context) responses.Responses
+	ForgotPassword(ctx context.Context, payload *model.ForgotPassword) responses.Responses
+	ResetPassword(ctx context.Context, payload *model.ResetPassword) responses.Responses
+	RefreshSession(ctx context.Context, payload *model.RefreshSession) responses.Responses
+	Logout(ctx context.Context) responses.Responses
+	LogoutAll(ctx context.Context) responses.Responses
 
 	JoinAsDriver(ctx context.Context, payload *model.VehicleRegistration) responses.Responses
 }
 
 type UsecaseImpl struct {
-	Repository        Repository
-	Logger            *logrus.Logger
-	VehicleRepository vehicles.Repository
-	JSONWebToken      jwt.JSONWebToken
+	Repository              Repository
+	Logger                  *logrus.Logger
+	VehicleRepository       vehicles.Repository
+	JSONWebToken            jwt.JSONWebToken
+	Mailer                  mailer.Mailer
+	PasswordResetRepository PasswordResetRepository
+	RefreshTokenRepository  RefreshTokenRepository
+	RevokedTokenRepository  RevokedTokenRepository
+	OTPService              otp.Service
 }
 
-func NewUsecaseImpl(repo Repository, logger *logrus.Logger, vehicleRepository vehicles.Repository, jwt jwt.JSONWebToken) Usecase {
+func NewUsecaseImpl(repo Repository, logger *logrus.Logger, vehicleRepository vehicles.Repository, jwt jwt.JSONWebToken, mailer mailer.Mailer, passwordResetRepository PasswordResetRepository, refreshTokenRepository RefreshTokenRepository, revokedTokenRepository RevokedTokenRepository, otpService otp.Service) Usecase {
 	return &UsecaseImpl{
-		Repository:        repo,
-		Logger:            logger,
-		VehicleRepository: vehicleRepository,
-		JSONWebToken:      jwt,
+		Repository:              repo,
+		Logger:                  logger,
+		VehicleRepository:       vehicleRepository,
+		JSONWebToken:            jwt,
+		Mailer:                  mailer,
+		PasswordResetRepository: passwordResetRepository,
+		RefreshTokenRepository:  refreshTokenRepository,
+		RevokedTokenRepository:  revokedTokenRepository,
+		OTPService:              otpService,
 	}
 }
 
@@ -87,6 +104,7 @@ func (u *UsecaseImpl) UserRegistration(ctx context.Context, payload *model.UserR
 		IsDriver:        *payload.IsDriver,
 		IsEmailVerified: false,
 		EmailVerifiedAt: nil,
+		IsPhoneVerified: true,
 		CreatedAt:       *date.CurrentUTCTime(),
 		UpdatedAt:       nil,
 	}
@@ -96,7 +114,13 @@ func (u *UsecaseImpl) UserRegistration(ctx context.Context, payload *model.UserR
 		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
 
+	if resp := u.verifyPhoneNumber(ctx, tx, payload.PhoneNumber, otp.PurposeRegistration, payload.OTPCode); resp != nil {
+		u.Repository.RollbackTx(ctx, tx)
+		return resp
+	}
+
 	userId, err := u.Repository.Insert(ctx, tx, &user)
+	user.ID = userId
 	if err != nil {
 		payload.Password = ""
 		u.Logger.WithContext(ctx).WithField("payload", user).Error(err)
@@ -112,17 +136,18 @@ func (u *UsecaseImpl) UserRegistration(ctx context.Context, payload *model.UserR
 			return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 		}
 
+		u.sendVerificationAfterRegistration(ctx, &user)
 		return httpResponse.Created("").NewResponses(nil, "Registration success")
 	}
 
 	vehicle := &entity.Vehicle{
 		UserId:       userId,
-		Type:         "motorcycle",
+		Type:         payload.VehicleType,
 		Model:        payload.VehicleModel,
 		LicensePlate: payload.VehicleLicensePlate,
 		Manufacture:  payload.VehicleManufature,
 		InUse:        true,
-		Capacity:     1,
+		Capacity:     payload.VehicleCapacity,
 		CreatedAt:    user.CreatedAt,
 	}
 
@@ -131,6 +156,11 @@ func (u *UsecaseImpl) UserRegistration(ctx context.Context, payload *model.UserR
 		return httpResponse.BadRequest("").NewResponses(nil, err.Error())
 	}
 
+	if err := VehicleSeatHandler(vehicle); err != nil {
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.BadRequest("").NewResponses(nil, err.Error())
+	}
+
 	isVehicleExist, err := u.VehicleRepository.FindOneByLicensePlate(ctx, payload.VehicleLicensePlate)
 	if err != nil && err != exception.ErrNotFound {
 		payload.Password = ""
@@ -159,6 +189,7 @@ func (u *UsecaseImpl) UserRegistration(ctx context.Context, payload *model.UserR
 		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
 
+	u.sendVerificationAfterRegistration(ctx, &user)
 	return httpResponse.Created("").NewResponses(nil, "Registration success")
 }
 
@@ -180,31 +211,24 @@ func (u *UsecaseImpl) UserLogin(ctx context.Context, payload *model.UserLogin) r
 		return httpResponse.BadRequest("").NewResponses(nil, "invalid credential")
 	}
 
-	expiresAt := time.Now().Add(time.Hour * 7)
-	claims := &model.UserBearer{}
-	claims.ID = user.ID
-	claims.Email = user.Email
-	claims.Name = user.Name
-	claims.IsDriver = user.IsDriver
-	claims.ExpiresAt = jwtv5.NewNumericDate(expiresAt)
+	familyId, err := randomToken(16)
+	if err != nil {
+		u.Logger.WithContext(ctx).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, "unexpected error")
+	}
+
+	var deviceName *string
+	if payload.DeviceName != "" {
+		deviceName = &payload.DeviceName
+	}
 
-	tokenString, err := u.JSONWebToken.CreateToken(ctx, claims)
+	result, err := u.issueSession(ctx, nil, user, familyId, deviceName, payload.Device)
 	if err != nil {
 		payload.Password = ""
 		u.Logger.WithFields(logrus.Fields{"body": payload}).Error(err.Error())
 		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
 
-	result := UserLogin{
-		Name:     user.Name,
-		Email:    user.Email,
-		IsDriver: user.IsDriver,
-		Token: Token{
-			Value:     &tokenString,
-			ExpiresIn: 60 * 60,
-		},
-	}
-
 	return httpResponse.Ok("").NewResponses(result, "Login success")
 }
 
@@ -295,12 +319,30 @@ func (u *UsecaseImpl) ChangePhoneNumber(ctx context.Context, payload *model.Chan
 		return httpResponse.Conflict("DUPLICATED_PHONE_NUMBER").NewResponses(nil, "duplicated phone number")
 	}
 
+	if tx, err = u.Repository.BeginTx(ctx); err != nil {
+		u.Logger.WithContext(ctx).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if resp := u.verifyPhoneNumber(ctx, tx, payload.New, otp.PurposeChangePhoneNumber, payload.OTPCode); resp != nil {
+		u.Repository.RollbackTx(ctx, tx)
+		return resp
+	}
+
 	updatedField := map[string]any{
-		"phone_number": payload.New,
+		"phone_number":      payload.New,
+		"is_phone_verified": true,
 	}
 
 	if err := u.Repository.Update(ctx, tx, requester.ID, updatedField); err != nil {
 		u.Logger.WithField("requester", payload).Error(err.Error())
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if err := u.Repository.CommitTx(ctx, tx); err != nil {
+		u.Logger.WithContext(ctx).WithField("requester", payload).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
 		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
 	}
 
@@ -400,15 +442,20 @@ func (u *UsecaseImpl) JoinAsDriver(ctx context.Context, payload *model.VehicleRe
 
 	vehicle := &entity.Vehicle{
 		UserId:       requester.ID,
-		Type:         "motorcycle",
+		Type:         payload.VehicleType,
 		Model:        payload.VehicleModel,
 		LicensePlate: payload.VehicleLicensePlate,
 		Manufacture:  payload.VehicleManufature,
 		InUse:        true,
-		Capacity:     1,
+		Capacity:     payload.VehicleCapacity,
 		CreatedAt:    *date.CurrentUTCTime(),
 	}
 
+	if err := VehicleSeatHandler(vehicle); err != nil {
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.BadRequest("").NewResponses(nil, err.Error())
+	}
+
 	_, err = u.VehicleRepository.Insert(ctx, tx, vehicle)
 	if err != nil {
 		u.Logger.WithContext(ctx).WithField("payload", vehicle).Error(err)
@@ -424,3 +471,16 @@ func (u *UsecaseImpl) JoinAsDriver(ctx context.Context, payload *model.VehicleRe
 
 	return httpResponse.Ok("").NewResponses(nil, "")
 }
+
+// verifyPhoneNumber uses up the code texted to the phone number, it returns nil once the number is proven.
+func (u *UsecaseImpl) verifyPhoneNumber(ctx context.Context, tx *sql.Tx, phoneNumber string, purpose string, code string) responses.Responses {
+	err := u.OTPService.Verify(ctx, tx, phoneNumber, purpose, code)
+	if invalid, ok := err.(*otp.Invalid); ok {
+		return httpResponse.BadRequest("INVALID_OTP").NewResponses(nil, invalid.Reason)
+	}
+	if err != nil {
+		u.Logger.WithContext(ctx).WithFields(logrus.Fields{"phoneNumber": phoneNumber, "purpose": purpose}).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+	return nil
+}
@@ -13,3 +13,28 @@ func VehicleNullHandler(vehicle *entity.Vehicle) (err error) {
 
 	return
 }
+
+const (
+	defaultVehicleType = "motorcycle"
+	defaultCarCapacity = 4
+)
+
+// VehicleSeatHandler fills the default type and passenger seats of a vehicle and rejects seats a motorcycle cannot offer.
+func VehicleSeatHandler(vehicle *entity.Vehicle) (err error) {
+	if vehicle.Type == "" {
+		vehicle.Type = defaultVehicleType
+	}
+
+	if vehicle.Capacity < 1 {
+		vehicle.Capacity = 1
+		if vehicle.Type == "car" {
+			vehicle.Capacity = defaultCarCapacity
+		}
+	}
+
+	if vehicle.Type == "motorcycle" && vehicle.Capacity > 1 {
+		return fmt.Errorf("a motorcycle can only carry one passenger")
+	}
+
+	return
+}
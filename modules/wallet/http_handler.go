@@ -0,0 +1,100 @@
+package wallet
+
+import (
+	"strconv"
+
+	"github.com/Difaal21/nebeng-dong/helpers/validation"
+	"github.com/Difaal21/nebeng-dong/middleware"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/responses"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+var httpResponse = responses.HttpResponseStatusCodesImpl{}
+
+type HTTPHandler struct {
+	Usecase Usecase
+	Session *middleware.Session
+}
+
+func NewHTTPHandler(router *gin.Engine, session *middleware.Session, usecase Usecase) {
+
+	handler := &HTTPHandler{
+		Usecase: usecase,
+		Session: session,
+	}
+
+	router.GET("/nebengdong-service/v1/users/wallet/transactions", session.Verify, handler.GetTransactions)
+	router.POST("/nebengdong-service/v1/users/wallet/top-ups", session.Verify, handler.CreateTopUp)
+	router.GET("/nebengdong-service/v1/users/wallet/top-ups/:orderId", session.Verify, handler.GetTopUp)
+
+	// called by the payment gateway, authenticated by the notification signature
+	router.POST("/nebengdong-service/v1/payment-gateway/notifications", handler.HandleNotification)
+}
+
+func (handler *HTTPHandler) GetTransactions(c *gin.Context) {
+	context := c.Request.Context()
+
+	queryString := c.Request.URL.Query()
+
+	page, _ := strconv.Atoi(queryString.Get("page"))
+	size, _ := strconv.Atoi(queryString.Get("size"))
+
+	params := model.GetWalletTransactionsParams{
+		Page: int64(page),
+		Size: int64(size),
+	}
+
+	if err := c.ShouldBind(&params); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, params)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.GetTransactions(context, &params)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) CreateTopUp(c *gin.Context) {
+	context := c.Request.Context()
+
+	var payload model.CreateTopUp
+
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, payload)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.CreateTopUp(context, &payload)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) GetTopUp(c *gin.Context) {
+	context := c.Request.Context()
+
+	resp := handler.Usecase.GetTopUp(context, c.Param("orderId"))
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) HandleNotification(c *gin.Context) {
+	context := c.Request.Context()
+
+	body, err := c.GetRawData()
+	if err != nil {
+		responses.REST(c, httpResponse.BadRequest("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.HandleNotification(context, body)
+	responses.REST(c, resp)
+}
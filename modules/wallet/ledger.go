@@ -0,0 +1,208 @@
+package wallet
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/helpers/date"
+	"github.com/Difaal21/nebeng-dong/modules/users"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	EntryCredit = "credit"
+	EntryDebit  = "debit"
+
+	ReferencePayment        = "payment"
+	ReferenceTopUp          = "top_up"
+	ReferenceAdmin          = "admin"
+	ReferenceReconciliation = "reconciliation"
+	ReferenceWithdrawal     = "withdrawal"
+)
+
+// Entry describes one balance movement and what caused it.
+type Entry struct {
+	UserId        int64
+	Amount        int64
+	ReferenceType string
+	ReferenceId   *int64
+	Description   string
+	// AllowNegative lets a debit take the balance below zero, e.g. fees owed by a driver after a trip.
+	AllowNegative bool
+}
+
+// Discrepancy compares users.coin with the balance derived from the ledger of one user.
+type Discrepancy struct {
+	UserId        int64 `json:"userId"`
+	Coin          int64 `json:"coin"`
+	LedgerBalance int64 `json:"ledgerBalance"`
+	Difference    int64 `json:"difference"`
+}
+
+// Ledger is the only place that should change users.coin, every change is appended to wallet_transactions.
+type Ledger interface {
+	Credit(ctx context.Context, tx *sql.Tx, entry *Entry) (transaction *entity.WalletTransaction, err error)
+	Debit(ctx context.Context, tx *sql.Tx, entry *Entry) (transaction *entity.WalletTransaction, err error)
+	Compare(ctx context.Context, userId int64) (discrepancy *Discrepancy, err error)
+	Reconcile(ctx context.Context, userId int64, difference int64, reason string) (adjustment *entity.WalletTransaction, err error)
+}
+
+type LedgerImpl struct {
+	Repository     Repository
+	UserRepository users.Repository
+	Logger         *logrus.Logger
+}
+
+func NewLedgerImpl(repo Repository, userRepository users.Repository, logger *logrus.Logger) Ledger {
+	return &LedgerImpl{
+		Repository:     repo,
+		UserRepository: userRepository,
+		Logger:         logger,
+	}
+}
+
+// Credit adds the amount to the user's balance. With a nil tx the ledger runs its own transaction.
+func (l *LedgerImpl) Credit(ctx context.Context, tx *sql.Tx, entry *Entry) (transaction *entity.WalletTransaction, err error) {
+	return l.apply(ctx, tx, EntryCredit, entry)
+}
+
+// Debit takes the amount from the user's balance. With a nil tx the ledger runs its own transaction.
+func (l *LedgerImpl) Debit(ctx context.Context, tx *sql.Tx, entry *Entry) (transaction *entity.WalletTransaction, err error) {
+	return l.apply(ctx, tx, EntryDebit, entry)
+}
+
+func (l *LedgerImpl) apply(ctx context.Context, tx *sql.Tx, entryType string, entry *Entry) (transaction *entity.WalletTransaction, err error) {
+	ownTx := tx == nil
+	if ownTx {
+		if tx, err = l.Repository.BeginTx(ctx); err != nil {
+			return
+		}
+	}
+
+	rollback := func() {
+		if ownTx {
+			l.Repository.RollbackTx(ctx, tx)
+		}
+	}
+
+	var balance int64
+	if entryType == EntryDebit {
+		balance, err = l.UserRepository.DecrementCoin(ctx, tx, entry.UserId, entry.Amount, entry.AllowNegative)
+	} else {
+		balance, err = l.UserRepository.IncrementCoin(ctx, tx, entry.UserId, entry.Amount)
+	}
+
+	if err != nil {
+		rollback()
+		return
+	}
+
+	transaction = &entity.WalletTransaction{
+		UserId:        entry.UserId,
+		EntryType:     entryType,
+		Amount:        entry.Amount,
+		BalanceAfter:  balance,
+		ReferenceType: entry.ReferenceType,
+		ReferenceId:   entry.ReferenceId,
+		Description:   entry.Description,
+		CreatedAt:     *date.CurrentUTCTime(),
+	}
+
+	if transaction.ID, err = l.Repository.Insert(ctx, tx, transaction); err != nil {
+		rollback()
+		return
+	}
+
+	if ownTx {
+		if err = l.Repository.CommitTx(ctx, tx); err != nil {
+			l.Repository.RollbackTx(ctx, tx)
+			return
+		}
+	}
+
+	return
+}
+
+// Compare reports how far users.coin drifted from the ledger without changing either.
+func (l *LedgerImpl) Compare(ctx context.Context, userId int64) (discrepancy *Discrepancy, err error) {
+	tx, err := l.Repository.BeginTx(ctx)
+	if err != nil {
+		return
+	}
+
+	// only reads, the transaction just keeps both reads on the same locked row
+	defer l.Repository.RollbackTx(ctx, tx)
+
+	return l.discrepancy(ctx, tx, userId)
+}
+
+// Reconcile appends an adjustment so the ledger matches users.coin, e.g. for balances that existed before the ledger.
+// difference is the one the administrator reviewed through Compare, exception.ErrConflict is returned when the
+// balance moved since. The reason is kept on the adjustment.
+func (l *LedgerImpl) Reconcile(ctx context.Context, userId int64, difference int64, reason string) (adjustment *entity.WalletTransaction, err error) {
+	tx, err := l.Repository.BeginTx(ctx)
+	if err != nil {
+		return
+	}
+
+	discrepancy, err := l.discrepancy(ctx, tx, userId)
+	if err != nil {
+		l.Repository.RollbackTx(ctx, tx)
+		return
+	}
+
+	if discrepancy.Difference != difference || difference == 0 {
+		l.Repository.RollbackTx(ctx, tx)
+		return nil, exception.ErrConflict
+	}
+
+	adjustment = &entity.WalletTransaction{
+		UserId:        userId,
+		EntryType:     EntryCredit,
+		Amount:        difference,
+		BalanceAfter:  discrepancy.Coin,
+		ReferenceType: ReferenceReconciliation,
+		Description:   "balance reconciled against ledger: " + reason,
+		CreatedAt:     *date.CurrentUTCTime(),
+	}
+
+	if difference < 0 {
+		adjustment.EntryType = EntryDebit
+		adjustment.Amount = -difference
+	}
+
+	if adjustment.ID, err = l.Repository.Insert(ctx, tx, adjustment); err != nil {
+		l.Repository.RollbackTx(ctx, tx)
+		return
+	}
+
+	if err = l.Repository.CommitTx(ctx, tx); err != nil {
+		l.Repository.RollbackTx(ctx, tx)
+		return
+	}
+
+	return
+}
+
+// discrepancy locks the user row, so no balance change slips in between reading the coin and the ledger.
+func (l *LedgerImpl) discrepancy(ctx context.Context, tx *sql.Tx, userId int64) (discrepancy *Discrepancy, err error) {
+	// a zero increment takes the row lock and returns the current coin
+	coin, err := l.UserRepository.IncrementCoin(ctx, tx, userId, 0)
+	if err != nil {
+		return
+	}
+
+	ledgerBalance, err := l.Repository.SumByUser(ctx, tx, userId)
+	if err != nil {
+		return
+	}
+
+	return &Discrepancy{
+		UserId:        userId,
+		Coin:          coin,
+		LedgerBalance: ledgerBalance,
+		Difference:    coin - ledgerBalance,
+	}, nil
+}
@@ -0,0 +1,212 @@
+package wallet
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/sirupsen/logrus"
+)
+
+type Repository interface {
+	BeginTx(ctx context.Context) (tx *sql.Tx, err error)
+	RollbackTx(ctx context.Context, tx *sql.Tx) (err error)
+	CommitTx(ctx context.Context, tx *sql.Tx) (err error)
+
+	Insert(ctx context.Context, tx *sql.Tx, transaction *entity.WalletTransaction) (id int64, err error)
+	FindManyByUser(ctx context.Context, userId int64, params *model.GetWalletTransactionsParams) (transactions []entity.WalletTransaction, err error)
+	CountByUser(ctx context.Context, userId int64) (total int64, err error)
+	SumByUser(ctx context.Context, tx *sql.Tx, userId int64) (balance int64, err error)
+}
+
+type RepositoryImpl struct {
+	DB        *sql.DB
+	Logger    *logrus.Logger
+	TableName string
+}
+
+func NewRepositoryImpl(db *sql.DB, logger *logrus.Logger) Repository {
+	return &RepositoryImpl{
+		DB:        db,
+		Logger:    logger,
+		TableName: "wallet_transactions",
+	}
+}
+
+func (repo *RepositoryImpl) BeginTx(ctx context.Context) (tx *sql.Tx, err error) {
+	return repo.DB.BeginTx(ctx, nil)
+}
+
+func (repo *RepositoryImpl) RollbackTx(ctx context.Context, tx *sql.Tx) (err error) {
+	return tx.Rollback()
+}
+
+func (repo *RepositoryImpl) CommitTx(ctx context.Context, tx *sql.Tx) (err error) {
+	return tx.Commit()
+}
+
+func (repo *RepositoryImpl) Insert(ctx context.Context, tx *sql.Tx, transaction *entity.WalletTransaction) (id int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`
+	INSERT INTO %s
+	SET
+		id = ?,
+		user_id = ?,
+		entry_type = ?,
+		amount = ?,
+		balance_after = ?,
+		reference_type = ?,
+		reference_id = ?,
+		description = ?,
+		created_at = ?
+	`, repo.TableName)
+
+	result, err := Exec(ctx, cmd, command, transaction.ID, transaction.UserId, transaction.EntryType, transaction.Amount, transaction.BalanceAfter, transaction.ReferenceType, transaction.ReferenceId, transaction.Description, transaction.CreatedAt)
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return
+	}
+
+	if id, err = result.LastInsertId(); err != nil {
+		return
+	}
+	return
+}
+
+func (repo *RepositoryImpl) FindManyByUser(ctx context.Context, userId int64, params *model.GetWalletTransactionsParams) (transactions []entity.WalletTransaction, err error) {
+	var cmd SqlCommand = repo.DB
+
+	var offset = (params.Page - 1) * params.Size
+
+	query := fmt.Sprintf(`
+	SELECT
+		wt.id,
+		wt.user_id,
+		wt.entry_type,
+		wt.amount,
+		wt.balance_after,
+		wt.reference_type,
+		wt.reference_id,
+		wt.description,
+		wt.created_at
+	FROM
+		%s wt
+	WHERE
+		wt.user_id = ?
+	ORDER BY
+		wt.id DESC
+	LIMIT %d OFFSET %d
+	`, repo.TableName, params.Size, offset)
+
+	return repo.Query(ctx, cmd, query, userId)
+}
+
+func (repo *RepositoryImpl) CountByUser(ctx context.Context, userId int64) (total int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	query := fmt.Sprintf(`SELECT COUNT(wt.id) FROM %s wt WHERE wt.user_id = ?`, repo.TableName)
+
+	if err = cmd.QueryRowContext(ctx, query, userId).Scan(&total); err != nil {
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return
+	}
+
+	return
+}
+
+// SumByUser derives the balance of a user from the ledger, credits minus debits.
+func (repo *RepositoryImpl) SumByUser(ctx context.Context, tx *sql.Tx, userId int64) (balance int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+		COALESCE(SUM(CASE WHEN wt.entry_type = 'credit' THEN wt.amount ELSE -wt.amount END), 0)
+	FROM
+		%s wt
+	WHERE
+		wt.user_id = ?
+	`, repo.TableName)
+
+	if err = cmd.QueryRowContext(ctx, query, userId).Scan(&balance); err != nil {
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return
+	}
+
+	return
+}
+
+// ==================================================================================================================== //
+type SqlCommand interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// ==================================================================================================================== //
+
+func Exec(ctx context.Context, cmd SqlCommand, command string, args ...interface{}) (result sql.Result, err error) {
+	var stmt *sql.Stmt
+	if stmt, err = cmd.PrepareContext(ctx, command); err != nil {
+		return
+	}
+
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			return
+		}
+	}()
+
+	if result, err = stmt.ExecContext(ctx, args...); err != nil {
+		return
+	}
+
+	return
+}
+
+func (repo *RepositoryImpl) Query(ctx context.Context, cmd SqlCommand, query string, args ...interface{}) (transactions []entity.WalletTransaction, err error) {
+
+	var rows *sql.Rows
+	if rows, err = cmd.QueryContext(ctx, query, args...); err != nil {
+		repo.Logger.Error(err.Error())
+		return
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+	}()
+
+	for rows.Next() {
+		var transaction entity.WalletTransaction
+
+		err = rows.Scan(&transaction.ID, &transaction.UserId, &transaction.EntryType, &transaction.Amount, &transaction.BalanceAfter, &transaction.ReferenceType, &transaction.ReferenceId, &transaction.Description, &transaction.CreatedAt)
+		if err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+
+		transactions = append(transactions, transaction)
+	}
+
+	if transactions == nil {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
@@ -0,0 +1,191 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/helpers/date"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/modules/payment/gateway"
+	"github.com/Difaal21/nebeng-dong/responses"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultTopUpExpiry = time.Hour
+
+func topUpExpiry() time.Duration {
+	if minutes, err := strconv.ParseInt(os.Getenv("TOP_UP_EXPIRY_MINUTES"), 10, 64); err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return defaultTopUpExpiry
+}
+
+func (u *UsecaseImpl) CreateTopUp(ctx context.Context, payload *model.CreateTopUp) responses.Responses {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	now := date.CurrentUTCTime()
+	charge := &gateway.Charge{
+		OrderId:       fmt.Sprintf("TOPUP-%d-%d", requester.ID, now.UnixNano()),
+		Amount:        payload.Amount,
+		CustomerName:  requester.Name,
+		CustomerEmail: requester.Email,
+		ExpiresIn:     topUpExpiry(),
+	}
+
+	result, err := u.Gateway.CreateCharge(ctx, charge)
+	if err != nil {
+		u.Logger.WithContext(ctx).WithFields(logrus.Fields{"requester": requester, "charge": charge}).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, "payment gateway is unavailable, please retry")
+	}
+
+	topUp := &entity.TopUp{
+		UserId:      requester.ID,
+		OrderId:     result.OrderId,
+		Provider:    u.Gateway.Name(),
+		Amount:      payload.Amount,
+		Status:      gateway.StatusPending,
+		Token:       result.Token,
+		RedirectURL: result.RedirectURL,
+		CreatedAt:   *now,
+	}
+
+	if topUp.ID, err = u.TopUpRepository.Insert(ctx, nil, topUp); err != nil {
+		u.Logger.WithContext(ctx).WithFields(logrus.Fields{"requester": requester, "topUp": topUp}).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Created("").NewResponses(topUp, "top up created, complete the payment on the redirect url")
+}
+
+// GetTopUp returns a top-up of the requester. A pending one is checked with the gateway first,
+// so a missed notification does not leave the wallet uncredited.
+func (u *UsecaseImpl) GetTopUp(ctx context.Context, orderId string) responses.Responses {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	topUp, err := u.TopUpRepository.FindByOrderId(ctx, nil, orderId)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithContext(ctx).WithField("orderId", orderId).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if topUp == nil || topUp.UserId != requester.ID {
+		return httpResponse.NotFound("").NewResponses(nil, "top up not found")
+	}
+
+	if topUp.Status != gateway.StatusPending {
+		return httpResponse.Ok("").NewResponses(topUp, "get top up success")
+	}
+
+	notification, err := u.Gateway.Status(ctx, orderId)
+	if err != nil {
+		// the stored state is still correct, only not refreshed
+		u.Logger.WithContext(ctx).WithField("topUp", topUp).Warn(err)
+		return httpResponse.Ok("").NewResponses(topUp, "get top up success")
+	}
+
+	if topUp, err = u.settleTopUp(ctx, notification); err != nil {
+		u.Logger.WithContext(ctx).WithField("notification", notification).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Ok("").NewResponses(topUp, "get top up success")
+}
+
+// HandleNotification applies a webhook of the payment gateway. Providers retry notifications,
+// so settling an already settled top-up is acknowledged without crediting the wallet again.
+func (u *UsecaseImpl) HandleNotification(ctx context.Context, body []byte) responses.Responses {
+
+	notification, err := u.Gateway.VerifyNotification(ctx, body)
+	if err != nil {
+		if err == gateway.ErrInvalidSignature {
+			return httpResponse.Unathorized("").NewResponses(nil, err.Error())
+		}
+		u.Logger.WithContext(ctx).Error(err)
+		return httpResponse.BadRequest("").NewResponses(nil, err.Error())
+	}
+
+	topUp, err := u.settleTopUp(ctx, notification)
+	if err != nil {
+		if err == exception.ErrNotFound {
+			return httpResponse.NotFound("").NewResponses(nil, "top up not found")
+		}
+		u.Logger.WithContext(ctx).WithField("notification", notification).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Ok("").NewResponses(topUp, "notification received")
+}
+
+// settleTopUp moves a pending top-up to the status reported by the gateway and credits the wallet once it settles.
+func (u *UsecaseImpl) settleTopUp(ctx context.Context, notification *gateway.Notification) (topUp *entity.TopUp, err error) {
+
+	tx, err := u.Repository.BeginTx(ctx)
+	if err != nil {
+		return
+	}
+
+	if topUp, err = u.TopUpRepository.FindByOrderId(ctx, tx, notification.OrderId); err != nil {
+		u.Repository.RollbackTx(ctx, tx)
+		return
+	}
+
+	if topUp.Status != gateway.StatusPending || notification.Status == gateway.StatusPending {
+		u.Repository.RollbackTx(ctx, tx)
+		return
+	}
+
+	if notification.Status == gateway.StatusSettled && notification.Amount != topUp.Amount {
+		u.Repository.RollbackTx(ctx, tx)
+		return nil, fmt.Errorf("top up %s settled %d instead of %d", topUp.OrderId, notification.Amount, topUp.Amount)
+	}
+
+	if err = u.TopUpRepository.UpdateStatus(ctx, tx, topUp.ID, notification.Status, notification.TransactionId); err != nil {
+		u.Repository.RollbackTx(ctx, tx)
+		return
+	}
+
+	if notification.Status == gateway.StatusSettled {
+		entry := &Entry{
+			UserId:        topUp.UserId,
+			Amount:        topUp.Amount,
+			ReferenceType: ReferenceTopUp,
+			ReferenceId:   &topUp.ID,
+			Description:   fmt.Sprintf("top up via %s", topUp.Provider),
+		}
+
+		if _, err = u.Ledger.Credit(ctx, tx, entry); err != nil {
+			u.Repository.RollbackTx(ctx, tx)
+			return
+		}
+	}
+
+	if err = u.Repository.CommitTx(ctx, tx); err != nil {
+		u.Repository.RollbackTx(ctx, tx)
+		return
+	}
+
+	topUp.Status = notification.Status
+	if notification.TransactionId != "" {
+		topUp.TransactionId = &notification.TransactionId
+	}
+	if notification.Status == gateway.StatusSettled {
+		topUp.SettledAt = date.CurrentUTCTime()
+	}
+
+	return
+}
@@ -0,0 +1,135 @@
+package wallet
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/sirupsen/logrus"
+)
+
+type TopUpRepository interface {
+	Insert(ctx context.Context, tx *sql.Tx, topUp *entity.TopUp) (id int64, err error)
+	// FindByOrderId locks the top-up row when tx is given, so concurrent notifications settle it only once.
+	FindByOrderId(ctx context.Context, tx *sql.Tx, orderId string) (topUp *entity.TopUp, err error)
+	UpdateStatus(ctx context.Context, tx *sql.Tx, id int64, status string, transactionId string) (err error)
+}
+
+type TopUpRepositoryImpl struct {
+	DB        *sql.DB
+	Logger    *logrus.Logger
+	TableName string
+}
+
+func NewTopUpRepositoryImpl(db *sql.DB, logger *logrus.Logger) TopUpRepository {
+	return &TopUpRepositoryImpl{
+		DB:        db,
+		Logger:    logger,
+		TableName: "top_ups",
+	}
+}
+
+func (repo *TopUpRepositoryImpl) Insert(ctx context.Context, tx *sql.Tx, topUp *entity.TopUp) (id int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`
+	INSERT INTO %s
+	SET
+		id = ?,
+		user_id = ?,
+		order_id = ?,
+		provider = ?,
+		amount = ?,
+		status = ?,
+		token = ?,
+		redirect_url = ?,
+		created_at = ?
+	`, repo.TableName)
+
+	result, err := Exec(ctx, cmd, command, topUp.ID, topUp.UserId, topUp.OrderId, topUp.Provider, topUp.Amount, topUp.Status, topUp.Token, topUp.RedirectURL, topUp.CreatedAt)
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return
+	}
+
+	if id, err = result.LastInsertId(); err != nil {
+		return
+	}
+	return
+}
+
+func (repo *TopUpRepositoryImpl) FindByOrderId(ctx context.Context, tx *sql.Tx, orderId string) (topUp *entity.TopUp, err error) {
+	var cmd SqlCommand = repo.DB
+
+	lock := ""
+	if tx != nil {
+		cmd = tx
+		lock = "FOR UPDATE"
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+		t.id,
+		t.user_id,
+		t.order_id,
+		t.provider,
+		t.amount,
+		t.status,
+		t.transaction_id,
+		t.token,
+		t.redirect_url,
+		t.created_at,
+		t.settled_at
+	FROM
+		%s t
+	WHERE
+		t.order_id = ?
+	%s
+	`, repo.TableName, lock)
+
+	topUp = &entity.TopUp{}
+	err = cmd.QueryRowContext(ctx, query, orderId).Scan(&topUp.ID, &topUp.UserId, &topUp.OrderId, &topUp.Provider, &topUp.Amount, &topUp.Status, &topUp.TransactionId, &topUp.Token, &topUp.RedirectURL, &topUp.CreatedAt, &topUp.SettledAt)
+	if err != nil {
+		topUp = nil
+		if err == sql.ErrNoRows {
+			return nil, exception.ErrNotFound
+		}
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return
+	}
+
+	return
+}
+
+// UpdateStatus records the outcome reported by the gateway, a settled top-up also gets its settled_at.
+func (repo *TopUpRepositoryImpl) UpdateStatus(ctx context.Context, tx *sql.Tx, id int64, status string, transactionId string) (err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`
+	UPDATE
+		%s
+	SET
+		status = ?,
+		transaction_id = NULLIF(?, ''),
+		settled_at = IF(? = 'settled', UTC_TIMESTAMP(), settled_at)
+	WHERE
+		id = ?
+	`, repo.TableName)
+
+	if _, err = Exec(ctx, cmd, command, status, transactionId, status, id); err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	return
+}
@@ -0,0 +1,63 @@
+package wallet
+
+import (
+	"context"
+
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/modules/payment/gateway"
+	"github.com/Difaal21/nebeng-dong/responses"
+	"github.com/sirupsen/logrus"
+)
+
+type Usecase interface {
+	GetTransactions(ctx context.Context, params *model.GetWalletTransactionsParams) responses.Responses
+	CreateTopUp(ctx context.Context, payload *model.CreateTopUp) responses.Responses
+	GetTopUp(ctx context.Context, orderId string) responses.Responses
+	HandleNotification(ctx context.Context, body []byte) responses.Responses
+}
+
+type UsecaseImpl struct {
+	Repository      Repository
+	TopUpRepository TopUpRepository
+	Ledger          Ledger
+	Gateway         gateway.Gateway
+	Logger          *logrus.Logger
+}
+
+func NewUsecaseImpl(repo Repository, topUpRepository TopUpRepository, ledger Ledger, paymentGateway gateway.Gateway, logger *logrus.Logger) Usecase {
+	return &UsecaseImpl{
+		Repository:      repo,
+		TopUpRepository: topUpRepository,
+		Ledger:          ledger,
+		Gateway:         paymentGateway,
+		Logger:          logger,
+	}
+}
+
+func (u *UsecaseImpl) GetTransactions(ctx context.Context, params *model.GetWalletTransactionsParams) responses.Responses {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	totalData, err := u.Repository.CountByUser(ctx, requester.ID)
+	if err != nil {
+		u.Logger.WithFields(logrus.Fields{"requester": requester, "params": params}).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	transactions, err := u.Repository.FindManyByUser(ctx, requester.ID, params)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithFields(logrus.Fields{"requester": requester, "params": params}).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if transactions == nil {
+		return httpResponse.NotFound("").NewResponses(nil, "no wallet transaction")
+	}
+
+	return httpResponse.Ok("").NewResponsesOffsetPagination(transactions, int64(len(transactions)), totalData, "get wallet transactions success")
+}
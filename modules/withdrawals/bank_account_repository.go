@@ -0,0 +1,149 @@
+package withdrawals
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
+)
+
+type BankAccountRepository interface {
+	Insert(ctx context.Context, tx *sql.Tx, account *entity.BankAccount) (id int64, err error)
+	FindOne(ctx context.Context, id int64) (account *entity.BankAccount, err error)
+	FindManyByUser(ctx context.Context, userId int64) (accounts []entity.BankAccount, err error)
+}
+
+type BankAccountRepositoryImpl struct {
+	DB        *sql.DB
+	Logger    *logrus.Logger
+	TableName string
+}
+
+func NewBankAccountRepositoryImpl(db *sql.DB, logger *logrus.Logger) BankAccountRepository {
+	return &BankAccountRepositoryImpl{
+		DB:        db,
+		Logger:    logger,
+		TableName: "bank_accounts",
+	}
+}
+
+func (repo *BankAccountRepositoryImpl) Insert(ctx context.Context, tx *sql.Tx, account *entity.BankAccount) (id int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`
+	INSERT INTO %s
+	SET
+		id = ?,
+		user_id = ?,
+		bank_code = ?,
+		account_number = ?,
+		account_holder = ?,
+		created_at = ?
+	`, repo.TableName)
+
+	result, err := Exec(ctx, cmd, command, account.ID, account.UserId, account.BankCode, account.AccountNumber, account.AccountHolder, account.CreatedAt)
+	if err != nil {
+		if driverErr, ok := err.(*mysql.MySQLError); ok && driverErr.Number == 1062 {
+			return 0, exception.ErrConflict
+		}
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return
+	}
+
+	if id, err = result.LastInsertId(); err != nil {
+		return
+	}
+	return
+}
+
+func (repo *BankAccountRepositoryImpl) FindOne(ctx context.Context, id int64) (account *entity.BankAccount, err error) {
+	var cmd SqlCommand = repo.DB
+
+	query := fmt.Sprintf(`
+	SELECT
+		ba.id,
+		ba.user_id,
+		ba.bank_code,
+		ba.account_number,
+		ba.account_holder,
+		ba.created_at
+	FROM
+		%s ba
+	WHERE
+		ba.id = ?
+	`, repo.TableName)
+
+	accounts, err := repo.Query(ctx, cmd, query, id)
+	if err != nil {
+		return
+	}
+
+	account = &accounts[len(accounts)-1]
+
+	return
+}
+
+func (repo *BankAccountRepositoryImpl) FindManyByUser(ctx context.Context, userId int64) (accounts []entity.BankAccount, err error) {
+	var cmd SqlCommand = repo.DB
+
+	query := fmt.Sprintf(`
+	SELECT
+		ba.id,
+		ba.user_id,
+		ba.bank_code,
+		ba.account_number,
+		ba.account_holder,
+		ba.created_at
+	FROM
+		%s ba
+	WHERE
+		ba.user_id = ?
+	ORDER BY
+		ba.id DESC
+	`, repo.TableName)
+
+	return repo.Query(ctx, cmd, query, userId)
+}
+
+func (repo *BankAccountRepositoryImpl) Query(ctx context.Context, cmd SqlCommand, query string, args ...interface{}) (accounts []entity.BankAccount, err error) {
+
+	var rows *sql.Rows
+	if rows, err = cmd.QueryContext(ctx, query, args...); err != nil {
+		repo.Logger.Error(err.Error())
+		return
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+	}()
+
+	for rows.Next() {
+		var account entity.BankAccount
+
+		err = rows.Scan(&account.ID, &account.UserId, &account.BankCode, &account.AccountNumber, &account.AccountHolder, &account.CreatedAt)
+		if err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+
+		accounts = append(accounts, account)
+	}
+
+	if accounts == nil {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
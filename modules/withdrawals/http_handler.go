@@ -0,0 +1,105 @@
+package withdrawals
+
+import (
+	"strconv"
+
+	"github.com/Difaal21/nebeng-dong/helpers/validation"
+	"github.com/Difaal21/nebeng-dong/middleware"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/responses"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+var httpResponse = responses.HttpResponseStatusCodesImpl{}
+
+type HTTPHandler struct {
+	Usecase Usecase
+	Session *middleware.Session
+}
+
+func NewHTTPHandler(router *gin.Engine, session *middleware.Session, usecase Usecase) {
+
+	handler := &HTTPHandler{
+		Usecase: usecase,
+		Session: session,
+	}
+
+	router.POST("/nebengdong-service/v1/users/bank-accounts", session.Verify, handler.AddBankAccount)
+	router.GET("/nebengdong-service/v1/users/bank-accounts", session.Verify, handler.GetBankAccounts)
+	router.POST("/nebengdong-service/v1/users/withdrawals", session.Verify, handler.RequestWithdrawal)
+	router.GET("/nebengdong-service/v1/users/withdrawals", session.Verify, handler.GetWithdrawals)
+}
+
+func (handler *HTTPHandler) AddBankAccount(c *gin.Context) {
+	context := c.Request.Context()
+
+	var payload model.CreateBankAccount
+
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, payload)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.AddBankAccount(context, &payload)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) GetBankAccounts(c *gin.Context) {
+	context := c.Request.Context()
+
+	resp := handler.Usecase.GetBankAccounts(context)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) RequestWithdrawal(c *gin.Context) {
+	context := c.Request.Context()
+
+	var payload model.CreateWithdrawal
+
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, payload)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.RequestWithdrawal(context, &payload)
+	responses.REST(c, resp)
+}
+
+func (handler *HTTPHandler) GetWithdrawals(c *gin.Context) {
+	context := c.Request.Context()
+
+	queryString := c.Request.URL.Query()
+
+	page, _ := strconv.Atoi(queryString.Get("page"))
+	size, _ := strconv.Atoi(queryString.Get("size"))
+
+	params := model.GetWithdrawalsParams{
+		Status: queryString.Get("status"),
+		Page:   int64(page),
+		Size:   int64(size),
+	}
+
+	if err := c.ShouldBind(&params); err != nil {
+		if errorFields, ok := err.(validator.ValidationErrors); ok {
+			schemas := validation.RequestBody(errorFields, params)
+			responses.REST(c, httpResponse.BadRequest("").NewResponses(schemas, "Bad Request"))
+			return
+		}
+		responses.REST(c, httpResponse.UnprocessableEntity("").NewResponses(nil, err.Error()))
+		return
+	}
+
+	resp := handler.Usecase.GetWithdrawals(context, &params)
+	responses.REST(c, resp)
+}
@@ -0,0 +1,297 @@
+package withdrawals
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/sirupsen/logrus"
+)
+
+type Repository interface {
+	BeginTx(ctx context.Context) (tx *sql.Tx, err error)
+	RollbackTx(ctx context.Context, tx *sql.Tx) (err error)
+	CommitTx(ctx context.Context, tx *sql.Tx) (err error)
+
+	Insert(ctx context.Context, tx *sql.Tx, withdrawal *entity.Withdrawal) (id int64, err error)
+	// FindOne locks the withdrawal row when tx is given.
+	FindOne(ctx context.Context, tx *sql.Tx, id int64) (withdrawal *entity.Withdrawal, err error)
+	// FindMany and Count list the withdrawals of one user, or of everyone when userId is nil.
+	FindMany(ctx context.Context, userId *int64, params *model.GetWithdrawalsParams) (withdrawals []entity.Withdrawal, err error)
+	Count(ctx context.Context, userId *int64, params *model.GetWithdrawalsParams) (total int64, err error)
+	UpdateStatus(ctx context.Context, tx *sql.Tx, id int64, fromStatus string, toStatus string, updateFields map[string]any) (err error)
+}
+
+type RepositoryImpl struct {
+	DB        *sql.DB
+	Logger    *logrus.Logger
+	TableName string
+}
+
+func NewRepositoryImpl(db *sql.DB, logger *logrus.Logger) Repository {
+	return &RepositoryImpl{
+		DB:        db,
+		Logger:    logger,
+		TableName: "withdrawals",
+	}
+}
+
+func (repo *RepositoryImpl) BeginTx(ctx context.Context) (tx *sql.Tx, err error) {
+	return repo.DB.BeginTx(ctx, nil)
+}
+
+func (repo *RepositoryImpl) RollbackTx(ctx context.Context, tx *sql.Tx) (err error) {
+	return tx.Rollback()
+}
+
+func (repo *RepositoryImpl) CommitTx(ctx context.Context, tx *sql.Tx) (err error) {
+	return tx.Commit()
+}
+
+func (repo *RepositoryImpl) Insert(ctx context.Context, tx *sql.Tx, withdrawal *entity.Withdrawal) (id int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	command := fmt.Sprintf(`
+	INSERT INTO %s
+	SET
+		id = ?,
+		user_id = ?,
+		bank_account_id = ?,
+		amount = ?,
+		status = ?,
+		requested_at = ?
+	`, repo.TableName)
+
+	result, err := Exec(ctx, cmd, command, withdrawal.ID, withdrawal.UserId, withdrawal.BankAccountId, withdrawal.Amount, withdrawal.Status, withdrawal.RequestedAt)
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return
+	}
+
+	if id, err = result.LastInsertId(); err != nil {
+		return
+	}
+	return
+}
+
+func (repo *RepositoryImpl) FindOne(ctx context.Context, tx *sql.Tx, id int64) (withdrawal *entity.Withdrawal, err error) {
+	var cmd SqlCommand = repo.DB
+
+	lock := ""
+	if tx != nil {
+		cmd = tx
+		lock = "FOR UPDATE"
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM
+		%s w
+		JOIN bank_accounts ba ON ba.id = w.bank_account_id
+	WHERE
+		w.id = ?
+	%s
+	`, withdrawalColumns, repo.TableName, lock)
+
+	withdrawals, err := repo.Query(ctx, cmd, query, id)
+	if err != nil {
+		return
+	}
+
+	withdrawal = &withdrawals[len(withdrawals)-1]
+
+	return
+}
+
+func (repo *RepositoryImpl) FindMany(ctx context.Context, userId *int64, params *model.GetWithdrawalsParams) (withdrawals []entity.Withdrawal, err error) {
+	var cmd SqlCommand = repo.DB
+
+	var offset = (params.Page - 1) * params.Size
+
+	where, args := withdrawalFilter(userId, params)
+
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM
+		%s w
+		JOIN bank_accounts ba ON ba.id = w.bank_account_id
+	%s
+	ORDER BY
+		w.id DESC
+	LIMIT %d OFFSET %d
+	`, withdrawalColumns, repo.TableName, where, params.Size, offset)
+
+	return repo.Query(ctx, cmd, query, args...)
+}
+
+func (repo *RepositoryImpl) Count(ctx context.Context, userId *int64, params *model.GetWithdrawalsParams) (total int64, err error) {
+	var cmd SqlCommand = repo.DB
+
+	where, args := withdrawalFilter(userId, params)
+
+	query := fmt.Sprintf(`SELECT COUNT(w.id) FROM %s w %s`, repo.TableName, where)
+
+	if err = cmd.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		repo.Logger.WithContext(ctx).Error(query, err.Error())
+		return
+	}
+
+	return
+}
+
+// UpdateStatus moves a withdrawal from one status to another together with the audit fields of the transition.
+// It returns exception.ErrConflict when the withdrawal is no longer in fromStatus.
+func (repo *RepositoryImpl) UpdateStatus(ctx context.Context, tx *sql.Tx, id int64, fromStatus string, toStatus string, updateFields map[string]any) (err error) {
+	var cmd SqlCommand = repo.DB
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	placeholders := []string{"status = ?"}
+	values := []interface{}{toStatus}
+
+	for field, value := range updateFields {
+		placeholders = append(placeholders, field+" = ?")
+		values = append(values, value)
+	}
+
+	command := fmt.Sprintf("UPDATE %s SET %s WHERE id = ? AND status = ?", repo.TableName, strings.Join(placeholders, ", "))
+	values = append(values, id, fromStatus)
+
+	result, err := Exec(ctx, cmd, command, values...)
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repo.Logger.WithContext(ctx).Error(command, err.Error())
+		return exception.ErrInternalServer
+	}
+
+	if affected < 1 {
+		return exception.ErrConflict
+	}
+
+	return
+}
+
+const withdrawalColumns = `
+		w.id,
+		w.user_id,
+		w.bank_account_id,
+		w.amount,
+		w.status,
+		w.rejection_reason,
+		w.payout_reference,
+		w.requested_at,
+		w.approved_at,
+		w.approved_by,
+		w.rejected_at,
+		w.rejected_by,
+		w.paid_at,
+		w.paid_by,
+		ba.bank_code,
+		ba.account_number,
+		ba.account_holder`
+
+func withdrawalFilter(userId *int64, params *model.GetWithdrawalsParams) (where string, args []interface{}) {
+	var conditions []string
+
+	if userId != nil {
+		conditions = append(conditions, "w.user_id = ?")
+		args = append(args, *userId)
+	}
+
+	if params.Status != "" {
+		conditions = append(conditions, "w.status = ?")
+		args = append(args, params.Status)
+	}
+
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	return
+}
+
+// ==================================================================================================================== //
+type SqlCommand interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// ==================================================================================================================== //
+
+func Exec(ctx context.Context, cmd SqlCommand, command string, args ...interface{}) (result sql.Result, err error) {
+	var stmt *sql.Stmt
+	if stmt, err = cmd.PrepareContext(ctx, command); err != nil {
+		return
+	}
+
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			return
+		}
+	}()
+
+	if result, err = stmt.ExecContext(ctx, args...); err != nil {
+		return
+	}
+
+	return
+}
+
+func (repo *RepositoryImpl) Query(ctx context.Context, cmd SqlCommand, query string, args ...interface{}) (withdrawals []entity.Withdrawal, err error) {
+
+	var rows *sql.Rows
+	if rows, err = cmd.QueryContext(ctx, query, args...); err != nil {
+		repo.Logger.Error(err.Error())
+		return
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+	}()
+
+	for rows.Next() {
+		var withdrawal entity.Withdrawal
+		bankAccount := &entity.BankAccount{}
+
+		err = rows.Scan(&withdrawal.ID, &withdrawal.UserId, &withdrawal.BankAccountId, &withdrawal.Amount, &withdrawal.Status, &withdrawal.RejectionReason, &withdrawal.PayoutReference, &withdrawal.RequestedAt, &withdrawal.ApprovedAt, &withdrawal.ApprovedBy, &withdrawal.RejectedAt, &withdrawal.RejectedBy, &withdrawal.PaidAt, &withdrawal.PaidBy, &bankAccount.BankCode, &bankAccount.AccountNumber, &bankAccount.AccountHolder)
+		if err != nil {
+			repo.Logger.Error(err.Error())
+			return
+		}
+
+		bankAccount.ID = withdrawal.BankAccountId
+		bankAccount.UserId = withdrawal.UserId
+		withdrawal.BankAccount = bankAccount
+
+		withdrawals = append(withdrawals, withdrawal)
+	}
+
+	if withdrawals == nil {
+		err = exception.ErrNotFound
+		return
+	}
+
+	return
+}
@@ -0,0 +1,195 @@
+package withdrawals
+
+import (
+	"context"
+
+	"github.com/Difaal21/nebeng-dong/entity"
+	"github.com/Difaal21/nebeng-dong/exception"
+	"github.com/Difaal21/nebeng-dong/helpers/date"
+	"github.com/Difaal21/nebeng-dong/model"
+	"github.com/Difaal21/nebeng-dong/modules/wallet"
+	"github.com/Difaal21/nebeng-dong/responses"
+	"github.com/sirupsen/logrus"
+)
+
+// A withdrawal is requested by a driver, then approved and paid, or rejected, by an administrator.
+// The amount is held from the coin balance on request and only given back when rejected.
+const (
+	StatusRequested = "requested"
+	StatusApproved  = "approved"
+	StatusPaid      = "paid"
+	StatusRejected  = "rejected"
+)
+
+type Usecase interface {
+	AddBankAccount(ctx context.Context, payload *model.CreateBankAccount) responses.Responses
+	GetBankAccounts(ctx context.Context) responses.Responses
+	RequestWithdrawal(ctx context.Context, payload *model.CreateWithdrawal) responses.Responses
+	GetWithdrawals(ctx context.Context, params *model.GetWithdrawalsParams) responses.Responses
+}
+
+type UsecaseImpl struct {
+	Repository            Repository
+	BankAccountRepository BankAccountRepository
+	Ledger                wallet.Ledger
+	Logger                *logrus.Logger
+}
+
+func NewUsecaseImpl(repo Repository, bankAccountRepository BankAccountRepository, ledger wallet.Ledger, logger *logrus.Logger) Usecase {
+	return &UsecaseImpl{
+		Repository:            repo,
+		BankAccountRepository: bankAccountRepository,
+		Ledger:                ledger,
+		Logger:                logger,
+	}
+}
+
+func (u *UsecaseImpl) AddBankAccount(ctx context.Context, payload *model.CreateBankAccount) responses.Responses {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if !requester.IsDriver {
+		return httpResponse.Forbidden("").NewResponses(nil, "only drivers can register a payout account")
+	}
+
+	account := &entity.BankAccount{
+		UserId:        requester.ID,
+		BankCode:      payload.BankCode,
+		AccountNumber: payload.AccountNumber,
+		AccountHolder: payload.AccountHolder,
+		CreatedAt:     *date.CurrentUTCTime(),
+	}
+
+	if account.ID, err = u.BankAccountRepository.Insert(ctx, nil, account); err != nil {
+		if err == exception.ErrConflict {
+			return httpResponse.Conflict("").NewResponses(nil, "bank account already registered")
+		}
+		u.Logger.WithContext(ctx).WithFields(logrus.Fields{"requester": requester, "account": account}).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Created("").NewResponses(account, "bank account registered")
+}
+
+func (u *UsecaseImpl) GetBankAccounts(ctx context.Context) responses.Responses {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	accounts, err := u.BankAccountRepository.FindManyByUser(ctx, requester.ID)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithContext(ctx).WithField("requester", requester).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if accounts == nil {
+		return httpResponse.NotFound("").NewResponses(nil, "no bank account")
+	}
+
+	return httpResponse.Ok("").NewResponses(accounts, "get bank accounts success")
+}
+
+func (u *UsecaseImpl) RequestWithdrawal(ctx context.Context, payload *model.CreateWithdrawal) responses.Responses {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if !requester.IsDriver {
+		return httpResponse.Forbidden("").NewResponses(nil, "only drivers can withdraw")
+	}
+
+	account, err := u.BankAccountRepository.FindOne(ctx, payload.BankAccountId)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithContext(ctx).WithField("payload", payload).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if account == nil || account.UserId != requester.ID {
+		return httpResponse.NotFound("").NewResponses(nil, "bank account not found")
+	}
+
+	withdrawal := &entity.Withdrawal{
+		UserId:        requester.ID,
+		BankAccountId: account.ID,
+		Amount:        payload.Amount,
+		Status:        StatusRequested,
+		RequestedAt:   *date.CurrentUTCTime(),
+		BankAccount:   account,
+	}
+
+	fields := logrus.Fields{"requester": requester, "withdrawal": withdrawal}
+
+	tx, err := u.Repository.BeginTx(ctx)
+	if err != nil {
+		u.Logger.WithContext(ctx).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if withdrawal.ID, err = u.Repository.Insert(ctx, tx, withdrawal); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	hold := &wallet.Entry{
+		UserId:        requester.ID,
+		Amount:        withdrawal.Amount,
+		ReferenceType: wallet.ReferenceWithdrawal,
+		ReferenceId:   &withdrawal.ID,
+		Description:   "withdrawal hold",
+	}
+
+	if _, err = u.Ledger.Debit(ctx, tx, hold); err != nil {
+		u.Repository.RollbackTx(ctx, tx)
+		if err == exception.ErrInsufficientBalance {
+			return httpResponse.BadRequest("INSUFFICIENT_BALANCE").NewResponses(nil, "coin balance does not cover the withdrawal")
+		}
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if err = u.Repository.CommitTx(ctx, tx); err != nil {
+		u.Logger.WithContext(ctx).WithFields(fields).Error(err)
+		u.Repository.RollbackTx(ctx, tx)
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	return httpResponse.Created("").NewResponses(withdrawal, "withdrawal requested")
+}
+
+func (u *UsecaseImpl) GetWithdrawals(ctx context.Context, params *model.GetWithdrawalsParams) responses.Responses {
+
+	requester, err := model.GetRequester(ctx)
+	if err != nil {
+		u.Logger.WithField("requester", requester).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	totalData, err := u.Repository.Count(ctx, &requester.ID, params)
+	if err != nil {
+		u.Logger.WithFields(logrus.Fields{"requester": requester, "params": params}).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	withdrawals, err := u.Repository.FindMany(ctx, &requester.ID, params)
+	if err != nil && err != exception.ErrNotFound {
+		u.Logger.WithFields(logrus.Fields{"requester": requester, "params": params}).Error(err.Error())
+		return httpResponse.InternalServerError("").NewResponses(nil, err.Error())
+	}
+
+	if withdrawals == nil {
+		return httpResponse.NotFound("").NewResponses(nil, "no withdrawal")
+	}
+
+	return httpResponse.Ok("").NewResponsesOffsetPagination(withdrawals, int64(len(withdrawals)), totalData, "get withdrawals success")
+}
@@ -0,0 +1,132 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var ErrBrokerClosed = errors.New("broker closed")
+
+// Broker fans messages out to every subscriber of a topic.
+// LocalBroker keeps everything in process, a networked broker (e.g. Redis) can be plugged in by implementing this interface.
+type Broker interface {
+	Publish(ctx context.Context, topic string, payload []byte) (err error)
+	Subscribe(ctx context.Context, topic string) (subscription Subscription, err error)
+	Close() (err error)
+}
+
+type Subscription interface {
+	Messages() <-chan []byte
+	Close()
+}
+
+// subscriberBuffer is how many messages a slow subscriber may fall behind before new ones are dropped for it.
+const subscriberBuffer = 16
+
+type LocalBroker struct {
+	mu     sync.RWMutex
+	topics map[string]map[*localSubscription]struct{}
+	closed bool
+}
+
+func NewLocalBroker() Broker {
+	return &LocalBroker{
+		topics: make(map[string]map[*localSubscription]struct{}),
+	}
+}
+
+func (b *LocalBroker) Publish(ctx context.Context, topic string, payload []byte) (err error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return ErrBrokerClosed
+	}
+
+	for subscription := range b.topics[topic] {
+		select {
+		case subscription.messages <- payload:
+		default:
+		}
+	}
+
+	return
+}
+
+func (b *LocalBroker) Subscribe(ctx context.Context, topic string) (subscription Subscription, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrBrokerClosed
+	}
+
+	local := &localSubscription{
+		broker:   b,
+		topic:    topic,
+		messages: make(chan []byte, subscriberBuffer),
+	}
+
+	if b.topics[topic] == nil {
+		b.topics[topic] = make(map[*localSubscription]struct{})
+	}
+	b.topics[topic][local] = struct{}{}
+
+	return local, nil
+}
+
+// Close ends every subscription, their message channels are closed.
+func (b *LocalBroker) Close() (err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.closed = true
+	for topic, subscriptions := range b.topics {
+		for subscription := range subscriptions {
+			close(subscription.messages)
+		}
+		delete(b.topics, topic)
+	}
+
+	return
+}
+
+func (b *LocalBroker) unsubscribe(subscription *localSubscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subscriptions, ok := b.topics[subscription.topic]
+	if !ok {
+		return
+	}
+
+	if _, ok := subscriptions[subscription]; !ok {
+		return
+	}
+
+	delete(subscriptions, subscription)
+	close(subscription.messages)
+
+	if len(subscriptions) == 0 {
+		delete(b.topics, subscription.topic)
+	}
+}
+
+type localSubscription struct {
+	broker   *LocalBroker
+	topic    string
+	messages chan []byte
+}
+
+func (s *localSubscription) Messages() <-chan []byte {
+	return s.messages
+}
+
+func (s *localSubscription) Close() {
+	s.broker.unsubscribe(s)
+}